@@ -66,7 +66,7 @@ func (api *authApi) authRefresh(c echo.Context) error {
 
 	// destroy previous tokens
 	user.RefreshTokenKey()
-	api.app.Dao().Save(user)
+	RequestDao(api.app, c).Save(user)
 
 	return api.authResponse(c, user)
 }
@@ -79,7 +79,7 @@ func (api *authApi) logout(c echo.Context) error {
 
 	// destroy previous tokens
 	user.RefreshTokenKey()
-	api.app.Dao().Save(user)
+	RequestDao(api.app, c).Save(user)
 
 	return c.NoContent(http.StatusOK)
 }
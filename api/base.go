@@ -70,6 +70,8 @@ func InitApi(app core.App) (*echo.Echo, error) {
 	}
 
 	bindAuthApi(app, e)
+	bindMetricsApi(app, e)
+	bindSettingsApi(app, e)
 
 	// trigger the custom BeforeServe hook for the created api router
 	// allowing users to further adjust its options or register new routes
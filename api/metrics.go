@@ -0,0 +1,31 @@
+package api
+
+import (
+	"github.com/har4s/ohmygo/core"
+	"github.com/har4s/ohmygo/model"
+	"github.com/labstack/echo/v4"
+)
+
+// bindMetricsApi registers the metrics api endpoints and the corresponding handlers.
+func bindMetricsApi(app core.App, rg *echo.Echo) {
+	api := metricsApi{app: app}
+	subGroup := rg.Group("/metrics", RequireAuth(), RequirePermission(model.PermissionMetricsView))
+	subGroup.GET("/db", api.db)
+}
+
+type metricsApi struct {
+	app core.App
+}
+
+// db serves the db statements counters and latency histograms
+// in the Prometheus text exposition format.
+func (api *metricsApi) db(c echo.Context) error {
+	db := api.app.DB()
+	if db == nil || db.Metrics == nil {
+		return NewNotFoundError("The db metrics are not enabled.", nil)
+	}
+
+	db.Metrics.ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
@@ -1,10 +1,13 @@
 package api
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/har4s/ohmygo/core"
+	"github.com/har4s/ohmygo/dao"
 	"github.com/har4s/ohmygo/model"
 	"github.com/har4s/ohmygo/tokens"
 	"github.com/har4s/ohmygo/tools/security"
@@ -14,17 +17,131 @@ import (
 
 // Common request context keys used by the middlewares and api handlers.
 const (
+	ContextAppKey  string = "app"
 	ContextUserKey string = "user"
 )
 
+// requestContextKey defines the type of the keys used to store
+// values in the request context (to avoid collisions with other packages).
+type requestContextKey string
+
+// RequestContextUser returns the auth user stored in the provided
+// request context by the LoadAuthContext middleware (if any).
+//
+// It is useful in places where the echo context is not available,
+// eg. in model hooks triggered by a request bound Dao (see RequestDao).
+func RequestContextUser(ctx context.Context) *model.User {
+	if ctx == nil {
+		return nil
+	}
+
+	user, _ := ctx.Value(requestContextKey(ContextUserKey)).(*model.User)
+
+	return user
+}
+
+// RequestDao returns the app Dao associated with the request context.
+func RequestDao(app core.App, c echo.Context) *dao.Dao {
+	return app.Dao().WithContext(c.Request().Context())
+}
+
+// Authorization rules of the Require* middlewares (see core.AuthorizeEvent).
+const (
+	AuthRuleAuth            string = "auth"
+	AuthRuleAdmin           string = "admin"
+	AuthRuleSuperadmin      string = "superadmin"
+	AuthRuleSameUserOrAdmin string = "sameUserOrAdmin"
+	AuthRulePermission      string = "permission"
+)
+
 // RequireAuth middleware requires a request to have
 // a valid user Authorization header.
 func RequireAuth() echo.MiddlewareFunc {
+	return requireRule(AuthRuleAuth, "", func(c echo.Context, user *model.User) bool {
+		return true
+	})
+}
+
+// RequireAdmin middleware requires the request auth user to be an admin or a superadmin.
+// It is expected to be used together with RequireAuth.
+func RequireAdmin() echo.MiddlewareFunc {
+	return requireRule(AuthRuleAdmin, "", func(c echo.Context, user *model.User) bool {
+		return user.IsAdmin() || user.IsSuperadmin()
+	})
+}
+
+// RequireSuperadmin middleware requires the request auth user to be a superadmin.
+// It is expected to be used together with RequireAuth.
+func RequireSuperadmin() echo.MiddlewareFunc {
+	return requireRule(AuthRuleSuperadmin, "", func(c echo.Context, user *model.User) bool {
+		return user.IsSuperadmin()
+	})
+}
+
+// RequireSameUserOrAdmin middleware requires the request auth user to be
+// either the user with id from the specified path param (default to "id")
+// or an admin or a superadmin.
+// It is expected to be used together with RequireAuth.
+func RequireSameUserOrAdmin(param string) echo.MiddlewareFunc {
+	if param == "" {
+		param = "id"
+	}
+
+	return requireRule(AuthRuleSameUserOrAdmin, "", func(c echo.Context, user *model.User) bool {
+		id := c.Param(param)
+
+		return (id != "" && id == user.Id) || user.IsAdmin() || user.IsSuperadmin()
+	})
+}
+
+// RequirePermission middleware requires the request auth user to have a role
+// granting the provided permission (eg. "users.delete").
+//
+// It is expected to be used together with RequireAuth.
+func RequirePermission(permission string) echo.MiddlewareFunc {
+	return requireRule(AuthRulePermission, permission, func(c echo.Context, user *model.User) bool {
+		return user.HasPermission(permission)
+	})
+}
+
+// requireRule creates a new middleware that allows the request
+// only if the request auth user passes the check.
+//
+// The decision could be changed by the app OnBeforeAuthorize hook.
+// The app is loaded from the context (see LoadAuthContext) and if missing
+// the request is always rejected, so that the hook couldn't be bypassed.
+//
+// Note that the request is also rejected if the hook allows a guest
+// (aka. a request without auth user), because the api handlers behind
+// the Require* middlewares expect the auth user to be set.
+func requireRule(rule string, permission string, check func(c echo.Context, user *model.User) bool) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			app, _ := c.Get(ContextAppKey).(core.App)
+			if app == nil {
+				return NewForbiddenError("You are not allowed to perform this request.", nil)
+			}
+
 			user, _ := c.Get(ContextUserKey).(*model.User)
-			if user == nil {
-				return NewUnauthorizedError("The request requires valid user authorization token to be set.", nil)
+
+			event := &core.AuthorizeEvent{
+				HttpContext: c,
+				User:        user,
+				Rule:        rule,
+				Permission:  permission,
+				Allowed:     user != nil && check(c, user),
+			}
+
+			if err := app.OnBeforeAuthorize().Trigger(event); err != nil {
+				return err
+			}
+
+			if !event.Allowed || user == nil {
+				if rule == AuthRuleAuth {
+					return NewUnauthorizedError("The request requires valid user authorization token to be set.", nil)
+				}
+
+				return NewForbiddenError("You are not allowed to perform this request.", nil)
 			}
 
 			return next(c)
@@ -33,12 +150,19 @@ func RequireAuth() echo.MiddlewareFunc {
 }
 
 // LoadAuthContext middleware reads the Authorization request header
-// and loads the token related user instance into the request's context.
+// and loads the token related user instance (together with its roles)
+// into the request's context.
+//
+// It also stores the app instance in the request's context (see ContextAppKey)
+// so that the Require* middlewares could trigger the app OnBeforeAuthorize hook.
 //
 // This middleware is expected to be already registered by default for all routes.
 func LoadAuthContext(app core.App) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			// make the app accessible to the other middlewares (eg. RequireAdmin)
+			c.Set(ContextAppKey, app)
+
 			token := c.Request().Header.Get("Authorization")
 			if token == "" {
 				return next(c)
@@ -55,10 +179,20 @@ func LoadAuthContext(app core.App) echo.MiddlewareFunc {
 			case tokens.TypeUser:
 				user, err := app.Dao().FindUserByToken(
 					token,
-					app.Settings().UserAuthToken.Secret,
+					app.Settings().UserAuthToken.Secret.String(),
 				)
 				if err == nil && user != nil {
+					// on failure the user is loaded without roles (aka. without permissions)
+					if err := app.Dao().ExpandUserRoles(user); err != nil && app.IsDebug() {
+						log.Println("Failed to load the user roles:", err)
+					}
+
 					c.Set(ContextUserKey, user)
+
+					// store the user also in the request context to make it
+					// accessible outside of the handlers (eg. in the model hooks)
+					ctx := context.WithValue(c.Request().Context(), requestContextKey(ContextUserKey), user)
+					c.SetRequest(c.Request().WithContext(ctx))
 				}
 			}
 
@@ -0,0 +1,175 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/har4s/ohmygo/api"
+	"github.com/har4s/ohmygo/core"
+	"github.com/har4s/ohmygo/model"
+	"github.com/labstack/echo/v4"
+)
+
+func TestRequireMiddlewares(t *testing.T) {
+	app := core.NewBaseApp(&core.BaseAppConfig{})
+
+	admin := &model.User{Roles: []*model.Role{{Name: model.RoleAdmin, Permissions: []string{"users.*"}}}}
+	admin.Id = "admin"
+
+	superadmin := &model.User{Roles: []*model.Role{{Name: model.RoleSuperadmin, Permissions: []string{"*"}}}}
+	superadmin.Id = "superadmin"
+
+	regular := &model.User{}
+	regular.Id = "regular"
+
+	scenarios := []struct {
+		name           string
+		middleware     echo.MiddlewareFunc
+		user           *model.User
+		paramId        string
+		expectedStatus int
+	}{
+		{"auth guest", api.RequireAuth(), nil, "", http.StatusUnauthorized},
+		{"auth user", api.RequireAuth(), regular, "", http.StatusOK},
+		{"admin guest", api.RequireAdmin(), nil, "", http.StatusForbidden},
+		{"admin regular", api.RequireAdmin(), regular, "", http.StatusForbidden},
+		{"admin admin", api.RequireAdmin(), admin, "", http.StatusOK},
+		{"admin superadmin", api.RequireAdmin(), superadmin, "", http.StatusOK},
+		{"superadmin admin", api.RequireSuperadmin(), admin, "", http.StatusForbidden},
+		{"superadmin superadmin", api.RequireSuperadmin(), superadmin, "", http.StatusOK},
+		{"same user guest", api.RequireSameUserOrAdmin(""), nil, "regular", http.StatusForbidden},
+		{"same user other", api.RequireSameUserOrAdmin(""), regular, "other", http.StatusForbidden},
+		{"same user self", api.RequireSameUserOrAdmin(""), regular, "regular", http.StatusOK},
+		{"same user admin", api.RequireSameUserOrAdmin(""), admin, "other", http.StatusOK},
+		{"permission regular", api.RequirePermission("users.delete"), regular, "", http.StatusForbidden},
+		{"permission admin", api.RequirePermission("users.delete"), admin, "", http.StatusOK},
+		{"permission admin missing", api.RequirePermission("settings.update"), admin, "", http.StatusForbidden},
+	}
+
+	for _, s := range scenarios {
+		status := runMiddleware(app, s.middleware, s.user, s.paramId)
+		if status != s.expectedStatus {
+			t.Errorf("[%s] Expected status %d, got %d", s.name, s.expectedStatus, status)
+		}
+	}
+}
+
+func TestRequireMiddlewaresWithoutApp(t *testing.T) {
+	superadmin := &model.User{Roles: []*model.Role{{Name: model.RoleSuperadmin, Permissions: []string{"*"}}}}
+	superadmin.Id = "superadmin"
+
+	scenarios := []struct {
+		name       string
+		middleware echo.MiddlewareFunc
+	}{
+		{"auth", api.RequireAuth()},
+		{"admin", api.RequireAdmin()},
+		{"permission", api.RequirePermission("users.delete")},
+	}
+
+	for _, s := range scenarios {
+		status := runMiddleware(nil, s.middleware, superadmin, "")
+		if status != http.StatusForbidden {
+			t.Errorf("[%s] Expected status %d, got %d", s.name, http.StatusForbidden, status)
+		}
+	}
+}
+
+func TestRequireMiddlewaresOnBeforeAuthorize(t *testing.T) {
+	app := core.NewBaseApp(&core.BaseAppConfig{})
+
+	regular := &model.User{}
+	regular.Id = "regular"
+
+	var lastEvent *core.AuthorizeEvent
+
+	app.OnBeforeAuthorize().Add(func(e *core.AuthorizeEvent) error {
+		lastEvent = e
+
+		switch e.Permission {
+		case "posts.create":
+			e.Allowed = true
+		case "posts.delete":
+			return api.NewNotFoundError("", nil)
+		}
+
+		return nil
+	})
+
+	scenarios := []struct {
+		name           string
+		middleware     echo.MiddlewareFunc
+		expectedStatus int
+		expectedRule   string
+	}{
+		{"unchanged", api.RequireAdmin(), http.StatusForbidden, api.AuthRuleAdmin},
+		{"allowed by the hook", api.RequirePermission("posts.create"), http.StatusOK, api.AuthRulePermission},
+		{"hook error", api.RequirePermission("posts.delete"), http.StatusNotFound, api.AuthRulePermission},
+	}
+
+	for _, s := range scenarios {
+		lastEvent = nil
+
+		status := runMiddleware(app, s.middleware, regular, "")
+		if status != s.expectedStatus {
+			t.Errorf("[%s] Expected status %d, got %d", s.name, s.expectedStatus, status)
+		}
+
+		if lastEvent == nil {
+			t.Errorf("[%s] Expected the OnBeforeAuthorize hook to be triggered", s.name)
+			continue
+		}
+
+		if lastEvent.Rule != s.expectedRule {
+			t.Errorf("[%s] Expected rule %q, got %q", s.name, s.expectedRule, lastEvent.Rule)
+		}
+
+		if lastEvent.User != regular {
+			t.Errorf("[%s] Expected the request user in the event, got %v", s.name, lastEvent.User)
+		}
+	}
+
+	// guests are rejected even if allowed by the hook
+	lastEvent = nil
+	if status := runMiddleware(app, api.RequirePermission("posts.create"), nil, ""); status != http.StatusForbidden {
+		t.Errorf("Expected the guest request to be rejected, got %d", status)
+	}
+	if lastEvent == nil {
+		t.Errorf("Expected the OnBeforeAuthorize hook to be triggered for the guest request")
+	}
+}
+
+// runMiddleware executes the middleware with a noop handler and returns the response status.
+func runMiddleware(app core.App, middleware echo.MiddlewareFunc, user *model.User, paramId string) int {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if app != nil {
+		c.Set(api.ContextAppKey, app)
+	}
+	if user != nil {
+		c.Set(api.ContextUserKey, user)
+	}
+	if paramId != "" {
+		c.SetParamNames("id")
+		c.SetParamValues(paramId)
+	}
+
+	err := middleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})(c)
+
+	var apiErr *api.ApiError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+
+	return rec.Code
+}
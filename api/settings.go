@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/har4s/ohmygo/core"
+	"github.com/har4s/ohmygo/forms"
+	"github.com/har4s/ohmygo/model"
+	"github.com/har4s/ohmygo/model/settings"
+	"github.com/labstack/echo/v4"
+)
+
+// bindSettingsApi registers the settings api endpoints.
+func bindSettingsApi(app core.App, rg *echo.Echo) {
+	api := settingsApi{app: app}
+
+	subGroup := rg.Group("/settings", RequireAuth())
+	subGroup.GET("", api.list, RequirePermission(model.PermissionSettingsView))
+	subGroup.PATCH("", api.set, RequirePermission(model.PermissionSettingsUpdate))
+}
+
+type settingsApi struct {
+	app core.App
+}
+
+// settingsResponse defines the settings api response data.
+type settingsResponse struct {
+	*settings.Settings
+
+	// Locked lists the json paths of the settings fields overridden
+	// by the settings file or environment variables (eg. "smtp.host").
+	Locked []string `json:"locked"`
+}
+
+func (api *settingsApi) response(redactedSettings *settings.Settings) *settingsResponse {
+	locked := api.app.LockedSettings()
+	if locked == nil {
+		locked = []string{}
+	}
+
+	return &settingsResponse{
+		Settings: redactedSettings,
+		Locked:   locked,
+	}
+}
+
+func (api *settingsApi) list(c echo.Context) error {
+	settings, err := api.app.Settings().RedactClone()
+	if err != nil {
+		return NewBadRequestError("", err)
+	}
+
+	event := &core.SettingsListEvent{
+		HttpContext:      c,
+		RedactedSettings: settings,
+	}
+
+	return api.app.OnSettingsListRequest().Trigger(event, func(e *core.SettingsListEvent) error {
+		return e.HttpContext.JSON(http.StatusOK, api.response(e.RedactedSettings))
+	})
+}
+
+func (api *settingsApi) set(c echo.Context) error {
+	form := forms.NewSettingsUpsert(api.app)
+	form.SetDao(RequestDao(api.app, c))
+
+	// load request
+	if err := c.Bind(form); err != nil {
+		return NewBadRequestError("An error occurred while loading the submitted data.", err)
+	}
+
+	oldSettings, err := api.app.Settings().Clone()
+	if err != nil {
+		return NewBadRequestError("", err)
+	}
+
+	event := &core.SettingsUpdateEvent{
+		HttpContext: c,
+		OldSettings: oldSettings,
+		NewSettings: form.Settings,
+	}
+
+	// update the settings
+	submitErr := form.Submit(func(next forms.InterceptorNextFunc) forms.InterceptorNextFunc {
+		return func() error {
+			return api.app.OnSettingsBeforeUpdateRequest().Trigger(event, func(e *core.SettingsUpdateEvent) error {
+				if err := next(); err != nil {
+					return NewBadRequestError("An error occurred while submitting the form.", err)
+				}
+
+				redactedSettings, err := api.app.Settings().RedactClone()
+				if err != nil {
+					return NewBadRequestError("", err)
+				}
+
+				return e.HttpContext.JSON(http.StatusOK, api.response(redactedSettings))
+			})
+		}
+	})
+
+	if submitErr == nil {
+		api.app.OnSettingsAfterUpdateRequest().Trigger(event)
+	}
+
+	return submitErr
+}
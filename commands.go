@@ -0,0 +1,46 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/har4s/ohmygo/core"
+)
+
+// runCommand executes the cli command with the provided arguments
+// (eg. "go run . settings:encrypt").
+func runCommand(app core.App, args []string) error {
+	switch args[0] {
+	case "settings:encrypt":
+		return encryptSettings(app)
+	}
+
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// encryptSettings encrypts in place the stored settings with the
+// ENCRYPTION_KEY primary key (eg. after enabling the encryption or rotating the key).
+func encryptSettings(app core.App) error {
+	keyring := app.Dao().EncryptionKeyring
+	if keyring == nil {
+		return errors.New("missing ENCRYPTION_KEY environment variable")
+	}
+
+	storedSettings, err := app.Dao().FindSettings()
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("there are no stored settings to encrypt")
+		}
+		return err
+	}
+
+	if err := app.Dao().SaveSettings(storedSettings); err != nil {
+		return err
+	}
+
+	color.Green("Successfully encrypted the stored settings with key %q.", keyring.PrimaryId())
+
+	return nil
+}
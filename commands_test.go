@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/har4s/ohmygo/core"
+	"github.com/har4s/ohmygo/migrations"
+	"github.com/har4s/ohmygo/model"
+	"github.com/har4s/ohmygo/model/settings"
+	"github.com/har4s/ohmygo/tools/migrate"
+	"github.com/har4s/ohmygo/tools/types"
+)
+
+const testEncryptionKey = "k1:abcdabcdabcdabcdabcdabcdabcdabcd"
+
+// createTestApp creates a new bootstrapped app with the provided encryption key.
+//
+// The test db is cleaned and fully migrated only if reset is true.
+func createTestApp(t *testing.T, encryptionKey string, reset bool) *core.BaseApp {
+	app := core.NewBaseApp(&core.BaseAppConfig{
+		DatabaseURL:   "har4s:@/ozzo_dbx_test?parseTime=true",
+		EncryptionKey: encryptionKey,
+	})
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		app.ResetBootstrapState()
+	})
+
+	if !reset {
+		return app
+	}
+
+	tables := []string{"user_roles", "roles", "audit_logs", "users", "params", migrate.DefaultMigrationsTable}
+	for _, table := range tables {
+		if _, err := app.DB().NewQuery("DROP TABLE IF EXISTS {{" + table + "}}").Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runner, err := migrate.NewRunner(app.DB(), migrations.Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	return app
+}
+
+func TestRunCommandUnknown(t *testing.T) {
+	if err := runCommand(nil, []string{"unknown"}); err == nil {
+		t.Fatal("Expected unknown command error, got nil")
+	}
+}
+
+func TestRunCommandSettingsEncrypt(t *testing.T) {
+	plainApp := createTestApp(t, "", true)
+
+	// missing encryption key
+	if err := runCommand(plainApp, []string{"settings:encrypt"}); err == nil {
+		t.Fatal("Expected missing encryption key error, got nil")
+	}
+
+	storedSettings := settings.New()
+	storedSettings.Meta.AppName = "test_app"
+	if err := plainApp.Dao().SaveSettings(storedSettings); err != nil {
+		t.Fatal(err)
+	}
+
+	app := createTestApp(t, testEncryptionKey, false)
+
+	if err := runCommand(app, []string{"settings:encrypt"}); err != nil {
+		t.Fatal(err)
+	}
+
+	param, err := app.Dao().FindParamByKey(model.ParamAppSettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stored string
+	if err := json.Unmarshal(param.Value, &stored); err != nil || !types.IsEncrypted(stored) {
+		t.Fatalf("Expected encrypted settings, got %s", param.Value)
+	}
+
+	result, err := app.Dao().FindSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Meta.AppName != "test_app" {
+		t.Fatalf("Expected app name %q, got %q", "test_app", result.Meta.AppName)
+	}
+
+	// the encrypted settings can't be loaded without the encryption key
+	if _, err := plainApp.Dao().FindSettings(); err == nil {
+		t.Fatal("Expected decrypt error, got nil")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Env struct {
@@ -12,6 +13,22 @@ type Env struct {
 	Port           int // default 8000
 	DatabaseURL    string
 	SkipMigrations bool // default false
+
+	// SlowQueryThreshold is the min duration of the logged slow db queries
+	// (default 0, aka. disabled)
+	SlowQueryThreshold time.Duration
+
+	// EncryptionKey is the optional key used to encrypt the stored settings secrets
+	// (comma separated "keyId:key" pairs for key rotation, the first one is the primary)
+	EncryptionKey string
+
+	// SettingsFile is the optional YAML or JSON file overriding the stored settings
+	// (the "OHMYGO_"-prefixed env variables, eg. OHMYGO_SMTP_HOST, have precedence over it)
+	SettingsFile string
+
+	// SettingsPollInterval is how often the stored settings are checked
+	// for changes made by other app instances (default 0, aka. disabled)
+	SettingsPollInterval time.Duration
 }
 
 func NewEnv() *Env {
@@ -40,6 +57,24 @@ func NewEnv() *Env {
 		env.SkipMigrations = v
 	}
 
+	// in milliseconds
+	if v, ok := env.GetInt("SLOW_QUERY_THRESHOLD"); ok {
+		env.SlowQueryThreshold = time.Duration(v) * time.Millisecond
+	}
+
+	if v, ok := env.Get("ENCRYPTION_KEY"); ok {
+		env.EncryptionKey = v
+	}
+
+	if v, ok := env.Get("SETTINGS_FILE"); ok {
+		env.SettingsFile = v
+	}
+
+	// in seconds
+	if v, ok := env.GetInt("SETTINGS_POLL_INTERVAL"); ok {
+		env.SettingsPollInterval = time.Duration(v) * time.Second
+	}
+
 	return env
 }
 
@@ -37,7 +37,14 @@ type App interface {
 	// after you are done working with it.
 	NewFilesystem() (*filesystem.System, error)
 
-	// RefreshSettings reinitializes and reloads the stored application settings.
+	// LockedSettings returns the sorted json paths of the settings fields
+	// overridden by the settings file or environment variables (eg. "smtp.host").
+	//
+	// The locked settings fields can't be changed with the settings API.
+	LockedSettings() []string
+
+	// RefreshSettings reinitializes and reloads the stored application settings
+	// (including the settings file and environment variables overrides).
 	RefreshSettings() error
 
 	// IsBootstrapped checks if the application was initialized
@@ -80,6 +87,12 @@ type App interface {
 	// It could be used to log the final API error in external services.
 	OnAfterApiError() *hook.Hook[*ApiErrorEvent]
 
+	// OnSettingsReload hook is triggered after each (re)load of the app settings
+	// (on bootstrap, after a Settings API update or when a change made by
+	// another app instance was detected), allowing the components that depend
+	// on the settings (eg. mail clients, filesystems) to reconfigure.
+	OnSettingsReload() *hook.Hook[*SettingsReloadEvent]
+
 	// ---------------------------------------------------------------
 	// Dao event hooks
 	// ---------------------------------------------------------------
@@ -108,6 +121,29 @@ type App interface {
 	// existing entry from the DB.
 	OnModelAfterDelete() *hook.Hook[*ModelEvent]
 
+	// ---------------------------------------------------------------
+	// Settings API event hooks
+	// ---------------------------------------------------------------
+
+	// OnSettingsListRequest hook is triggered on each successful
+	// API Settings list request.
+	//
+	// Could be used to validate or modify the response before
+	// returning it to the client.
+	OnSettingsListRequest() *hook.Hook[*SettingsListEvent]
+
+	// OnSettingsBeforeUpdateRequest hook is triggered before each API
+	// Settings update request (after request data load and before settings persistence).
+	//
+	// Could be used to additionally validate the request data or
+	// implement completely different persistence behavior
+	// (returning [hook.StopPropagation]).
+	OnSettingsBeforeUpdateRequest() *hook.Hook[*SettingsUpdateEvent]
+
+	// OnSettingsAfterUpdateRequest hook is triggered after each
+	// successful API Settings update request.
+	OnSettingsAfterUpdateRequest() *hook.Hook[*SettingsUpdateEvent]
+
 	// ---------------------------------------------------------------
 	// User API event hooks
 	// ---------------------------------------------------------------
@@ -118,4 +154,14 @@ type App interface {
 	// Could be used to additionally validate or modify the
 	// authenticated user data and token.
 	OnUserAuthRequest() *hook.Hook[*UserAuthEvent]
+
+	// OnBeforeAuthorize hook is triggered by the api Require* middlewares
+	// (RequireAuth, RequireAdmin, RequirePermission, etc.) of each protected
+	// route, right before allowing or denying the request.
+	//
+	// Could be used to plug custom authorization decisions
+	// by changing the event Allowed field or by returning an error.
+	// Note that requests without auth user are always rejected,
+	// even if the event Allowed field is set to true.
+	OnBeforeAuthorize() *hook.Hook[*AuthorizeEvent]
 }
@@ -3,7 +3,11 @@ package core
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fatih/color"
@@ -14,6 +18,7 @@ import (
 	"github.com/har4s/ohmygo/tools/filesystem"
 	"github.com/har4s/ohmygo/tools/hook"
 	"github.com/har4s/ohmygo/tools/mailer"
+	"github.com/har4s/ohmygo/tools/security"
 	"github.com/har4s/ohmygo/tools/store"
 )
 
@@ -22,21 +27,33 @@ const (
 	DefaultDataMaxIdleConns int = 20
 	DefaultLogsMaxOpenConns int = 10
 	DefaultLogsMaxIdleConns int = 2
+
+	// SettingsEnvPrefix is the prefix of the environment variables
+	// overriding the app settings (eg. "OHMYGO_SMTP_HOST").
+	SettingsEnvPrefix = "OHMYGO"
 )
 
 type BaseApp struct {
 	// configurable parameters
-	isDebug          bool
-	databaseUrl      string
-	dataMaxOpenConns int
-	dataMaxIdleConns int
-	logsMaxOpenConns int
-	logsMaxIdleConns int
+	isDebug            bool
+	databaseUrl        string
+	dataMaxOpenConns   int
+	dataMaxIdleConns   int
+	logsMaxOpenConns   int
+	logsMaxIdleConns   int
+	retryPolicy        *dao.RetryPolicy
+	slowQueryThreshold time.Duration
+	encryptionKey      string
+	settingsFile       string
 
 	// internals
-	cache    *store.Store[any]
-	settings *settings.Settings
-	dao      *dao.Dao
+	cache             *store.Store[any]
+	encryptionKeyring *security.Keyring
+	settings          atomic.Pointer[settings.Settings]
+	settingsMux       sync.Mutex
+	settingsVersion   int64
+	lockedSettings    atomic.Pointer[[]string]
+	dao               *dao.Dao
 
 	// app event hooks
 	onBeforeBootstrap *hook.Hook[*BootstrapEvent]
@@ -44,6 +61,7 @@ type BaseApp struct {
 	onBeforeServe     *hook.Hook[*ServeEvent]
 	onBeforeApiError  *hook.Hook[*ApiErrorEvent]
 	onAfterApiError   *hook.Hook[*ApiErrorEvent]
+	onSettingsReload  *hook.Hook[*SettingsReloadEvent]
 
 	// dao event hooks
 	onModelBeforeCreate *hook.Hook[*ModelEvent]
@@ -60,6 +78,7 @@ type BaseApp struct {
 
 	// user api event hooks
 	onUserAuthRequest *hook.Hook[*UserAuthEvent]
+	onBeforeAuthorize *hook.Hook[*AuthorizeEvent]
 }
 
 // BaseAppConfig defines a BaseApp configuration option
@@ -70,6 +89,27 @@ type BaseAppConfig struct {
 	DataMaxIdleConns int // default 20
 	LogsMaxOpenConns int // default to 10
 	LogsMaxIdleConns int // default to 2
+
+	// RetryPolicy specifies how the failed db writes and transactions
+	// are retried (default to dao.DefaultRetryPolicy).
+	RetryPolicy *dao.RetryPolicy
+
+	// SlowQueryThreshold is the min duration of a db query to be logged
+	// as slow together with its caller location (default to 0, aka. disabled).
+	SlowQueryThreshold time.Duration
+
+	// EncryptionKey is the key (or comma separated "keyId:key" keys for rotation,
+	// see [security.ParseKeyring]) used to encrypt the stored settings secrets.
+	//
+	// If empty, the settings secrets are stored as plain text.
+	EncryptionKey string
+
+	// SettingsFile is the path to an optional YAML or JSON file
+	// with settings values that override the stored ones.
+	//
+	// The settings could be also overridden with SettingsEnvPrefix
+	// environment variables, which have precedence over the file values.
+	SettingsFile string
 }
 
 // NewBaseApp creates and returns a new BaseApp instance
@@ -78,14 +118,17 @@ type BaseAppConfig struct {
 // To initialize the app, you need to call `app.Bootstrap()`.
 func NewBaseApp(config *BaseAppConfig) *BaseApp {
 	app := &BaseApp{
-		isDebug:          config.IsDebug,
-		databaseUrl:      config.DatabaseURL,
-		dataMaxOpenConns: config.DataMaxOpenConns,
-		dataMaxIdleConns: config.DataMaxIdleConns,
-		logsMaxOpenConns: config.LogsMaxOpenConns,
-		logsMaxIdleConns: config.LogsMaxIdleConns,
-		cache:            store.New[any](nil),
-		settings:         settings.New(),
+		isDebug:            config.IsDebug,
+		databaseUrl:        config.DatabaseURL,
+		dataMaxOpenConns:   config.DataMaxOpenConns,
+		dataMaxIdleConns:   config.DataMaxIdleConns,
+		logsMaxOpenConns:   config.LogsMaxOpenConns,
+		logsMaxIdleConns:   config.LogsMaxIdleConns,
+		retryPolicy:        config.RetryPolicy,
+		slowQueryThreshold: config.SlowQueryThreshold,
+		encryptionKey:      config.EncryptionKey,
+		settingsFile:       config.SettingsFile,
+		cache:              store.New[any](nil),
 
 		// app event hooks
 		onBeforeBootstrap: &hook.Hook[*BootstrapEvent]{},
@@ -93,6 +136,7 @@ func NewBaseApp(config *BaseAppConfig) *BaseApp {
 		onBeforeServe:     &hook.Hook[*ServeEvent]{},
 		onBeforeApiError:  &hook.Hook[*ApiErrorEvent]{},
 		onAfterApiError:   &hook.Hook[*ApiErrorEvent]{},
+		onSettingsReload:  &hook.Hook[*SettingsReloadEvent]{},
 
 		// dao event hooks
 		onModelBeforeCreate: &hook.Hook[*ModelEvent]{},
@@ -109,8 +153,10 @@ func NewBaseApp(config *BaseAppConfig) *BaseApp {
 
 		// user api event hooks
 		onUserAuthRequest: &hook.Hook[*UserAuthEvent]{},
+		onBeforeAuthorize: &hook.Hook[*AuthorizeEvent]{},
 	}
 
+	app.settings.Store(settings.New())
 	app.registerDefaultHooks()
 
 	return app
@@ -119,7 +165,7 @@ func NewBaseApp(config *BaseAppConfig) *BaseApp {
 // IsBootstrapped checks if the application was initialized
 // (aka. whether Bootstrap() was called).
 func (app *BaseApp) IsBootstrapped() bool {
-	return app.dao != nil && app.settings != nil
+	return app.dao != nil && app.settings.Load() != nil
 }
 
 // Bootstrap initializes the application
@@ -138,6 +184,10 @@ func (app *BaseApp) Bootstrap() error {
 		return err
 	}
 
+	if err := app.initEncryption(); err != nil {
+		return err
+	}
+
 	if err := app.initDB(); err != nil {
 		return err
 	}
@@ -162,7 +212,7 @@ func (app *BaseApp) ResetBootstrapState() error {
 	}
 
 	app.dao = nil
-	app.settings = nil
+	app.settings.Store(nil)
 
 	return nil
 }
@@ -193,8 +243,21 @@ func (app *BaseApp) IsDebug() bool {
 }
 
 // Settings returns the loaded app settings.
+//
+// The returned instance is replaced (not modified) on settings reload
+// so it is safe to be read concurrently with RefreshSettings.
 func (app *BaseApp) Settings() *settings.Settings {
-	return app.settings
+	return app.settings.Load()
+}
+
+// LockedSettings returns the sorted json paths of the settings fields
+// overridden by the settings file or environment variables (eg. "smtp.host").
+func (app *BaseApp) LockedSettings() []string {
+	if paths := app.lockedSettings.Load(); paths != nil {
+		return *paths
+	}
+
+	return nil
 }
 
 // Cache returns the app internal cache store.
@@ -210,7 +273,7 @@ func (app *BaseApp) NewMailClient() mailer.Mailer {
 			Host:       app.Settings().Smtp.Host,
 			Port:       app.Settings().Smtp.Port,
 			Username:   app.Settings().Smtp.Username,
-			Password:   app.Settings().Smtp.Password,
+			Password:   app.Settings().Smtp.Password.String(),
 			Tls:        app.Settings().Smtp.Tls,
 			AuthMethod: app.Settings().Smtp.AuthMethod,
 		}
@@ -225,14 +288,15 @@ func (app *BaseApp) NewMailClient() mailer.Mailer {
 // NB! Make sure to call `Close()` on the returned result
 // after you are done working with it.
 func (app *BaseApp) NewFilesystem() (*filesystem.System, error) {
-	if app.settings.S3.Enabled {
+	s := app.Settings()
+	if s.S3.Enabled {
 		return filesystem.NewS3(
-			app.settings.S3.Bucket,
-			app.settings.S3.Region,
-			app.settings.S3.Endpoint,
-			app.settings.S3.AccessKey,
-			app.settings.S3.Secret,
-			app.settings.S3.ForcePathStyle,
+			s.S3.Bucket,
+			s.S3.Region,
+			s.S3.Endpoint,
+			s.S3.AccessKey,
+			s.S3.Secret.String(),
+			s.S3.ForcePathStyle,
 		)
 	}
 
@@ -240,28 +304,142 @@ func (app *BaseApp) NewFilesystem() (*filesystem.System, error) {
 	return filesystem.NewLocal("")
 }
 
-// RefreshSettings reinitializes and reloads the stored application settings.
+// RefreshSettings reinitializes and reloads the stored application settings
+// and triggers the OnSettingsReload hook.
+//
+// The settings are loaded in layers, where each layer overrides the previous one:
+// the defaults, the stored settings, the settings file and the environment variables.
 func (app *BaseApp) RefreshSettings() error {
-	if app.settings == nil {
-		app.settings = settings.New()
+	event, err := app.loadSettings()
+	if err != nil {
+		return err
+	}
+
+	// triggered after releasing the settings lock so that
+	// the hook handlers could access (or refresh) the app settings
+	return app.OnSettingsReload().Trigger(event)
+}
+
+// loadSettings loads a new app settings instance and replaces the current one with it.
+func (app *BaseApp) loadSettings() (*SettingsReloadEvent, error) {
+	app.settingsMux.Lock()
+	defer app.settingsMux.Unlock()
+
+	oldSettings := app.settings.Load()
+	if oldSettings == nil {
+		oldSettings = settings.New()
+	}
+
+	// load the version before the settings so that a concurrent
+	// change is not missed (at worst the settings are reloaded twice)
+	version, err := app.Dao().FindParamVersion(model.ParamAppSettings)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
 	}
 
 	storedSettings, err := app.Dao().FindSettings()
 	if err != nil && err != sql.ErrNoRows {
-		return err
+		return nil, err
 	}
 
-	// no settings were previously stored
+	newSettings := settings.New()
+
 	if storedSettings == nil {
-		return app.Dao().SaveSettings(app.settings)
+		// no settings were previously stored
+		if err := app.Dao().SaveSettings(newSettings); err != nil {
+			return nil, err
+		}
+	} else if err := newSettings.Merge(storedSettings); err != nil {
+		// load the settings from the stored param into the new ones
+		return nil, err
 	}
 
-	// load the settings from the stored param into the app ones
-	if err := app.settings.Merge(storedSettings); err != nil {
+	overrides, err := app.settingsOverrides()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := newSettings.ApplyOverrides(overrides); err != nil {
+		return nil, err
+	}
+
+	// the current settings instance is replaced (instead of modified in place)
+	// so that it could be safely read without locking
+	app.settings.Store(newSettings)
+	lockedSettings := overrides.Paths()
+	app.lockedSettings.Store(&lockedSettings)
+	app.settingsVersion = version
+
+	return &SettingsReloadEvent{
+		App:         app,
+		OldSettings: oldSettings,
+		NewSettings: newSettings,
+	}, nil
+}
+
+// WatchSettings polls the stored settings version every interval and reloads
+// the app settings (see RefreshSettings) when it was changed
+// (eg. by another app instance sharing the same db).
+//
+// It blocks until ctx is done.
+func (app *BaseApp) WatchSettings(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := app.reloadChangedSettings(); err != nil && app.IsDebug() {
+				log.Println("Failed to reload the changed settings:", err)
+			}
+		}
+	}
+}
+
+// reloadChangedSettings refreshes the app settings if the stored settings version
+// is different from the one of the last loaded settings.
+func (app *BaseApp) reloadChangedSettings() error {
+	version, err := app.Dao().FindParamVersion(model.ParamAppSettings)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	app.settingsMux.Lock()
+	changed := version != app.settingsVersion
+	app.settingsMux.Unlock()
+
+	if !changed {
+		return nil
+	}
+
+	return app.RefreshSettings()
+}
+
+// settingsOverrides collects the settings file and environment variables overrides.
+func (app *BaseApp) settingsOverrides() (settings.Overrides, error) {
+	overrides := settings.Overrides{}
+
+	if app.settingsFile != "" {
+		fileOverrides, err := settings.ReadOverridesFile(app.settingsFile)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fileOverrides {
+			overrides[k] = v
+		}
+	}
+
+	envOverrides, err := settings.EnvOverrides(SettingsEnvPrefix, os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range envOverrides {
+		overrides[k] = v
+	}
+
+	return overrides, nil
 }
 
 // -------------------------------------------------------------------
@@ -288,6 +466,10 @@ func (app *BaseApp) OnAfterApiError() *hook.Hook[*ApiErrorEvent] {
 	return app.onAfterApiError
 }
 
+func (app *BaseApp) OnSettingsReload() *hook.Hook[*SettingsReloadEvent] {
+	return app.onSettingsReload
+}
+
 // -------------------------------------------------------------------
 // Dao event hooks
 // -------------------------------------------------------------------
@@ -340,10 +522,32 @@ func (app *BaseApp) OnUserAuthRequest() *hook.Hook[*UserAuthEvent] {
 	return app.onUserAuthRequest
 }
 
+func (app *BaseApp) OnBeforeAuthorize() *hook.Hook[*AuthorizeEvent] {
+	return app.onBeforeAuthorize
+}
+
 // -------------------------------------------------------------------
 // Helpers
 // -------------------------------------------------------------------
 
+// initEncryption loads the configured encryption keys into the app keyring
+// (it is applied to the app Dao, see [dao.Dao.EncryptionKeyring]).
+func (app *BaseApp) initEncryption() error {
+	if app.encryptionKey == "" {
+		app.encryptionKeyring = nil
+		return nil
+	}
+
+	keyring, err := security.ParseKeyring(app.encryptionKey)
+	if err != nil {
+		return fmt.Errorf("invalid encryption key: %w", err)
+	}
+
+	app.encryptionKeyring = keyring
+
+	return nil
+}
+
 func (app *BaseApp) initDB() error {
 	maxOpenConns := DefaultDataMaxOpenConns
 	maxIdleConns := DefaultDataMaxIdleConns
@@ -382,6 +586,19 @@ func (app *BaseApp) initDB() error {
 		concurrentDB.ExecLogFunc = nonconcurrentDB.ExecLogFunc
 	}
 
+	// share the query cache so that both dbs are invalidated together
+	nonconcurrentDB.QueryCache = concurrentDB.QueryCache
+
+	concurrentDB.Metrics = dbx.NewQueryMetrics()
+	nonconcurrentDB.Metrics = concurrentDB.Metrics
+
+	concurrentDB.SlowQueryThreshold = app.slowQueryThreshold
+	nonconcurrentDB.SlowQueryThreshold = app.slowQueryThreshold
+
+	// the statements are prepared per connections pool
+	concurrentDB.StmtCache = dbx.NewStmtCache(dbx.DefaultStmtCacheCapacity)
+	nonconcurrentDB.StmtCache = dbx.NewStmtCache(dbx.DefaultStmtCacheCapacity)
+
 	app.dao = app.createDaoWithHooks(concurrentDB, nonconcurrentDB)
 
 	return nil
@@ -389,6 +606,8 @@ func (app *BaseApp) initDB() error {
 
 func (app *BaseApp) createDaoWithHooks(concurrentDB, nonconcurrentDB dbx.Builder) *dao.Dao {
 	d := dao.NewMultiDB(concurrentDB, nonconcurrentDB)
+	d.RetryPolicy = app.retryPolicy
+	d.EncryptionKeyring = app.encryptionKeyring
 
 	d.BeforeCreateFunc = func(eventDao *dao.Dao, m model.Model) error {
 		return app.OnModelBeforeCreate().Trigger(&ModelEvent{eventDao, m})
@@ -427,4 +646,28 @@ func (app *BaseApp) createDaoWithHooks(concurrentDB, nonconcurrentDB dbx.Builder
 }
 
 func (app *BaseApp) registerDefaultHooks() {
+	// invalidate the cached query results of the changed model table
+	invalidateQueryCache := func(e *ModelEvent) error {
+		if app.dao == nil {
+			return nil
+		}
+		if db, ok := app.dao.ConcurrentDB().(*dbx.DB); ok && db.QueryCache != nil {
+			db.QueryCache.InvalidateTags(e.Model.TableName())
+		}
+		return nil
+	}
+	app.OnModelAfterCreate().Add(invalidateQueryCache)
+	app.OnModelAfterUpdate().Add(invalidateQueryCache)
+	app.OnModelAfterDelete().Add(invalidateQueryCache)
+
+	// remove the cached decoded value of the changed param
+	invalidateParamCache := func(e *ModelEvent) error {
+		if param, ok := e.Model.(*model.Param); ok {
+			app.Cache().Remove(paramCacheKeyPrefix + param.Key)
+		}
+		return nil
+	}
+	app.OnModelAfterCreate().Add(invalidateParamCache)
+	app.OnModelAfterUpdate().Add(invalidateParamCache)
+	app.OnModelAfterDelete().Add(invalidateParamCache)
 }
@@ -0,0 +1,163 @@
+package core_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/har4s/ohmygo/core"
+	"github.com/har4s/ohmygo/migrations"
+	"github.com/har4s/ohmygo/tools/migrate"
+)
+
+// createTestApp creates a new bootstrapped app with a clean and fully migrated test db.
+func createTestApp(t *testing.T, settingsFile string) *core.BaseApp {
+	app := core.NewBaseApp(&core.BaseAppConfig{
+		DatabaseURL:  "har4s:@/ozzo_dbx_test?parseTime=true",
+		SettingsFile: settingsFile,
+	})
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		app.ResetBootstrapState()
+	})
+
+	tables := []string{"user_roles", "roles", "audit_logs", "users", "params", migrate.DefaultMigrationsTable}
+	for _, table := range tables {
+		if _, err := app.DB().NewQuery("DROP TABLE IF EXISTS {{" + table + "}}").Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runner, err := migrate.NewRunner(app.DB(), migrations.Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	return app
+}
+
+func TestRefreshSettings(t *testing.T) {
+	settingsFile := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(settingsFile, []byte(`{"meta":{"appName":"locked_app"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := createTestApp(t, settingsFile)
+
+	events := []*core.SettingsReloadEvent{}
+	app.OnSettingsReload().Add(func(e *core.SettingsReloadEvent) error {
+		events = append(events, e)
+		return nil
+	})
+
+	if err := app.RefreshSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 OnSettingsReload event, got %d", len(events))
+	}
+
+	if events[0].NewSettings != app.Settings() {
+		t.Fatal("Expected the event new settings to be the current app settings")
+	}
+
+	if app.Settings().Meta.AppName != "locked_app" {
+		t.Fatalf("Expected the settings file app name, got %q", app.Settings().Meta.AppName)
+	}
+
+	expectedLocked := []string{"meta.appName"}
+	if locked := app.LockedSettings(); !reflect.DeepEqual(locked, expectedLocked) {
+		t.Fatalf("Expected locked settings %v, got %v", expectedLocked, locked)
+	}
+}
+
+func TestRefreshSettingsConcurrentLockedSettings(t *testing.T) {
+	app := createTestApp(t, "")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			if err := app.RefreshSettings(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			app.LockedSettings()
+			app.Settings()
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestWatchSettings(t *testing.T) {
+	app := createTestApp(t, "")
+
+	if err := app.RefreshSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := make(chan *core.SettingsReloadEvent, 10)
+	app.OnSettingsReload().Add(func(e *core.SettingsReloadEvent) error {
+		reloaded <- e
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go app.WatchSettings(ctx, 10*time.Millisecond)
+
+	// unchanged settings
+	select {
+	case <-reloaded:
+		t.Fatal("Expected the unchanged settings to not be reloaded")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// change the stored settings (eg. from another app instance)
+	storedSettings, err := app.Dao().FindSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	storedSettings.Meta.AppName = "changed_app"
+	if err := app.Dao().SaveSettings(storedSettings); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-reloaded:
+		if e.NewSettings.Meta.AppName != "changed_app" {
+			t.Fatalf("Expected the changed app name, got %q", e.NewSettings.Meta.AppName)
+		}
+		if app.Settings().Meta.AppName != "changed_app" {
+			t.Fatalf("Expected the app settings to be reloaded, got %q", app.Settings().Meta.AppName)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the changed settings to be reloaded")
+	}
+
+	// the reloaded version is not reloaded again
+	select {
+	case <-reloaded:
+		t.Fatal("Expected the settings to be reloaded only once")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
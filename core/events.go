@@ -25,6 +25,12 @@ type ApiErrorEvent struct {
 	Error       error
 }
 
+type SettingsReloadEvent struct {
+	App         App
+	OldSettings *settings.Settings
+	NewSettings *settings.Settings
+}
+
 // -------------------------------------------------------------------
 // Model DAO events data
 // -------------------------------------------------------------------
@@ -58,3 +64,11 @@ type UserAuthEvent struct {
 	User        *model.User
 	Token       string
 }
+
+type AuthorizeEvent struct {
+	HttpContext echo.Context
+	User        *model.User // nil for guests
+	Rule        string      // the middleware rule (eg. "admin", "permission")
+	Permission  string      // the required permission (only for the "permission" rule)
+	Allowed     bool        // the middleware decision
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/har4s/ohmygo/dao"
+)
+
+// ParamCacheTTL is the max duration a decoded param value is cached in
+// App.Cache() (aka. the max delay before seeing a change made by another app instance).
+//
+// The cached value is removed on every Param model change made by the app.
+const ParamCacheTTL = 5 * time.Minute
+
+// paramCacheKeyPrefix is the App.Cache() key prefix of the cached param values.
+const paramCacheKeyPrefix = "@param:"
+
+type cachedParam struct {
+	value   any
+	version int64
+	expires time.Time
+}
+
+// GetParam returns the decoded value of the Param with the provided key
+// (see [dao.GetParam]) and caches it in App.Cache().
+//
+// Note that the cached value is shared, so the nested
+// pointers, slices and maps must not be modified.
+func GetParam[T any](app App, key string, defaultValue T) (T, error) {
+	value, _, err := GetParamWithVersion(app, key, defaultValue)
+
+	return value, err
+}
+
+// GetParamWithVersion returns the decoded value and the version of the
+// Param with the provided key (see [dao.GetParamWithVersion]) and caches them in App.Cache().
+func GetParamWithVersion[T any](app App, key string, defaultValue T) (T, int64, error) {
+	cacheKey := paramCacheKeyPrefix + key
+
+	if cached, ok := app.Cache().Get(cacheKey).(*cachedParam); ok && time.Now().Before(cached.expires) {
+		if value, ok := cached.value.(T); ok {
+			return value, cached.version, nil
+		}
+	}
+
+	param, err := app.Dao().FindParamByKey(key)
+	if err != nil {
+		// the missing params are not cached to avoid filling the cache with random keys
+		if err == sql.ErrNoRows {
+			return defaultValue, 0, nil
+		}
+		return defaultValue, 0, err
+	}
+
+	value, err := dao.ParamValue(param, defaultValue)
+	if err != nil {
+		return defaultValue, 0, err
+	}
+
+	expires := time.Now().Add(ParamCacheTTL)
+	if param.Expires != nil && !param.Expires.IsZero() && param.Expires.Time().Before(expires) {
+		expires = param.Expires.Time()
+	}
+
+	app.Cache().Set(cacheKey, &cachedParam{
+		value:   value,
+		version: param.Version,
+		expires: expires,
+	})
+
+	return value, param.Version, nil
+}
+
+// CompareAndSetParam atomically updates the Param with the provided key
+// (see [dao.CompareAndSetParam]).
+//
+// On version mismatch the param cached value is removed so that
+// the next GetParamWithVersion call returns the current version.
+func CompareAndSetParam[T any](app App, key string, version int64, value T) (bool, error) {
+	ok, err := dao.CompareAndSetParam(app.Dao(), key, version, value)
+	if err == nil && !ok {
+		app.Cache().Remove(paramCacheKeyPrefix + key)
+	}
+
+	return ok, err
+}
@@ -0,0 +1,142 @@
+package dao
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/har4s/ohmygo/dbx"
+	"github.com/har4s/ohmygo/model"
+)
+
+// AuditLogQuery returns a new AuditLog select query.
+func (dao *Dao) AuditLogQuery() *dbx.SelectQuery {
+	return dao.ModelQuery(&model.AuditLog{})
+}
+
+// FindAuditLogById finds the audit log with the provided id.
+func (dao *Dao) FindAuditLogById(id string) (*model.AuditLog, error) {
+	log := &model.AuditLog{}
+
+	err := dao.AuditLogQuery().
+		AndWhere(dbx.HashExp{"id": id}).
+		Limit(1).
+		One(log)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return log, nil
+}
+
+// FindLastAuditLog finds the head of the audit logs chain
+// (aka. the log that is not referenced by any other log).
+func (dao *Dao) FindLastAuditLog() (*model.AuditLog, error) {
+	log := &model.AuditLog{}
+
+	err := dao.AuditLogQuery().
+		AndWhere(dbx.NewExp("NOT EXISTS (SELECT 1 FROM {{audit_logs}} [[next]] WHERE [[next.prevHash]] = {{audit_logs}}.[[hash]])")).
+		OrderBy("created DESC").
+		Limit(1).
+		One(log)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return log, nil
+}
+
+// maxAuditLogChainAttempts is the max number of times SaveAuditLog
+// tries to chain a new audit log when another one was concurrently
+// chained to the same last log.
+const maxAuditLogChainAttempts = 10
+
+// SaveAuditLog chains the provided new audit log to the last
+// existing one and persists it.
+//
+// The audit logs prevHash column is unique, so if another log was
+// concurrently chained to the same last log, the insert fails and
+// the new log is chained again to the new last log.
+//
+// Existing audit logs cannot be modified.
+func (dao *Dao) SaveAuditLog(log *model.AuditLog) error {
+	if !log.IsNew() {
+		return errors.New("existing audit logs cannot be modified")
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := dao.RunInTransaction(func(txDao *Dao) error {
+			log.PrevHash = ""
+			if last, _ := txDao.FindLastAuditLog(); last != nil {
+				log.PrevHash = last.Hash
+			}
+
+			// the id and created date are part of the hash
+			if !log.HasId() {
+				log.RefreshId()
+			}
+			if log.GetCreated().IsZero() {
+				log.RefreshCreated()
+			}
+			log.RefreshHash()
+
+			return txDao.Save(log)
+		})
+
+		if err == nil || attempt >= maxAuditLogChainAttempts || !dao.isAuditLogChained(log) {
+			return err
+		}
+	}
+}
+
+// isAuditLogChained checks whether another audit log
+// is already chained to the previous log of the provided one.
+func (dao *Dao) isAuditLogChained(log *model.AuditLog) bool {
+	var exists bool
+
+	err := dao.AuditLogQuery().
+		Select("count(*)").
+		AndWhere(dbx.HashExp{"prevHash": log.PrevHash}).
+		AndWhere(dbx.Not(dbx.HashExp{"id": log.Id})).
+		Limit(1).
+		Row(&exists)
+
+	return err == nil && exists
+}
+
+// VerifyAuditLogs walks the audit logs chain and checks that none
+// of the logs were modified, removed from the middle of the chain or
+// inserted without being properly chained.
+func (dao *Dao) VerifyAuditLogs() error {
+	logs := []model.AuditLog{}
+	if err := dao.AuditLogQuery().OrderBy("created ASC").All(&logs); err != nil {
+		return err
+	}
+
+	byPrevHash := make(map[string]*model.AuditLog, len(logs))
+	for i := range logs {
+		log := &logs[i]
+
+		if log.Hash != log.CalculateHash() {
+			return fmt.Errorf("audit log %q hash mismatch", log.Id)
+		}
+
+		if _, ok := byPrevHash[log.PrevHash]; ok {
+			return fmt.Errorf("audit log %q forks the chain", log.Id)
+		}
+		byPrevHash[log.PrevHash] = log
+	}
+
+	// walk the chain starting from the first log
+	total := 0
+	for log := byPrevHash[""]; log != nil; log = byPrevHash[log.Hash] {
+		total++
+	}
+
+	if total != len(logs) {
+		return fmt.Errorf("broken audit logs chain (%d of %d logs are chained)", total, len(logs))
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package dao_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/har4s/ohmygo/dao"
+	"github.com/har4s/ohmygo/dbx"
+	"github.com/har4s/ohmygo/model"
+	"github.com/har4s/ohmygo/tools/types"
+)
+
+func createTestAuditLogs(t *testing.T, testDao *dao.Dao, total int) []*model.AuditLog {
+	logs := make([]*model.AuditLog, total)
+
+	for i := range logs {
+		logs[i] = &model.AuditLog{
+			RecordTable: "users",
+			RecordId:    fmt.Sprintf("test%d", i),
+			Action:      model.AuditActionCreate,
+			Diff:        types.JsonRaw(`{"email":{"new":"test@example.com"}}`),
+		}
+
+		if err := testDao.SaveAuditLog(logs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return logs
+}
+
+func TestSaveAuditLog(t *testing.T) {
+	testDao := createTestDao(t)
+
+	logs := createTestAuditLogs(t, testDao, 3)
+
+	for i, log := range logs {
+		expectedPrevHash := ""
+		if i > 0 {
+			expectedPrevHash = logs[i-1].Hash
+		}
+
+		if log.PrevHash != expectedPrevHash {
+			t.Errorf("(%d) Expected prevHash %q, got %q", i, expectedPrevHash, log.PrevHash)
+		}
+
+		if log.Hash != log.CalculateHash() {
+			t.Errorf("(%d) Expected hash %q, got %q", i, log.CalculateHash(), log.Hash)
+		}
+	}
+
+	last, err := testDao.FindLastAuditLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Id != logs[2].Id {
+		t.Fatalf("Expected the last log to be %q, got %q", logs[2].Id, last.Id)
+	}
+
+	// existing logs cannot be modified
+	logs[0].Action = model.AuditActionDelete
+	if err := testDao.SaveAuditLog(logs[0]); err == nil {
+		t.Fatal("Expected the existing log save to fail")
+	}
+
+	// another log cannot be chained to an already chained one
+	forked := &model.AuditLog{RecordTable: "users", RecordId: "forked", Action: model.AuditActionCreate}
+	forked.RefreshId()
+	forked.RefreshCreated()
+	forked.PrevHash = logs[0].Hash
+	forked.RefreshHash()
+	if err := testDao.Save(forked); err == nil {
+		t.Fatal("Expected the forked log save to fail")
+	}
+}
+
+func TestVerifyAuditLogs(t *testing.T) {
+	scenarios := []struct {
+		name        string
+		tamper      func(testDao *dao.Dao, logs []*model.AuditLog) error
+		expectError bool
+	}{
+		{
+			"valid chain",
+			func(testDao *dao.Dao, logs []*model.AuditLog) error {
+				return nil
+			},
+			false,
+		},
+		{
+			"modified log",
+			func(testDao *dao.Dao, logs []*model.AuditLog) error {
+				_, err := testDao.DB().Update(
+					"audit_logs",
+					dbx.Params{"actorId": "test"},
+					dbx.HashExp{"id": logs[1].Id},
+				).Execute()
+				return err
+			},
+			true,
+		},
+		{
+			"removed log from the middle of the chain",
+			func(testDao *dao.Dao, logs []*model.AuditLog) error {
+				_, err := testDao.DB().Delete("audit_logs", dbx.HashExp{"id": logs[1].Id}).Execute()
+				return err
+			},
+			true,
+		},
+		{
+			"removed last log",
+			func(testDao *dao.Dao, logs []*model.AuditLog) error {
+				_, err := testDao.DB().Delete("audit_logs", dbx.HashExp{"id": logs[2].Id}).Execute()
+				return err
+			},
+			false,
+		},
+	}
+
+	for _, s := range scenarios {
+		testDao := createTestDao(t)
+
+		logs := createTestAuditLogs(t, testDao, 3)
+
+		if err := s.tamper(testDao, logs); err != nil {
+			t.Fatalf("[%s] Failed to tamper the logs: %v", s.name, err)
+		}
+
+		err := testDao.VerifyAuditLogs()
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("[%s] Expected hasErr %v, got %v (%v)", s.name, s.expectError, hasErr, err)
+		}
+	}
+}
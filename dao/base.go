@@ -1,12 +1,12 @@
 package dao
 
 import (
+	"context"
 	"errors"
-	"strings"
-	"time"
 
 	"github.com/har4s/ohmygo/dbx"
 	"github.com/har4s/ohmygo/model"
+	"github.com/har4s/ohmygo/tools/security"
 )
 
 const DefaultMaxFailRetries = 5
@@ -31,6 +31,19 @@ type Dao struct {
 	concurrentDB    dbx.Builder
 	nonconcurrentDB dbx.Builder
 
+	ctx context.Context
+
+	// the buffered after hooks of the current transaction (if any)
+	txScope *txScope
+
+	// RetryPolicy specifies how the failed writes and transactions
+	// are retried (default to DefaultRetryPolicy).
+	RetryPolicy *RetryPolicy
+
+	// EncryptionKeyring is the keyring used to encrypt and decrypt the
+	// stored settings secrets (if nil, the secrets are stored as plain text).
+	EncryptionKeyring *security.Keyring
+
 	BeforeCreateFunc func(eventDao *Dao, m model.Model) error
 	AfterCreateFunc  func(eventDao *Dao, m model.Model)
 	BeforeUpdateFunc func(eventDao *Dao, m model.Model) error
@@ -39,6 +52,31 @@ type Dao struct {
 	AfterDeleteFunc  func(eventDao *Dao, m model.Model)
 }
 
+// Context returns the context associated with the dao (if any).
+func (dao *Dao) Context() context.Context {
+	return dao.ctx
+}
+
+// WithContext returns a shallow copy of the dao (including its hooks)
+// associated with the provided context.
+//
+// The context is also applied to the dao db builders (except for *dbx.Tx
+// which inherits the context of the db that started the transaction).
+func (dao *Dao) WithContext(ctx context.Context) *Dao {
+	clone := *dao
+	clone.ctx = ctx
+	clone.concurrentDB = builderWithContext(dao.concurrentDB, ctx)
+	clone.nonconcurrentDB = builderWithContext(dao.nonconcurrentDB, ctx)
+	return &clone
+}
+
+func builderWithContext(builder dbx.Builder, ctx context.Context) dbx.Builder {
+	if db, ok := builder.(*dbx.DB); ok {
+		return db.WithContext(ctx)
+	}
+	return builder
+}
+
 // DB returns the default dao db builder (*dbx.DB or *dbx.TX).
 //
 // Currently the default db builder is dao.concurrentDB but that may change in the future.
@@ -81,85 +119,192 @@ type afterCallGroup struct {
 	Model    model.Model
 }
 
+// txScope holds the after hooks and callbacks buffered during a transaction
+// (or savepoint) until it is successfully committed.
+type txScope struct {
+	afterCalls       []afterCallGroup
+	afterCommitFuncs []func()
+
+	// restoreFuncs revert the in-memory state of the models persisted
+	// in the transaction (eg. the "new" flag) in case it is rolled back
+	restoreFuncs []func()
+}
+
+// rollback restores the state of the models persisted in the scope
+// (in reverse order) so that they could be saved again (eg. on retry).
+func (scope *txScope) rollback() {
+	for i := len(scope.restoreFuncs) - 1; i >= 0; i-- {
+		scope.restoreFuncs[i]()
+	}
+
+	scope.restoreFuncs = nil
+}
+
+// flush forwards the buffered calls to the provided parent dao.
+//
+// If the parent dao is part of an outer transaction the calls are buffered
+// again in its scope, otherwise they are executed immediately.
+func (scope *txScope) flush(parent *Dao) {
+	for _, call := range scope.afterCalls {
+		switch call.Action {
+		case "create":
+			if parent.AfterCreateFunc != nil {
+				parent.AfterCreateFunc(parent, call.Model)
+			}
+		case "update":
+			if parent.AfterUpdateFunc != nil {
+				parent.AfterUpdateFunc(parent, call.Model)
+			}
+		case "delete":
+			if parent.AfterDeleteFunc != nil {
+				parent.AfterDeleteFunc(parent, call.Model)
+			}
+		}
+	}
+
+	for _, fn := range scope.afterCommitFuncs {
+		parent.OnAfterCommit(fn)
+	}
+
+	// the models state should be restored also if the outer transaction is rolled back
+	if parent.txScope != nil {
+		parent.txScope.restoreFuncs = append(parent.txScope.restoreFuncs, scope.restoreFuncs...)
+	}
+}
+
+// saveModelState stores the current state of the provided model
+// in the dao transaction scope (if any), so that it could be restored
+// if the transaction is rolled back.
+func (dao *Dao) saveModelState(m model.Model) {
+	if dao.txScope == nil {
+		return
+	}
+
+	isNew := m.IsNew()
+	id := m.GetId()
+
+	var original map[string]any
+	tracker, _ := m.(model.ChangesTracker)
+	if tracker != nil {
+		original = tracker.Original()
+	}
+
+	dao.txScope.restoreFuncs = append(dao.txScope.restoreFuncs, func() {
+		if isNew {
+			m.MarkAsNew()
+		} else {
+			m.MarkAsNotNew()
+		}
+
+		m.SetId(id)
+
+		if tracker != nil {
+			tracker.Snapshot(original)
+		}
+	})
+}
+
+// OnAfterCommit registers a callback that will be executed once the
+// outermost transaction of the dao is successfully committed.
+//
+// The callback is discarded if the transaction (or the savepoint
+// of a nested transaction) is rolled back.
+//
+// If the dao is not part of a RunInTransaction call, fn is executed immediately.
+func (dao *Dao) OnAfterCommit(fn func()) {
+	if dao.txScope == nil {
+		fn()
+		return
+	}
+
+	dao.txScope.afterCommitFuncs = append(dao.txScope.afterCommitFuncs, fn)
+}
+
 // RunInTransaction wraps fn into a transaction.
 //
 // It is safe to nest RunInTransaction calls as long as you use the txDao.
+// Nested calls are executed within a savepoint, so that an error returned
+// by a nested fn rolls back only its own changes.
+//
+// The after hooks (and OnAfterCommit callbacks) are buffered and executed
+// only after the outermost transaction is successfully committed.
+//
+// If the outermost transaction fails with a retryable error (eg. deadlock),
+// the whole transaction (including fn) is executed again according to
+// the dao retry policy, so fn should not have side effects outside of txDao.
+//
+// The state of the models saved with txDao (eg. the "new" flag and the
+// changes snapshot) is restored when their transaction is rolled back,
+// so that they are persisted again on retry.
 func (dao *Dao) RunInTransaction(fn func(txDao *Dao) error) error {
 	switch txOrDB := dao.NonconcurrentDB().(type) {
 	case *dbx.Tx:
-		// nested transactions are not supported by default
-		// so execute the function within the current transaction
-		// ---
-		// create a new dao with the same hooks to avoid semaphore deadlock when nesting
-		txDao := New(txOrDB)
-		txDao.BeforeCreateFunc = dao.BeforeCreateFunc
-		txDao.BeforeUpdateFunc = dao.BeforeUpdateFunc
-		txDao.BeforeDeleteFunc = dao.BeforeDeleteFunc
-		txDao.AfterCreateFunc = dao.AfterCreateFunc
-		txDao.AfterUpdateFunc = dao.AfterUpdateFunc
-		txDao.AfterDeleteFunc = dao.AfterDeleteFunc
-
-		return fn(txDao)
+		return dao.runInScope(func(scope *txScope) error {
+			return txOrDB.Transactional(func(tx *dbx.Tx) error {
+				return fn(dao.newTxDao(tx, scope))
+			})
+		})
 	case *dbx.DB:
-		afterCalls := []afterCallGroup{}
+		return dao.withRetry(func() error {
+			return dao.runInScope(func(scope *txScope) error {
+				return txOrDB.Transactional(func(tx *dbx.Tx) error {
+					return fn(dao.newTxDao(tx, scope))
+				})
+			})
+		})
+	}
 
-		txError := txOrDB.Transactional(func(tx *dbx.Tx) error {
-			txDao := New(tx)
+	return errors.New("failed to start transaction (unknown dao.NonconcurrentDB() instance)")
+}
 
-			if dao.BeforeCreateFunc != nil {
-				txDao.BeforeCreateFunc = func(eventDao *Dao, m model.Model) error {
-					return dao.BeforeCreateFunc(eventDao, m)
-				}
-			}
-			if dao.BeforeUpdateFunc != nil {
-				txDao.BeforeUpdateFunc = func(eventDao *Dao, m model.Model) error {
-					return dao.BeforeUpdateFunc(eventDao, m)
-				}
-			}
-			if dao.BeforeDeleteFunc != nil {
-				txDao.BeforeDeleteFunc = func(eventDao *Dao, m model.Model) error {
-					return dao.BeforeDeleteFunc(eventDao, m)
-				}
-			}
+// runInScope executes the provided transaction func with a new after
+// calls scope and flushes it on success.
+func (dao *Dao) runInScope(txFunc func(scope *txScope) error) error {
+	scope := &txScope{}
 
-			if dao.AfterCreateFunc != nil {
-				txDao.AfterCreateFunc = func(eventDao *Dao, m model.Model) {
-					afterCalls = append(afterCalls, afterCallGroup{"create", eventDao, m})
-				}
-			}
-			if dao.AfterUpdateFunc != nil {
-				txDao.AfterUpdateFunc = func(eventDao *Dao, m model.Model) {
-					afterCalls = append(afterCalls, afterCallGroup{"update", eventDao, m})
-				}
-			}
-			if dao.AfterDeleteFunc != nil {
-				txDao.AfterDeleteFunc = func(eventDao *Dao, m model.Model) {
-					afterCalls = append(afterCalls, afterCallGroup{"delete", eventDao, m})
-				}
-			}
+	if err := txFunc(scope); err != nil {
+		scope.rollback()
+		return err
+	}
 
-			return fn(txDao)
-		})
+	// forward the buffered calls to the outer transaction or execute them
+	// (note: using the parent dao to allow following queries in the after hooks)
+	scope.flush(dao)
 
-		if txError == nil {
-			// execute after event calls on successful transaction
-			// (note: using the non-transaction dao to allow following queries in the after hooks)
-			for _, call := range afterCalls {
-				switch call.Action {
-				case "create":
-					dao.AfterCreateFunc(dao, call.Model)
-				case "update":
-					dao.AfterUpdateFunc(dao, call.Model)
-				case "delete":
-					dao.AfterDeleteFunc(dao, call.Model)
-				}
-			}
-		}
+	return nil
+}
+
+// newTxDao creates a new transaction dao with the same before hooks as
+// the current one and after hooks buffered in the provided scope.
+//
+// A new dao is created also for nested transactions to avoid semaphore deadlock.
+func (dao *Dao) newTxDao(tx *dbx.Tx, scope *txScope) *Dao {
+	txDao := New(tx)
+	txDao.ctx = dao.ctx
+	txDao.txScope = scope
+	txDao.RetryPolicy = dao.RetryPolicy
+	txDao.EncryptionKeyring = dao.EncryptionKeyring
+	txDao.BeforeCreateFunc = dao.BeforeCreateFunc
+	txDao.BeforeUpdateFunc = dao.BeforeUpdateFunc
+	txDao.BeforeDeleteFunc = dao.BeforeDeleteFunc
 
-		return txError
+	if dao.AfterCreateFunc != nil {
+		txDao.AfterCreateFunc = func(eventDao *Dao, m model.Model) {
+			scope.afterCalls = append(scope.afterCalls, afterCallGroup{"create", eventDao, m})
+		}
+	}
+	if dao.AfterUpdateFunc != nil {
+		txDao.AfterUpdateFunc = func(eventDao *Dao, m model.Model) {
+			scope.afterCalls = append(scope.afterCalls, afterCallGroup{"update", eventDao, m})
+		}
+	}
+	if dao.AfterDeleteFunc != nil {
+		txDao.AfterDeleteFunc = func(eventDao *Dao, m model.Model) {
+			scope.afterCalls = append(scope.afterCalls, afterCallGroup{"delete", eventDao, m})
+		}
 	}
 
-	return errors.New("failed to start transaction (unknown dao.NonconcurrentDB() instance)")
+	return txDao
 }
 
 // Delete deletes the provided model.
@@ -184,20 +329,24 @@ func (dao *Dao) Delete(m model.Model) error {
 		}
 
 		return nil
-	}, DefaultMaxFailRetries)
+	})
 }
 
 // Save upserts (update or create if primary key is not set) the provided model.
+//
+// For models implementing [model.ChangesTracker] only the changed columns
+// are updated and the write (including the update hooks) is skipped
+// entirely if nothing has changed since the model was loaded.
 func (dao *Dao) Save(m model.Model) error {
 	if m.IsNew() {
 		return dao.failRetry(func(retryDao *Dao) error {
 			return retryDao.create(m)
-		}, DefaultMaxFailRetries)
+		})
 	}
 
 	return dao.failRetry(func(retryDao *Dao) error {
 		return retryDao.update(m)
-	}, DefaultMaxFailRetries)
+	})
 }
 
 func (dao *Dao) update(m model.Model) error {
@@ -205,19 +354,51 @@ func (dao *Dao) update(m model.Model) error {
 		return errors.New("ID is not set")
 	}
 
+	dao.saveModelState(m)
+
 	if m.GetCreated().IsZero() {
 		m.RefreshCreated()
 	}
 
+	tracker, _ := m.(model.ChangesTracker)
+
+	// nothing has changed since the model was loaded or last persisted
+	if tracker != nil && tracker.IsTracked() && len(tracker.DetectChanges(model.ColumnValues(m))) == 0 {
+		return nil
+	}
+
 	m.RefreshUpdated()
 
+	if tracker != nil {
+		// refresh the changes so that they are available in the before hooks
+		tracker.DetectChanges(model.ColumnValues(m))
+	}
+
 	if dao.BeforeUpdateFunc != nil {
 		if err := dao.BeforeUpdateFunc(dao, m); err != nil {
 			return err
 		}
 	}
 
-	if v, ok := any(m).(model.ColumnValueMapper); ok {
+	if tracker != nil {
+		// write only the changed columns
+		// (the changes are detected again to include the ones made by the before hooks)
+		dataMap := tracker.DetectChanges(model.ColumnValues(m))
+
+		if len(dataMap) > 0 {
+			_, err := dao.NonconcurrentDB().Update(
+				m.TableName(),
+				dataMap,
+				dbx.HashExp{"id": m.GetId()},
+			).Execute()
+
+			if err != nil {
+				return err
+			}
+		}
+
+		tracker.Snapshot(model.ColumnValues(m))
+	} else if v, ok := any(m).(model.ColumnValueMapper); ok {
 		dataMap := v.ColumnValueMap()
 
 		_, err := dao.NonconcurrentDB().Update(
@@ -243,6 +424,8 @@ func (dao *Dao) update(m model.Model) error {
 }
 
 func (dao *Dao) create(m model.Model) error {
+	dao.saveModelState(m)
+
 	if !m.HasId() {
 		// auto generate id
 		m.RefreshId()
@@ -284,6 +467,10 @@ func (dao *Dao) create(m model.Model) error {
 	// clears the "new" model flag
 	m.MarkAsNotNew()
 
+	if tracker, ok := m.(model.ChangesTracker); ok {
+		tracker.Snapshot(model.ColumnValues(m))
+	}
+
 	if dao.AfterCreateFunc != nil {
 		dao.AfterCreateFunc(dao, m)
 	}
@@ -291,32 +478,58 @@ func (dao *Dao) create(m model.Model) error {
 	return nil
 }
 
-func (dao *Dao) failRetry(op func(retryDao *Dao) error, maxRetries int) error {
+// retryPolicy returns the dao retry policy or the default one if not set.
+func (dao *Dao) retryPolicy() *RetryPolicy {
+	if dao.RetryPolicy != nil {
+		return dao.RetryPolicy
+	}
+
+	return DefaultRetryPolicy
+}
+
+// withRetry executes op and retries it according to the dao retry policy.
+func (dao *Dao) withRetry(op func() error) error {
+	policy := dao.retryPolicy()
+
+	for attempt := 1; ; attempt++ {
+		err := op()
+
+		if !policy.ShouldRetry(err, attempt) {
+			return err
+		}
+
+		if waitErr := policy.wait(dao.ctx, attempt); waitErr != nil {
+			return err
+		}
+	}
+}
+
+func (dao *Dao) failRetry(op func(retryDao *Dao) error) error {
+	// the db usually aborts the entire transaction on deadlock
+	// so the retry is left to the outermost RunInTransaction call
+	if _, ok := dao.NonconcurrentDB().(*dbx.Tx); ok {
+		return op(dao)
+	}
+
 	retryDao := dao
-	attempts := 1
-
-Retry:
-	if attempts == 2 {
-		// assign new Dao without the before hooks to avoid triggering
-		// the already fired before callbacks multiple times
-		retryDao = NewMultiDB(dao.concurrentDB, dao.nonconcurrentDB)
-		retryDao.AfterCreateFunc = dao.AfterCreateFunc
-		retryDao.AfterUpdateFunc = dao.AfterUpdateFunc
-		retryDao.AfterDeleteFunc = dao.AfterDeleteFunc
-	}
-
-	// execute
-	err := op(retryDao)
-
-	if err != nil &&
-		attempts < maxRetries &&
-		// note: we are checking the err message so that we can handle both the cgo and noncgo errors
-		strings.Contains(err.Error(), "database is locked") {
-		// wait and retry
-		time.Sleep(time.Duration(200*attempts) * time.Millisecond)
+	attempts := 0
+
+	return dao.withRetry(func() error {
 		attempts++
-		goto Retry
-	}
 
-	return err
+		if attempts == 2 {
+			// assign new Dao without the before hooks to avoid triggering
+			// the already fired before callbacks multiple times
+			retryDao = NewMultiDB(dao.concurrentDB, dao.nonconcurrentDB)
+			retryDao.ctx = dao.ctx
+			retryDao.txScope = dao.txScope
+			retryDao.RetryPolicy = dao.RetryPolicy
+			retryDao.EncryptionKeyring = dao.EncryptionKeyring
+			retryDao.AfterCreateFunc = dao.AfterCreateFunc
+			retryDao.AfterUpdateFunc = dao.AfterUpdateFunc
+			retryDao.AfterDeleteFunc = dao.AfterDeleteFunc
+		}
+
+		return op(retryDao)
+	})
 }
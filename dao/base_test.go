@@ -0,0 +1,42 @@
+package dao_test
+
+import (
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/har4s/ohmygo/dao"
+	"github.com/har4s/ohmygo/dbx"
+	"github.com/har4s/ohmygo/migrations"
+	"github.com/har4s/ohmygo/tools/migrate"
+)
+
+// createTestDao creates a new Dao for a clean and fully migrated test db.
+//
+// The db connection is closed at the end of the test.
+func createTestDao(t *testing.T) *dao.Dao {
+	db, err := dbx.Open("mysql", "har4s:@/ozzo_dbx_test?parseTime=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	tables := []string{"user_roles", "roles", "audit_logs", "users", "params", migrate.DefaultMigrationsTable}
+	for _, table := range tables {
+		if _, err := db.NewQuery("DROP TABLE IF EXISTS {{" + table + "}}").Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runner, err := migrate.NewRunner(db, migrations.Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runner.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	return dao.New(db)
+}
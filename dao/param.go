@@ -1,6 +1,12 @@
 package dao
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/har4s/ohmygo/dbx"
 	"github.com/har4s/ohmygo/model"
 	"github.com/har4s/ohmygo/tools/types"
@@ -27,27 +33,224 @@ func (dao *Dao) FindParamByKey(key string) (*model.Param, error) {
 	return param, nil
 }
 
+// FindParamVersion returns the current version of the Param with the provided key.
+func (dao *Dao) FindParamVersion(key string) (int64, error) {
+	var version int64
+
+	err := dao.ParamQuery().
+		Select("version").
+		AndWhere(dbx.HashExp{"key": key}).
+		Limit(1).
+		Row(&version)
+
+	return version, err
+}
+
 // SaveParam creates or updates a Param model by the provided key-value pair.
 // The value argument will be encoded as json string.
+//
+// The param version is incremented on each save and its expiration (if any) is cleared.
 func (dao *Dao) SaveParam(key string, value any) error {
-	param, _ := dao.FindParamByKey(key)
-	if param == nil {
-		param = &model.Param{Key: key}
-	}
+	return dao.saveParam(key, value, nil, -1)
+}
 
-	normalizedValue := value
+// errParamVersionMismatch is returned by saveParam when the stored
+// param version is different from the expected one.
+var errParamVersionMismatch = errors.New("param version mismatch")
 
+// saveParam creates or updates a Param model by the provided key-value pair
+// and increments its version.
+//
+// If expectedVersion is not negative, the param is saved only if its current
+// version matches (0 for a missing param), otherwise errParamVersionMismatch is returned.
+func (dao *Dao) saveParam(key string, value any, expires *types.DateTime, expectedVersion int64) error {
 	encodedValue := types.JsonRaw{}
-	if err := encodedValue.Scan(normalizedValue); err != nil {
+	if err := encodedValue.Scan(value); err != nil {
 		return err
 	}
 
-	param.Value = encodedValue
+	return dao.RunInTransaction(func(txDao *Dao) error {
+		param := &model.Param{}
+		err := txDao.ParamQuery().
+			AndWhere(dbx.HashExp{"key": key}).
+			Limit(1).
+			One(param)
+		if err != nil {
+			if err != sql.ErrNoRows {
+				return err
+			}
+			param = &model.Param{Key: key}
+		}
+
+		if expectedVersion >= 0 && param.Version != expectedVersion {
+			return errParamVersionMismatch
+		}
+
+		currentVersion := param.Version
+
+		param.Value = encodedValue
+		param.Expires = expires
+
+		if err := txDao.Save(param); err != nil {
+			return err
+		}
 
-	return dao.Save(param)
+		// increment the version with a single statement so that
+		// concurrent saves always end up with different versions
+		result, err := txDao.NonconcurrentDB().Update(
+			param.TableName(),
+			dbx.Params{"version": dbx.NewExp("[[version]] + 1")},
+			dbx.HashExp{"id": param.Id, "version": currentVersion},
+		).Execute()
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return errParamVersionMismatch
+		}
+
+		param.Version = currentVersion + 1
+
+		return nil
+	})
+}
+
+// DeleteExpiredParams deletes all Param models with expired TTL.
+func (dao *Dao) DeleteExpiredParams() error {
+	params := []model.Param{}
+
+	err := dao.ParamQuery().
+		AndWhere(dbx.NewExp(
+			"[[expires]] IS NOT NULL AND [[expires]] <= {:now}",
+			dbx.Params{"now": types.NowDateTime()},
+		)).
+		All(&params)
+	if err != nil {
+		return err
+	}
+
+	for i := range params {
+		if err := dao.DeleteParam(&params[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // DeleteParam deletes the provided Param model.
 func (dao *Dao) DeleteParam(param *model.Param) error {
 	return dao.Delete(param)
 }
+
+// -------------------------------------------------------------------
+// Typed params
+// -------------------------------------------------------------------
+
+// ParamValue decodes the value of the provided Param model into T.
+//
+// defaultValue is returned if param is nil or expired.
+func ParamValue[T any](param *model.Param, defaultValue T) (T, error) {
+	if param == nil || param.IsExpired() || len(param.Value) == 0 {
+		return defaultValue, nil
+	}
+
+	var value T
+	if err := json.Unmarshal(param.Value, &value); err != nil {
+		return defaultValue, fmt.Errorf("failed to decode param %q: %w", param.Key, err)
+	}
+
+	return value, nil
+}
+
+// GetParam returns the decoded value of the Param with the provided key.
+//
+// defaultValue is returned if the param doesn't exist or has expired.
+func GetParam[T any](dao *Dao, key string, defaultValue T) (T, error) {
+	value, _, err := GetParamWithVersion(dao, key, defaultValue)
+
+	return value, err
+}
+
+// GetParamWithVersion returns the decoded value and the version
+// of the Param with the provided key (see CompareAndSetParam).
+//
+// defaultValue and 0 version are returned if the param doesn't exist.
+// For expired params defaultValue is returned together with the stored version.
+func GetParamWithVersion[T any](dao *Dao, key string, defaultValue T) (T, int64, error) {
+	param, err := dao.FindParamByKey(key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return defaultValue, 0, nil
+		}
+		return defaultValue, 0, err
+	}
+
+	value, err := ParamValue(param, defaultValue)
+
+	return value, param.Version, err
+}
+
+// SetParam creates or updates the Param with the provided key and value
+// (the value is always json encoded, including strings and []byte).
+//
+// The param version is incremented on each save and its expiration (if any) is cleared.
+func SetParam[T any](dao *Dao, key string, value T) error {
+	encodedValue, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return dao.saveParam(key, types.JsonRaw(encodedValue), nil, -1)
+}
+
+// SetParamWithTTL creates or updates the Param with the provided key and value
+// that expires after ttl.
+//
+// The expired params are treated as missing by GetParam and
+// could be removed with [Dao.DeleteExpiredParams].
+func SetParamWithTTL[T any](dao *Dao, key string, value T, ttl time.Duration) error {
+	encodedValue, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	expires, err := types.ParseDateTime(time.Now().Add(ttl))
+	if err != nil {
+		return err
+	}
+
+	return dao.saveParam(key, types.JsonRaw(encodedValue), &expires, -1)
+}
+
+// CompareAndSetParam atomically updates the Param with the provided key
+// only if its current version matches the provided one (see GetParamWithVersion).
+//
+// Use 0 version to create the param only if it doesn't exist.
+//
+// Returns false (without error) if the param was changed in the meantime.
+func CompareAndSetParam[T any](dao *Dao, key string, version int64, value T) (bool, error) {
+	encodedValue, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	err = dao.saveParam(key, types.JsonRaw(encodedValue), nil, version)
+	if err == nil {
+		return true, nil
+	}
+
+	if err != errParamVersionMismatch {
+		// eg. a concurrent insert of the same missing param
+		if current, findErr := dao.FindParamVersion(key); findErr == nil && current != version {
+			err = errParamVersionMismatch
+		}
+	}
+
+	if err == errParamVersionMismatch {
+		return false, nil
+	}
+
+	return false, err
+}
@@ -0,0 +1,185 @@
+package dao_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/har4s/ohmygo/dao"
+	"github.com/har4s/ohmygo/model"
+	"github.com/har4s/ohmygo/model/settings"
+)
+
+func TestGetParam(t *testing.T) {
+	testDao := createTestDao(t)
+
+	// missing param
+	value, err := dao.GetParam(testDao, "test", 123)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 123 {
+		t.Fatalf("Expected the default value, got %v", value)
+	}
+
+	if err := dao.SetParam(testDao, "test", 456); err != nil {
+		t.Fatal(err)
+	}
+
+	value, version, err := dao.GetParamWithVersion(testDao, "test", 123)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 456 {
+		t.Fatalf("Expected 456, got %v", value)
+	}
+	if version != 1 {
+		t.Fatalf("Expected version 1, got %d", version)
+	}
+
+	// invalid value type
+	if _, err := dao.GetParam(testDao, "test", "default"); err == nil {
+		t.Fatal("Expected decode error, got nil")
+	}
+}
+
+func TestSetParamWithTTL(t *testing.T) {
+	testDao := createTestDao(t)
+
+	if err := dao.SetParamWithTTL(testDao, "expired", "a", -1*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := dao.SetParamWithTTL(testDao, "active", "b", 1*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := dao.SetParam(testDao, "permanent", "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		key           string
+		expectValue   string
+		expectExpires bool
+	}{
+		{"expired", "default", true},
+		{"active", "b", true},
+		{"permanent", "c", false},
+	}
+
+	for i, s := range scenarios {
+		value, err := dao.GetParam(testDao, s.key, "default")
+		if err != nil {
+			t.Errorf("(%d) Unexpected error %v", i, err)
+			continue
+		}
+		if value != s.expectValue {
+			t.Errorf("(%d) Expected %q, got %q", i, s.expectValue, value)
+		}
+
+		param, err := testDao.FindParamByKey(s.key)
+		if err != nil {
+			t.Errorf("(%d) Unexpected error %v", i, err)
+			continue
+		}
+		if hasExpires := param.Expires != nil; hasExpires != s.expectExpires {
+			t.Errorf("(%d) Expected expires %v, got %v", i, s.expectExpires, param.Expires)
+		}
+	}
+
+	if err := testDao.DeleteExpiredParams(); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	if err := testDao.ParamQuery().Select("key").OrderBy("key").Column(&keys); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != "active" || keys[1] != "permanent" {
+		t.Fatalf("Expected only the active and permanent params to remain, got %v", keys)
+	}
+
+	// saving without TTL clears the expiration
+	if err := dao.SetParam(testDao, "active", "d"); err != nil {
+		t.Fatal(err)
+	}
+	param, err := testDao.FindParamByKey("active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if param.Expires != nil {
+		t.Fatalf("Expected nil expires, got %v", param.Expires)
+	}
+}
+
+func TestCompareAndSetParam(t *testing.T) {
+	testDao := createTestDao(t)
+
+	scenarios := []struct {
+		version       int64
+		value         string
+		expectOk      bool
+		expectValue   string
+		expectVersion int64
+	}{
+		{1, "a", false, "", 0},  // missing param with non-zero version
+		{0, "a", true, "a", 1},  // create
+		{0, "b", false, "a", 1}, // create of an existing param
+		{1, "c", true, "c", 2},
+		{1, "d", false, "c", 2}, // stale version
+		{2, "e", true, "e", 3},
+	}
+
+	for i, s := range scenarios {
+		ok, err := dao.CompareAndSetParam(testDao, "test", s.version, s.value)
+		if err != nil {
+			t.Errorf("(%d) Unexpected error %v", i, err)
+			continue
+		}
+		if ok != s.expectOk {
+			t.Errorf("(%d) Expected ok %v, got %v", i, s.expectOk, ok)
+		}
+
+		value, version, err := dao.GetParamWithVersion(testDao, "test", "")
+		if err != nil {
+			t.Errorf("(%d) Unexpected error %v", i, err)
+			continue
+		}
+		if value != s.expectValue {
+			t.Errorf("(%d) Expected value %q, got %q", i, s.expectValue, value)
+		}
+		if version != s.expectVersion {
+			t.Errorf("(%d) Expected version %d, got %d", i, s.expectVersion, version)
+		}
+	}
+}
+
+func TestFindParamVersion(t *testing.T) {
+	testDao := createTestDao(t)
+
+	if _, err := testDao.FindParamVersion(model.ParamAppSettings); err != sql.ErrNoRows {
+		t.Fatalf("Expected sql.ErrNoRows for a missing param, got %v", err)
+	}
+
+	saves := []func() error{
+		func() error { return testDao.SaveSettings(settings.New()) },
+		func() error { return testDao.SaveSettings(settings.New()) }, // unchanged value
+		func() error { return testDao.SaveParam(model.ParamAppSettings, settings.New()) },
+	}
+
+	for i, save := range saves {
+		if err := save(); err != nil {
+			t.Errorf("(%d) Failed to save the param: %v", i, err)
+			continue
+		}
+
+		version, err := testDao.FindParamVersion(model.ParamAppSettings)
+		if err != nil {
+			t.Errorf("(%d) Unexpected error %v", i, err)
+			continue
+		}
+
+		if expected := int64(i + 1); version != expected {
+			t.Errorf("(%d) Expected version %d, got %d", i, expected, version)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// DefaultRetryPolicy is the retry policy used by the dao if none is set.
+var DefaultRetryPolicy = &RetryPolicy{
+	MaxAttempts: DefaultMaxFailRetries,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	Jitter:      0.25,
+}
+
+// RetryPolicy defines how the dao write operations (and whole
+// RunInTransaction blocks) are retried on transient db errors
+// like deadlocks, lock wait timeouts and serialization failures.
+type RetryPolicy struct {
+	// MaxAttempts is the max number of times the operation is
+	// executed (including the first one). Values <= 1 disable the retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry.
+	// Every next retry doubles the previous delay.
+	BaseDelay time.Duration
+
+	// MaxDelay is the upper limit of a single retry delay (0 means no limit).
+	MaxDelay time.Duration
+
+	// Jitter randomizes each delay by up to ±Jitter fraction
+	// of its value (eg. 0.25 for ±25%) to spread concurrent retries.
+	Jitter float64
+
+	// IsRetryable is an optional custom check whether an error should be retried.
+	// If not set, IsRetryableError is used.
+	IsRetryable func(err error) bool
+}
+
+// ShouldRetry reports whether the operation that failed with err
+// on the specified attempt (starting from 1) should be retried.
+func (p *RetryPolicy) ShouldRetry(err error, attempt int) bool {
+	if err == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+
+	if p.IsRetryable != nil {
+		return p.IsRetryable(err)
+	}
+
+	return IsRetryableError(err)
+}
+
+// Delay returns the backoff delay before the next retry of the specified
+// failed attempt (starting from 1).
+func (p *RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 && delay > 0 {
+		delta := float64(delay) * p.Jitter
+		delay += time.Duration(delta * (2*rand.Float64() - 1))
+	}
+
+	if delay < 0 {
+		return 0
+	}
+
+	return delay
+}
+
+// wait blocks for the retry delay of the specified attempt or
+// until the provided context is done (ctx could be nil).
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(p.Delay(attempt))
+	defer timer.Stop()
+
+	if ctx == nil {
+		<-timer.C
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// mysql error numbers that are safe to retry.
+const (
+	mysqlErrLockWaitTimeout = 1205
+	mysqlErrDeadlock        = 1213
+)
+
+// postgres error codes (SQLSTATE) that are safe to retry.
+const (
+	pgErrSerializationFailure = "40001"
+	pgErrDeadlockDetected     = "40P01"
+)
+
+// IsRetryableError reports whether err is a transient db error
+// after which the failed operation (or transaction) could be retried:
+//   - MySQL: 1213 (deadlock) and 1205 (lock wait timeout)
+//   - Postgres: 40001 (serialization failure) and 40P01 (deadlock detected)
+//   - SQLite: "database is locked"
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrDeadlock || mysqlErr.Number == mysqlErrLockWaitTimeout
+	}
+
+	// both lib/pq and pgx errors implement the SQLState method
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		code := pgErr.SQLState()
+		return code == pgErrSerializationFailure || code == pgErrDeadlockDetected
+	}
+
+	// note: we are checking the err message so that we can handle
+	// both the cgo and noncgo sqlite errors
+	return strings.Contains(err.Error(), "database is locked")
+}
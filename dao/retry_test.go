@@ -0,0 +1,212 @@
+package dao_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/har4s/ohmygo/dao"
+	"github.com/har4s/ohmygo/model"
+)
+
+type testPgError struct {
+	code string
+}
+
+func (e *testPgError) Error() string {
+	return "pg error " + e.code
+}
+
+func (e *testPgError) SQLState() string {
+	return e.code
+}
+
+func TestIsRetryableError(t *testing.T) {
+	scenarios := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("test"), false},
+		{errors.New("database is locked"), true},
+		{&mysql.MySQLError{Number: 1213}, true},
+		{&mysql.MySQLError{Number: 1205}, true},
+		{&mysql.MySQLError{Number: 1062}, false},
+		{fmt.Errorf("wrapped: %w", &mysql.MySQLError{Number: 1213}), true},
+		{&testPgError{"40001"}, true},
+		{&testPgError{"40P01"}, true},
+		{&testPgError{"23505"}, false},
+	}
+
+	for i, s := range scenarios {
+		result := dao.IsRetryableError(s.err)
+		if result != s.expected {
+			t.Errorf("(%d) Expected %v, got %v", i, s.expected, result)
+		}
+	}
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213}
+
+	policy := &dao.RetryPolicy{MaxAttempts: 3}
+
+	if policy.ShouldRetry(nil, 1) {
+		t.Error("Expected nil error to not be retried")
+	}
+
+	if !policy.ShouldRetry(deadlock, 2) {
+		t.Error("Expected attempt 2 to be retried")
+	}
+
+	if policy.ShouldRetry(deadlock, 3) {
+		t.Error("Expected attempt 3 to not be retried")
+	}
+
+	policy.IsRetryable = func(err error) bool { return false }
+	if policy.ShouldRetry(deadlock, 1) {
+		t.Error("Expected the custom IsRetryable check to be used")
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := &dao.RetryPolicy{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  300 * time.Millisecond,
+	}
+
+	scenarios := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 300 * time.Millisecond},
+		{10, 300 * time.Millisecond},
+	}
+
+	for _, s := range scenarios {
+		if d := policy.Delay(s.attempt); d != s.expected {
+			t.Errorf("(%d) Expected delay %v, got %v", s.attempt, s.expected, d)
+		}
+	}
+
+	// with jitter
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := policy.Delay(1)
+		if d < 50*time.Millisecond || d > 150*time.Millisecond {
+			t.Fatalf("Expected delay between 50ms and 150ms, got %v", d)
+		}
+	}
+}
+
+func TestRunInTransactionRetry(t *testing.T) {
+	testDao := createTestDao(t)
+	testDao.RetryPolicy = &dao.RetryPolicy{MaxAttempts: 3}
+
+	existing := createTestUser(t, testDao, "existing@example.com")
+
+	created := &model.User{Email: "created@example.com", PasswordHash: "test"}
+	created.RefreshTokenKey()
+
+	existing.Email = "updated@example.com"
+
+	attempts := 0
+	err := testDao.RunInTransaction(func(txDao *dao.Dao) error {
+		attempts++
+
+		if err := txDao.SaveUser(created); err != nil {
+			return err
+		}
+
+		if err := txDao.SaveUser(existing); err != nil {
+			return err
+		}
+
+		if attempts == 1 {
+			return &mysql.MySQLError{Number: 1213} // deadlock
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if attempts != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", attempts)
+	}
+
+	if created.IsNew() {
+		t.Fatal("Expected the created user to be marked as not new")
+	}
+
+	if _, err := testDao.FindUserById(created.Id); err != nil {
+		t.Fatalf("Expected the created user to be persisted, got %v", err)
+	}
+
+	updated, err := testDao.FindUserById(existing.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Email != "updated@example.com" {
+		t.Fatalf("Expected the updated email to be persisted, got %q", updated.Email)
+	}
+}
+
+func TestRunInTransactionRollbackRestoresModels(t *testing.T) {
+	testDao := createTestDao(t)
+
+	existing := createTestUser(t, testDao, "existing@example.com")
+
+	created := &model.User{Email: "created@example.com", PasswordHash: "test"}
+	created.RefreshTokenKey()
+
+	existing.Email = "updated@example.com"
+
+	err := testDao.RunInTransaction(func(txDao *dao.Dao) error {
+		if err := txDao.SaveUser(existing); err != nil {
+			return err
+		}
+
+		// rolled back savepoint
+		txDao.RunInTransaction(func(nestedDao *dao.Dao) error {
+			if err := nestedDao.SaveUser(created); err != nil {
+				return err
+			}
+			return errors.New("test")
+		})
+
+		if !created.IsNew() {
+			t.Error("Expected the created user to be new after the savepoint rollback")
+		}
+
+		return errors.New("test")
+	})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	// the rolled back changes are saved again
+	if err := testDao.SaveUser(existing); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDao.SaveUser(created); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := testDao.FindUserById(existing.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Email != "updated@example.com" {
+		t.Fatalf("Expected the updated email to be persisted, got %q", updated.Email)
+	}
+
+	if _, err := testDao.FindUserById(created.Id); err != nil {
+		t.Fatalf("Expected the created user to be persisted, got %v", err)
+	}
+}
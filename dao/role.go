@@ -0,0 +1,189 @@
+package dao
+
+import (
+	"errors"
+
+	"github.com/har4s/ohmygo/dbx"
+	"github.com/har4s/ohmygo/model"
+)
+
+// RoleQuery returns a new Role select query.
+func (dao *Dao) RoleQuery() *dbx.SelectQuery {
+	return dao.ModelQuery(&model.Role{})
+}
+
+// UserRoleQuery returns a new UserRole select query.
+func (dao *Dao) UserRoleQuery() *dbx.SelectQuery {
+	return dao.ModelQuery(&model.UserRole{})
+}
+
+// FindRoleById finds the role with the provided id.
+func (dao *Dao) FindRoleById(id string) (*model.Role, error) {
+	model := &model.Role{}
+
+	err := dao.RoleQuery().
+		AndWhere(dbx.HashExp{"id": id}).
+		Limit(1).
+		One(model)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// FindRoleByName finds the role with the provided name.
+func (dao *Dao) FindRoleByName(name string) (*model.Role, error) {
+	model := &model.Role{}
+
+	err := dao.RoleQuery().
+		AndWhere(dbx.HashExp{"name": name}).
+		Limit(1).
+		One(model)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// FindUserRoles returns all roles assigned to the user with the provided id.
+func (dao *Dao) FindUserRoles(userId string) ([]*model.Role, error) {
+	rows := []model.Role{}
+
+	err := dao.RoleQuery().
+		InnerJoin("user_roles", dbx.NewExp("[[user_roles.roleId]] = [[roles.id]]")).
+		AndWhere(dbx.HashExp{"user_roles.userId": userId}).
+		OrderBy("roles.name ASC").
+		All(&rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]*model.Role, len(rows))
+	for i := range rows {
+		roles[i] = &rows[i]
+	}
+
+	return roles, nil
+}
+
+// ExpandUserRoles loads the roles assigned to the provided user into user.Roles.
+func (dao *Dao) ExpandUserRoles(user *model.User) error {
+	roles, err := dao.FindUserRoles(user.Id)
+	if err != nil {
+		return err
+	}
+
+	user.Roles = roles
+
+	return nil
+}
+
+// UserHasPermission checks whether any of the roles assigned to
+// the provided user grants the specified permission.
+//
+// The user roles are loaded if not already (see ExpandUserRoles).
+func (dao *Dao) UserHasPermission(user *model.User, permission string) (bool, error) {
+	if user.Roles == nil {
+		if err := dao.ExpandUserRoles(user); err != nil {
+			return false, err
+		}
+	}
+
+	return user.HasPermission(permission), nil
+}
+
+// AssignUserRole assigns the provided role to the user
+// (does nothing if the role is already assigned).
+func (dao *Dao) AssignUserRole(user *model.User, role *model.Role) error {
+	var exists bool
+
+	err := dao.UserRoleQuery().
+		Select("count(*)").
+		AndWhere(dbx.HashExp{"userId": user.Id, "roleId": role.Id}).
+		Limit(1).
+		Row(&exists)
+
+	if err != nil || exists {
+		return err
+	}
+
+	if err := dao.Save(&model.UserRole{UserId: user.Id, RoleId: role.Id}); err != nil {
+		return err
+	}
+
+	if user.Roles != nil {
+		user.Roles = append(user.Roles, role)
+	}
+
+	return nil
+}
+
+// RevokeUserRole removes the provided role assignment from the user.
+func (dao *Dao) RevokeUserRole(user *model.User, role *model.Role) error {
+	userRoles := []model.UserRole{}
+
+	err := dao.UserRoleQuery().
+		AndWhere(dbx.HashExp{"userId": user.Id, "roleId": role.Id}).
+		All(&userRoles)
+
+	if err != nil {
+		return err
+	}
+
+	for i := range userRoles {
+		if err := dao.Delete(&userRoles[i]); err != nil {
+			return err
+		}
+	}
+
+	if user.Roles != nil {
+		roles := make([]*model.Role, 0, len(user.Roles))
+		for _, r := range user.Roles {
+			if r.Id != role.Id {
+				roles = append(roles, r)
+			}
+		}
+		user.Roles = roles
+	}
+
+	return nil
+}
+
+// SaveRole upserts the provided Role model.
+func (dao *Dao) SaveRole(role *model.Role) error {
+	return dao.Save(role)
+}
+
+// DeleteRole deletes the provided Role model together with its user assignments.
+//
+// Returns an error if the role is a built-in (aka. system) one.
+func (dao *Dao) DeleteRole(role *model.Role) error {
+	if role.IsSystem {
+		return errors.New("you cannot delete a system role")
+	}
+
+	return dao.RunInTransaction(func(txDao *Dao) error {
+		userRoles := []model.UserRole{}
+
+		err := txDao.UserRoleQuery().
+			AndWhere(dbx.HashExp{"roleId": role.Id}).
+			All(&userRoles)
+
+		if err != nil {
+			return err
+		}
+
+		for i := range userRoles {
+			if err := txDao.Delete(&userRoles[i]); err != nil {
+				return err
+			}
+		}
+
+		return txDao.Delete(role)
+	})
+}
@@ -0,0 +1,178 @@
+package dao_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/har4s/ohmygo/dao"
+	"github.com/har4s/ohmygo/dbx"
+	"github.com/har4s/ohmygo/model"
+)
+
+func createTestUser(t *testing.T, testDao *dao.Dao, email string) *model.User {
+	user := &model.User{Email: email, PasswordHash: "test"}
+	user.RefreshTokenKey()
+
+	if err := testDao.SaveUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+func createTestRole(t *testing.T, testDao *dao.Dao, name string, permissions ...string) *model.Role {
+	role := &model.Role{Name: name, Permissions: permissions}
+
+	if err := testDao.SaveRole(role); err != nil {
+		t.Fatal(err)
+	}
+
+	return role
+}
+
+func TestAssignAndRevokeUserRole(t *testing.T) {
+	testDao := createTestDao(t)
+
+	user := createTestUser(t, testDao, "test@example.com")
+	editor := createTestRole(t, testDao, "editor", model.PermissionUsersView)
+
+	// assigning an already assigned role does nothing
+	for i := 0; i < 2; i++ {
+		if err := testDao.AssignUserRole(user, editor); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var total int
+	err := testDao.UserRoleQuery().
+		Select("count(*)").
+		AndWhere(dbx.HashExp{"userId": user.Id}).
+		Row(&total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 {
+		t.Fatalf("Expected 1 role assignment, got %d", total)
+	}
+
+	roles, err := testDao.FindUserRoles(user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 1 || roles[0].Id != editor.Id {
+		t.Fatalf("Expected the editor role to be assigned, got %v", roles)
+	}
+
+	// the loaded user roles are updated
+	if err := testDao.ExpandUserRoles(user); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDao.RevokeUserRole(user, editor); err != nil {
+		t.Fatal(err)
+	}
+	if len(user.Roles) != 0 {
+		t.Fatalf("Expected the user roles to be updated, got %v", user.Roles)
+	}
+
+	roles, err = testDao.FindUserRoles(user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 0 {
+		t.Fatalf("Expected no assigned roles, got %v", roles)
+	}
+
+	// revoking a not assigned role does nothing
+	if err := testDao.RevokeUserRole(user, editor); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserHasPermission(t *testing.T) {
+	testDao := createTestDao(t)
+
+	admin, err := testDao.FindRoleByName(model.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	superadmin, err := testDao.FindRoleByName(model.RoleSuperadmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	editor := createTestRole(t, testDao, "editor", model.PermissionUsersView, model.PermissionUsersUpdate)
+
+	scenarios := []struct {
+		roles      []*model.Role
+		permission string
+		expected   bool
+	}{
+		{nil, model.PermissionUsersView, false},
+		{[]*model.Role{editor}, model.PermissionUsersView, true},
+		{[]*model.Role{editor}, model.PermissionUsersDelete, false},
+		{[]*model.Role{editor}, model.PermissionSettingsView, false},
+		{[]*model.Role{admin}, model.PermissionSettingsUpdate, true},
+		{[]*model.Role{admin}, "roles.delete", false},
+		{[]*model.Role{editor, admin}, model.PermissionUsersDelete, true},
+		{[]*model.Role{superadmin}, "roles.delete", true},
+	}
+
+	for i, s := range scenarios {
+		user := createTestUser(t, testDao, fmt.Sprintf("test%d@example.com", i))
+		for _, role := range s.roles {
+			if err := testDao.AssignUserRole(user, role); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// load the roles from the db
+		user.Roles = nil
+
+		result, err := testDao.UserHasPermission(user, s.permission)
+		if err != nil {
+			t.Errorf("(%d) Unexpected error %v", i, err)
+			continue
+		}
+		if result != s.expected {
+			t.Errorf("(%d) Expected %v, got %v", i, s.expected, result)
+		}
+	}
+}
+
+func TestDeleteRole(t *testing.T) {
+	testDao := createTestDao(t)
+
+	admin, err := testDao.FindRoleByName(model.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testDao.DeleteRole(admin); err == nil {
+		t.Fatal("Expected the system role delete to fail")
+	}
+
+	user := createTestUser(t, testDao, "test@example.com")
+	editor := createTestRole(t, testDao, "editor", model.PermissionUsersView)
+	if err := testDao.AssignUserRole(user, editor); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := testDao.DeleteRole(editor); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := testDao.FindRoleById(editor.Id); err == nil {
+		t.Fatal("Expected the role to be deleted")
+	}
+
+	roles, err := testDao.FindUserRoles(user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 0 {
+		t.Fatalf("Expected the role assignments to be deleted, got %v", roles)
+	}
+
+	// the other roles are not affected
+	if _, err := testDao.FindRoleById(admin.Id); err != nil {
+		t.Fatalf("Expected the admin role to remain, got %v", err)
+	}
+}
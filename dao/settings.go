@@ -6,27 +6,69 @@ import (
 
 	"github.com/har4s/ohmygo/model"
 	"github.com/har4s/ohmygo/model/settings"
+	"github.com/har4s/ohmygo/tools/types"
 )
 
 // FindSettings returns and decode the serialized app settings param value.
 //
-// Returns an error if it fails to decode the stored serialized param value.
+// Encrypted settings are decrypted with [Dao.EncryptionKeyring].
+//
+// Returns an error if it fails to decode or decrypt the stored serialized param value.
 func (d *Dao) FindSettings() (*settings.Settings, error) {
 	param, err := d.FindParamByKey(model.ParamAppSettings)
 	if err != nil {
 		return nil, err
 	}
 
+	data := types.EncryptedJsonRaw(param.Value)
+
+	// the encrypted settings are stored as a json string
+	var encrypted string
+	if err := json.Unmarshal(param.Value, &encrypted); err == nil {
+		if err := data.WithKeyring(d.EncryptionKeyring).Scan(encrypted); err != nil {
+			return nil, errors.New("failed to decrypt the stored app settings: " + err.Error())
+		}
+	}
+
 	result := settings.New()
 
-	if err := json.Unmarshal(param.Value, result); err != nil {
+	if err := json.Unmarshal(data, result); err != nil {
 		return nil, errors.New("failed to load the stored app settings")
 	}
 
+	// settings stored with only their secret values encrypted
+	if err := result.DecryptSecrets(d.EncryptionKeyring); err != nil {
+		return nil, errors.New("failed to decrypt the stored app settings: " + err.Error())
+	}
+
 	return result, nil
 }
 
 // SaveSettings persists the specified settings configuration.
+//
+// If [Dao.EncryptionKeyring] is set, the whole serialized settings value
+// is encrypted with its primary key and stored as a json string.
 func (dao *Dao) SaveSettings(newSettings *settings.Settings) error {
-	return dao.SaveParam(model.ParamAppSettings, newSettings)
+	if dao.EncryptionKeyring == nil {
+		return dao.SaveParam(model.ParamAppSettings, newSettings)
+	}
+
+	data, err := json.Marshal(newSettings)
+	if err != nil {
+		return err
+	}
+
+	value := types.EncryptedJsonRaw(data)
+
+	encrypted, err := value.WithKeyring(dao.EncryptionKeyring).Value()
+	if err != nil {
+		return err
+	}
+
+	encodedValue, err := json.Marshal(encrypted)
+	if err != nil {
+		return err
+	}
+
+	return dao.SaveParam(model.ParamAppSettings, encodedValue)
 }
@@ -0,0 +1,113 @@
+package dao_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/har4s/ohmygo/model"
+	"github.com/har4s/ohmygo/model/settings"
+	"github.com/har4s/ohmygo/tools/security"
+	"github.com/har4s/ohmygo/tools/types"
+)
+
+func TestSaveAndFindSettings(t *testing.T) {
+	keyring, _ := security.NewKeyring("k1", map[string]string{
+		"k1": "abcdabcdabcdabcdabcdabcdabcdabcd",
+	})
+	otherKeyring, _ := security.NewKeyring("k2", map[string]string{
+		"k2": "efghefghefghefghefghefghefghefgh",
+	})
+
+	scenarios := []struct {
+		saveKeyring     *security.Keyring
+		findKeyring     *security.Keyring
+		expectEncrypted bool
+		expectError     bool
+	}{
+		{nil, nil, false, false},
+		{nil, keyring, false, false},
+		{keyring, keyring, true, false},
+		{keyring, nil, true, true},
+		{keyring, otherKeyring, true, true},
+	}
+
+	for i, s := range scenarios {
+		testDao := createTestDao(t)
+
+		newSettings := settings.New()
+		newSettings.Meta.AppName = "test_app"
+		newSettings.Smtp.Password = "test_password"
+
+		testDao.EncryptionKeyring = s.saveKeyring
+		if err := testDao.SaveSettings(newSettings); err != nil {
+			t.Errorf("(%d) Failed to save the settings: %v", i, err)
+			continue
+		}
+
+		param, err := testDao.FindParamByKey(model.ParamAppSettings)
+		if err != nil {
+			t.Errorf("(%d) Failed to find the settings param: %v", i, err)
+			continue
+		}
+
+		var stored string
+		isEncrypted := json.Unmarshal(param.Value, &stored) == nil && types.IsEncrypted(stored)
+		if isEncrypted != s.expectEncrypted {
+			t.Errorf("(%d) Expected encrypted %v, got %v (%s)", i, s.expectEncrypted, isEncrypted, param.Value)
+		}
+		if s.expectEncrypted && strings.Contains(param.Value.String(), "test_app") {
+			t.Errorf("(%d) Expected the whole settings value to be encrypted, got %s", i, param.Value)
+		}
+
+		testDao.EncryptionKeyring = s.findKeyring
+		result, err := testDao.FindSettings()
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if hasErr {
+			continue
+		}
+
+		if result.Meta.AppName != "test_app" {
+			t.Errorf("(%d) Expected app name %q, got %q", i, "test_app", result.Meta.AppName)
+		}
+
+		if result.Smtp.Password != "test_password" {
+			t.Errorf("(%d) Expected smtp password %q, got %q", i, "test_password", result.Smtp.Password)
+		}
+	}
+}
+
+func TestFindSettingsWithEncryptedSecrets(t *testing.T) {
+	keyring, _ := security.NewKeyring("k1", map[string]string{
+		"k1": "abcdabcdabcdabcdabcdabcdabcdabcd",
+	})
+
+	testDao := createTestDao(t)
+	testDao.EncryptionKeyring = keyring
+
+	// settings persisted with only their secret values encrypted
+	password, err := types.EncryptedString("test_password").Encrypt(keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldSettings := settings.New()
+	oldSettings.Smtp.Password = types.EncryptedString(password)
+	if err := testDao.SaveParam(model.ParamAppSettings, oldSettings); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := testDao.FindSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Smtp.Password != "test_password" {
+		t.Fatalf("Expected smtp password %q, got %q", "test_password", result.Smtp.Password)
+	}
+}
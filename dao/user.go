@@ -112,7 +112,7 @@ func (dao *Dao) IsUserEmailUnique(email string, excludeIds ...string) bool {
 	return query.Row(&exists) == nil && !exists
 }
 
-// DeleteUser deletes the provided User model.
+// DeleteUser deletes the provided User model together with its role assignments.
 //
 // Returns an error if there is only 1 user.
 func (dao *Dao) DeleteUser(user *model.User) error {
@@ -125,7 +125,25 @@ func (dao *Dao) DeleteUser(user *model.User) error {
 		return errors.New("you cannot delete the only existing user")
 	}
 
-	return dao.Delete(user)
+	return dao.RunInTransaction(func(txDao *Dao) error {
+		userRoles := []model.UserRole{}
+
+		err := txDao.UserRoleQuery().
+			AndWhere(dbx.HashExp{"userId": user.Id}).
+			All(&userRoles)
+
+		if err != nil {
+			return err
+		}
+
+		for i := range userRoles {
+			if err := txDao.Delete(&userRoles[i]); err != nil {
+				return err
+			}
+		}
+
+		return txDao.Delete(user)
+	})
 }
 
 // SaveUser upserts the provided User model.
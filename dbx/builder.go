@@ -52,6 +52,9 @@ type Builder interface {
 	// The keys of cols are the column names, while the values of cols are the corresponding column
 	// values to be inserted.
 	Upsert(table string, cols Params, constraints ...string) *Query
+	// UpsertReturning creates a Query that represents an UPSERT SQL statement returning the inserted or updated row.
+	// The returned row could be populated with the Query.One() method.
+	UpsertReturning(table string, cols Params, constraints ...string) *Query
 	// Update creates a Query that represents an UPDATE SQL statement.
 	// The keys of cols are the column names, while the values of cols are the corresponding new column
 	// values. If the "where" expression is nil, the UPDATE SQL statement will have no WHERE clause
@@ -100,8 +103,20 @@ type Builder interface {
 	CreateIndex(table, name string, cols ...string) *Query
 	// CreateUniqueIndex creates a Query that can be used to create a unique index for a table.
 	CreateUniqueIndex(table, name string, cols ...string) *Query
+	// CreateFullTextIndex creates a Query that can be used to create a full-text search index for a table.
+	CreateFullTextIndex(table, name string, cols ...string) *Query
+	// DropFullTextIndex creates a Query that can be used to remove the named full-text search index from a table.
+	DropFullTextIndex(table, name string) *Query
 	// DropIndex creates a Query that can be used to remove the named index from a table.
 	DropIndex(table, name string) *Query
+
+	// Savepoint creates a Query that can be used to set a named transaction savepoint.
+	Savepoint(name string) *Query
+	// RollbackToSavepoint creates a Query that can be used to roll back a transaction to the named savepoint.
+	RollbackToSavepoint(name string) *Query
+	// ReleaseSavepoint creates a Query that can be used to release the named transaction savepoint.
+	// It returns nil if the DB doesn't support releasing savepoints.
+	ReleaseSavepoint(name string) *Query
 }
 
 // BaseBuilder provides a basic implementation of the Builder interface.
@@ -184,18 +199,21 @@ func (b *BaseBuilder) Insert(table string, cols Params) *Query {
 		}
 	}
 
-	var sql string
+	sql := "INSERT INTO " + b.db.QuoteTableName(table)
+	if len(names) > 0 {
+		sql += " (" + strings.Join(columns, ", ") + ")"
+	}
+	outputPos := len(sql)
 	if len(names) == 0 {
-		sql = fmt.Sprintf("INSERT INTO %v DEFAULT VALUES", b.db.QuoteTableName(table))
+		sql += " DEFAULT VALUES"
 	} else {
-		sql = fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v)",
-			b.db.QuoteTableName(table),
-			strings.Join(columns, ", "),
-			strings.Join(values, ", "),
-		)
+		sql += " VALUES (" + strings.Join(values, ", ") + ")"
 	}
 
-	return b.NewQuery(sql).Bind(params)
+	q := b.NewQuery(sql).Bind(params)
+	q.outputPos = outputPos
+
+	return q
 }
 
 // Upsert creates a Query that represents an UPSERT SQL statement.
@@ -209,11 +227,26 @@ func (b *BaseBuilder) Upsert(table string, cols Params, constraints ...string) *
 	return q
 }
 
+// UpsertReturning creates a Query that represents an UPSERT SQL statement returning the inserted or updated row.
+// The returned row could be populated with the Query.One() method.
+func (b *BaseBuilder) UpsertReturning(table string, cols Params, constraints ...string) *Query {
+	q := b.NewQuery("")
+	q.LastError = errors.New("UpsertReturning is not supported")
+	return q
+}
+
 // Update creates a Query that represents an UPDATE SQL statement.
 // The keys of cols are the column names, while the values of cols are the corresponding new column
 // values. If the "where" expression is nil, the UPDATE SQL statement will have no WHERE clause
 // (be careful in this case as the SQL statement will update ALL rows in the table).
-func (b *BaseBuilder) Update(table string, cols Params, where Expression) *Query {
+func (b *BaseBuilder) Update(table string, cols Params, where Expression) (q *Query) {
+	defer func() {
+		if r := recover(); r != nil {
+			q = b.NewQuery("")
+			q.LastError = recoverExpressionError(r)
+		}
+	}()
+
 	names := make([]string, 0, len(cols))
 	for name := range cols {
 		names = append(names, name)
@@ -234,6 +267,7 @@ func (b *BaseBuilder) Update(table string, cols Params, where Expression) *Query
 	}
 
 	sql := fmt.Sprintf("UPDATE %v SET %v", b.db.QuoteTableName(table), strings.Join(lines, ", "))
+	outputPos := len(sql)
 	if where != nil {
 		w := where.Build(b.db, params)
 		if w != "" {
@@ -241,14 +275,25 @@ func (b *BaseBuilder) Update(table string, cols Params, where Expression) *Query
 		}
 	}
 
-	return b.NewQuery(sql).Bind(params)
+	q = b.NewQuery(sql).Bind(params)
+	q.outputPos = outputPos
+
+	return q
 }
 
 // Delete creates a Query that represents a DELETE SQL statement.
 // If the "where" expression is nil, the DELETE SQL statement will have no WHERE clause
 // (be careful in this case as the SQL statement will delete ALL rows in the table).
-func (b *BaseBuilder) Delete(table string, where Expression) *Query {
+func (b *BaseBuilder) Delete(table string, where Expression) (q *Query) {
+	defer func() {
+		if r := recover(); r != nil {
+			q = b.NewQuery("")
+			q.LastError = recoverExpressionError(r)
+		}
+	}()
+
 	sql := "DELETE FROM " + b.db.QuoteTableName(table)
+	outputPos := len(sql)
 	params := Params{}
 	if where != nil {
 		w := where.Build(b.db, params)
@@ -256,7 +301,11 @@ func (b *BaseBuilder) Delete(table string, where Expression) *Query {
 			sql += " WHERE " + w
 		}
 	}
-	return b.NewQuery(sql).Bind(params)
+
+	q = b.NewQuery(sql).Bind(params)
+	q.outputPos = outputPos
+
+	return q
 }
 
 // CreateTable creates a Query that represents a CREATE TABLE SQL statement.
@@ -382,12 +431,65 @@ func (b *BaseBuilder) CreateUniqueIndex(table, name string, cols ...string) *Que
 	return b.NewQuery(sql)
 }
 
+// CreateFullTextIndex creates a Query that can be used to create a full-text search index for a table.
+func (b *BaseBuilder) CreateFullTextIndex(table, name string, cols ...string) *Query {
+	sql := fmt.Sprintf("CREATE FULLTEXT INDEX %v ON %v (%v)",
+		b.db.QuoteColumnName(name),
+		b.db.QuoteTableName(table),
+		b.quoteColumns(cols))
+	return b.NewQuery(sql)
+}
+
+// DropFullTextIndex creates a Query that can be used to remove the named full-text search index from a table.
+func (b *BaseBuilder) DropFullTextIndex(table, name string) *Query {
+	return b.DropIndex(table, name)
+}
+
 // DropIndex creates a Query that can be used to remove the named index from a table.
 func (b *BaseBuilder) DropIndex(table, name string) *Query {
 	sql := fmt.Sprintf("DROP INDEX %v ON %v", b.db.QuoteColumnName(name), b.db.QuoteTableName(table))
 	return b.NewQuery(sql)
 }
 
+// Savepoint creates a Query that can be used to set a named transaction savepoint.
+func (b *BaseBuilder) Savepoint(name string) *Query {
+	sql := "SAVEPOINT " + b.db.QuoteColumnName(name)
+	return b.NewQuery(sql)
+}
+
+// RollbackToSavepoint creates a Query that can be used to roll back a transaction to the named savepoint.
+func (b *BaseBuilder) RollbackToSavepoint(name string) *Query {
+	sql := "ROLLBACK TO SAVEPOINT " + b.db.QuoteColumnName(name)
+	return b.NewQuery(sql)
+}
+
+// ReleaseSavepoint creates a Query that can be used to release the named transaction savepoint.
+func (b *BaseBuilder) ReleaseSavepoint(name string) *Query {
+	sql := "RELEASE SAVEPOINT " + b.db.QuoteColumnName(name)
+	return b.NewQuery(sql)
+}
+
+// buildInsertValues builds the placeholders of the cols values with the provided names
+// and binds the values to params.
+//
+// The expression params are merged (and renamed on collision) so that
+// they don't overwrite the generated placeholders and vice versa.
+func buildInsertValues(db *DB, names []string, cols Params, params Params) []string {
+	values := make([]string, 0, len(names))
+	for _, name := range names {
+		value := cols[name]
+		if e, ok := value.(Expression); ok {
+			expParams := Params{}
+			values = append(values, mergeParams(e.Build(db, expParams), expParams, params))
+		} else {
+			pn := paramName(params)
+			values = append(values, "{:"+pn+"}")
+			params[pn] = value
+		}
+	}
+	return values
+}
+
 // quoteColumns quotes a list of columns and concatenates them with commas.
 func (b *BaseBuilder) quoteColumns(cols []string) string {
 	s := ""
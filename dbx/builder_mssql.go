@@ -5,7 +5,9 @@
 package dbx
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -85,6 +87,109 @@ func (b *MssqlBuilder) AlterColumn(table, col, typ string) *Query {
 	return b.NewQuery(sql)
 }
 
+// Savepoint creates a Query that can be used to set a named transaction savepoint.
+func (b *MssqlBuilder) Savepoint(name string) *Query {
+	sql := "SAVE TRANSACTION " + b.db.QuoteColumnName(name)
+	return b.NewQuery(sql)
+}
+
+// RollbackToSavepoint creates a Query that can be used to roll back a transaction to the named savepoint.
+func (b *MssqlBuilder) RollbackToSavepoint(name string) *Query {
+	sql := "ROLLBACK TRANSACTION " + b.db.QuoteColumnName(name)
+	return b.NewQuery(sql)
+}
+
+// ReleaseSavepoint returns nil since SQL Server doesn't support releasing savepoints
+// (they are discarded together with the transaction).
+func (b *MssqlBuilder) ReleaseSavepoint(name string) *Query {
+	return nil
+}
+
+// Upsert creates a Query that represents an UPSERT SQL statement.
+// Upsert inserts a row into the table if the primary key or unique index is not found.
+// Otherwise it will update the row with the new values.
+// The keys of cols are the column names, while the values of cols are the corresponding column
+// values to be inserted.
+//
+// The statement is a MERGE statement, which requires the constraint columns
+// to be specified and to be part of cols.
+func (b *MssqlBuilder) Upsert(table string, cols Params, constraints ...string) *Query {
+	return b.buildMerge(table, cols, constraints, "")
+}
+
+// UpsertReturning creates a Query that represents an UPSERT SQL statement returning the inserted or updated row.
+// The returned row could be populated with the Query.One() method.
+func (b *MssqlBuilder) UpsertReturning(table string, cols Params, constraints ...string) *Query {
+	return b.buildMerge(table, cols, constraints, "OUTPUT inserted.*")
+}
+
+// buildMerge builds the upsert MERGE statement with the optional output clause.
+func (b *MssqlBuilder) buildMerge(table string, cols Params, constraints []string, output string) *Query {
+	if len(constraints) == 0 {
+		q := b.NewQuery("")
+		q.LastError = errors.New("SQL Server Upsert requires the constraint columns")
+		return q
+	}
+
+	isConstraint := make(map[string]bool, len(constraints))
+	for _, c := range constraints {
+		if _, ok := cols[c]; !ok {
+			q := b.NewQuery("")
+			q.LastError = fmt.Errorf("missing value for the %q constraint column", c)
+			return q
+		}
+		isConstraint[c] = true
+	}
+
+	names := []string{}
+	for name := range cols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := Params{}
+	values := buildInsertValues(b.db, names, cols, params)
+	columns := make([]string, 0, len(names))
+	sources := make([]string, 0, len(names))
+	updates := []string{}
+	for _, name := range names {
+		col := b.db.QuoteColumnName(name)
+		columns = append(columns, col)
+		sources = append(sources, "s."+col)
+		if !isConstraint[name] {
+			updates = append(updates, "t."+col+"=s."+col)
+		}
+	}
+
+	on := make([]string, len(constraints))
+	for i, c := range constraints {
+		c = b.db.QuoteColumnName(c)
+		on[i] = "t." + c + "=s." + c
+	}
+
+	sql := fmt.Sprintf("MERGE INTO %v WITH (HOLDLOCK) AS t USING (VALUES (%v)) AS s (%v) ON %v",
+		b.db.QuoteTableName(table),
+		strings.Join(values, ", "),
+		strings.Join(columns, ", "),
+		strings.Join(on, " AND "),
+	)
+	if len(updates) > 0 {
+		sql += " WHEN MATCHED THEN UPDATE SET " + strings.Join(updates, ", ")
+	}
+	sql += fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%v) VALUES (%v)", strings.Join(columns, ", "), strings.Join(sources, ", "))
+	if output != "" {
+		sql += " " + output
+	}
+
+	// MERGE statements must be terminated by a semicolon
+	q := b.NewQuery(sql + ";").Bind(params)
+	if output == "" {
+		q.outputPos = len(sql)
+	}
+
+	return q
+}
+
 // BuildOrderByAndLimit generates the ORDER BY and LIMIT clauses.
 func (q *MssqlQueryBuilder) BuildOrderByAndLimit(sql string, cols []string, limit int64, offset int64) string {
 	orderBy := q.BuildOrderBy(cols)
@@ -113,3 +218,126 @@ func (q *MssqlQueryBuilder) BuildOrderByAndLimit(sql string, cols []string, limi
 	}
 	return sql
 }
+
+// BuildWith generates a WITH clause from the given common table expressions.
+// SQL Server doesn't use the RECURSIVE keyword for recursive common table expressions.
+func (q *MssqlQueryBuilder) BuildWith(ctes []CTEInfo, params Params) string {
+	return q.buildWith(ctes, params, "WITH")
+}
+
+// BuildLock applies the row locking options to the given FROM clause and
+// returns it together with the locking clause to be appended to the statement.
+//
+// SQL Server doesn't support locking clauses and uses table hints instead,
+// which are applied to the last table of the FROM clause.
+func (q *MssqlQueryBuilder) BuildLock(from string, lock LockInfo) (string, string) {
+	if from == "" {
+		return from, ""
+	}
+
+	var hints []string
+	switch lock.Mode {
+	case LockForUpdate:
+		hints = []string{"UPDLOCK", "ROWLOCK"}
+	case LockForShare:
+		hints = []string{"HOLDLOCK", "ROWLOCK"}
+	default:
+		return from, ""
+	}
+
+	switch lock.Option {
+	case LockSkipLocked:
+		hints = append(hints, "READPAST")
+	case LockNoWait:
+		hints = append(hints, "NOWAIT")
+	}
+
+	return from + " WITH (" + strings.Join(hints, ", ") + ")", ""
+}
+
+// BuildKeyset generates the keyset pagination predicate selecting the rows after the given column values.
+// SQL Server doesn't support row value comparison, so the predicate is expanded to "(a > ?) OR (a = ? AND b > ?)".
+func (q *MssqlQueryBuilder) BuildKeyset(cols []KeysetColumn, values []interface{}, params Params) string {
+	return q.buildExpandedKeyset(cols, values, params)
+}
+
+// BuildJsonExtract generates an expression extracting the scalar value at the JSON path of the given column.
+func (q *MssqlQueryBuilder) BuildJsonExtract(col, path string, params Params) string {
+	name := paramName(params)
+	params[name] = path
+	return "JSON_VALUE(" + q.db.QuoteColumnName(col) + ", {:" + name + "})"
+}
+
+// BuildJsonContains generates a condition checking whether the JSON document at the path of the given column contains value.
+// SQL Server doesn't have a JSON containment function, so the value items are checked with OPENJSON().
+func (q *MssqlQueryBuilder) BuildJsonContains(col, path string, value interface{}, params Params) string {
+	col = q.db.QuoteColumnName(col)
+
+	return jsonContainsCondition(value, path, params,
+		func(path, param string) string {
+			name := paramName(params)
+			params[name] = path
+			return "EXISTS (SELECT 1 FROM OPENJSON(" + col + ", {:" + name + "}) AS je WHERE je.[value]=" + param + ")"
+		},
+		func(path string) string {
+			name := paramName(params)
+			params[name] = path
+			return "JSON_VALUE(" + col + ", {:" + name + "})"
+		},
+	)
+}
+
+// BuildJsonArrayLength generates an expression returning the length of the JSON array at the path of the given column.
+func (q *MssqlQueryBuilder) BuildJsonArrayLength(col, path string, params Params) string {
+	name := paramName(params)
+	params[name] = path
+	return "(SELECT COUNT(*) FROM OPENJSON(" + q.db.QuoteColumnName(col) + ", {:" + name + "}))"
+}
+
+// CreateFullTextIndex creates a Query that can be used to create a full-text search index for a table.
+// SQL Server full-text indexes require a full-text catalog and a unique key index,
+// so they should be created with a raw query.
+func (b *MssqlBuilder) CreateFullTextIndex(table, name string, cols ...string) *Query {
+	q := b.NewQuery("")
+	q.LastError = errors.New("SQL Server full-text indexes must be created with a raw query")
+	return q
+}
+
+// DropFullTextIndex creates a Query that can be used to remove the full-text search index from a table.
+// SQL Server allows only one full-text index per table, so the name is ignored.
+func (b *MssqlBuilder) DropFullTextIndex(table, name string) *Query {
+	return b.NewQuery("DROP FULLTEXT INDEX ON " + b.db.QuoteTableName(table))
+}
+
+// BuildMatch generates a full-text search condition matching the plain text query against the given columns.
+func (q *MssqlQueryBuilder) BuildMatch(cols []string, query, index string, params Params) string {
+	name := paramName(params)
+	params[name] = query
+	return "FREETEXT((" + q.quoteColumns(cols) + "), {:" + name + "})"
+}
+
+// BuildMatchRelevance generates an expression calculating the full-text search relevance (higher is more relevant).
+// The rank is available only in FREETEXTTABLE() results, so the relevance is always the same.
+func (q *MssqlQueryBuilder) BuildMatchRelevance(cols []string, query, index string, params Params) string {
+	return "(SELECT 0)"
+}
+
+// BuildReturning adds a clause returning the given columns of the affected rows to the given statement.
+// SQL Server uses an OUTPUT clause which is placed in the middle of the INSERT, UPDATE, DELETE and MERGE statements.
+func (q *MssqlQueryBuilder) BuildReturning(sql string, outputPos int, cols []string) (string, error) {
+	if outputPos < 0 || outputPos > len(sql) {
+		return "", errors.New("Returning is supported only by the INSERT, UPDATE, DELETE and MERGE statements created by the builder")
+	}
+
+	prefix := "inserted."
+	if strings.HasPrefix(sql, "DELETE") {
+		prefix = "deleted."
+	}
+
+	output := make([]string, len(cols))
+	for i, col := range cols {
+		output[i] = prefix + q.db.QuoteSimpleColumnName(col)
+	}
+
+	return sql[:outputPos] + " OUTPUT " + strings.Join(output, ", ") + sql[outputPos:], nil
+}
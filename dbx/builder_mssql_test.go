@@ -65,6 +65,114 @@ func TestMssqlQueryBuilder_BuildOrderByAndLimit(t *testing.T) {
 	assert.Equal(t, sql, expected, "t4")
 }
 
+func TestMssqlBuilder_Savepoint(t *testing.T) {
+	b := getMssqlBuilder()
+	assert.Equal(t, b.Savepoint("sp1").SQL(), `SAVE TRANSACTION [sp1]`, "t1")
+	assert.Equal(t, b.RollbackToSavepoint("sp1").SQL(), `ROLLBACK TRANSACTION [sp1]`, "t2")
+	assert.Nil(t, b.ReleaseSavepoint("sp1"), "t3")
+}
+
+func TestMssqlQueryBuilder_BuildWith(t *testing.T) {
+	b := getMssqlBuilder()
+	ctes := []CTEInfo{{"tree", true, b.Select("id").From("nodes")}}
+	sql := b.QueryBuilder().BuildWith(ctes, Params{})
+	assert.Equal(t, "WITH [tree] AS (SELECT [id] FROM [nodes]\nORDER BY (SELECT NULL)\nOFFSET 0 ROWS)", sql, "t1")
+}
+
+func TestMssqlQueryBuilder_BuildLock(t *testing.T) {
+	b := getMssqlBuilder()
+
+	q := b.Select("id").From("jobs").Where(HashExp{"status": 0}).ForUpdate().SkipLocked().Build()
+	assert.Equal(t, "SELECT [id] FROM [jobs] WITH (UPDLOCK, ROWLOCK, READPAST) WHERE [status]={:p0}\nORDER BY (SELECT NULL)\nOFFSET 0 ROWS", q.SQL(), "t1")
+
+	q = b.Select("id").From("jobs").ForShare().NoWait().Build()
+	assert.Equal(t, "SELECT [id] FROM [jobs] WITH (HOLDLOCK, ROWLOCK, NOWAIT)\nORDER BY (SELECT NULL)\nOFFSET 0 ROWS", q.SQL(), "t2")
+
+	q = b.Select("id").From("jobs").SkipLocked().Build()
+	assert.Equal(t, "SELECT [id] FROM [jobs]\nORDER BY (SELECT NULL)\nOFFSET 0 ROWS", q.SQL(), "t3")
+}
+
+func TestMssqlQueryBuilder_BuildJson(t *testing.T) {
+	b := getMssqlBuilder()
+
+	q := b.Select("id").From("params").Where(JsonExtract("value", "$.meta.appName").Compare("=", "ohmygo")).Build()
+	assert.Equal(t, "SELECT [id] FROM [params] WHERE JSON_VALUE([value], {:p0})={:p1}\nORDER BY (SELECT NULL)\nOFFSET 0 ROWS", q.SQL(), "t1")
+	assert.Equal(t, Params{"p0": "$.meta.appName", "p1": "ohmygo"}, q.Params(), "t2")
+
+	q = b.Select("id").From("users").Where(JsonContains("roles", "admin")).Build()
+	assert.Equal(t, "SELECT [id] FROM [users] WHERE EXISTS (SELECT 1 FROM OPENJSON([roles], {:p1}) AS je WHERE je.[value]={:p0})\nORDER BY (SELECT NULL)\nOFFSET 0 ROWS", q.SQL(), "t3")
+	assert.Equal(t, Params{"p0": "admin", "p1": "$"}, q.Params(), "t4")
+
+	q = b.Select("id").From("params").Where(JsonArrayLength("value", "$.tags").Compare(">", 2)).Build()
+	assert.Equal(t, "SELECT [id] FROM [params] WHERE (SELECT COUNT(*) FROM OPENJSON([value], {:p0}))>{:p1}\nORDER BY (SELECT NULL)\nOFFSET 0 ROWS", q.SQL(), "t5")
+}
+
+func TestMssqlBuilder_FullText(t *testing.T) {
+	b := getMssqlBuilder()
+
+	q := b.CreateFullTextIndex("users", "users_fts", "email")
+	assert.NotNil(t, q.LastError, "t1")
+
+	q = b.DropFullTextIndex("users", "users_fts")
+	assert.Equal(t, "DROP FULLTEXT INDEX ON [users]", q.SQL(), "t2")
+
+	q = b.Select("id").From("users").Where(Match([]string{"email", "name"}, "john")).Build()
+	assert.Equal(t, "SELECT [id] FROM [users] WHERE FREETEXT(([email], [name]), {:p0})\nORDER BY (SELECT NULL)\nOFFSET 0 ROWS", q.SQL(), "t3")
+}
+
+func TestMssqlBuilder_Upsert(t *testing.T) {
+	b := getMssqlBuilder()
+	q := b.Upsert("users", Params{
+		"id":   1,
+		"name": "James",
+		"age":  NewExp("{:age}+1", Params{"age": 30}),
+	}, "id")
+	assert.Nil(t, q.LastError, "t1")
+	assert.Equal(t, q.SQL(), "MERGE INTO [users] WITH (HOLDLOCK) AS t USING (VALUES ({:age}+1, {:p1}, {:p2})) AS s ([age], [id], [name]) ON t.[id]=s.[id] WHEN MATCHED THEN UPDATE SET t.[age]=s.[age], t.[name]=s.[name] WHEN NOT MATCHED THEN INSERT ([age], [id], [name]) VALUES (s.[age], s.[id], s.[name]);", "t2")
+	assert.Equal(t, q.Params(), Params{"age": 30, "p1": 1, "p2": "James"}, "t3")
+
+	q = b.UpsertReturning("users", Params{"id": 1}, "id")
+	assert.Equal(t, q.SQL(), "MERGE INTO [users] WITH (HOLDLOCK) AS t USING (VALUES ({:p0})) AS s ([id]) ON t.[id]=s.[id] WHEN NOT MATCHED THEN INSERT ([id]) VALUES (s.[id]) OUTPUT inserted.*;", "t4")
+
+	// expression params named as the generated placeholders
+	q = b.Upsert("users", Params{
+		"a":  1,
+		"b":  NewExp("{:p0}+{:p1}", Params{"p0": 10, "p1": 20}),
+		"id": 2,
+	}, "id")
+	assert.Equal(t, q.SQL(), "MERGE INTO [users] WITH (HOLDLOCK) AS t USING (VALUES ({:p0}, {:p0_1}+{:p1}, {:p3})) AS s ([a], [b], [id]) ON t.[id]=s.[id] WHEN MATCHED THEN UPDATE SET t.[a]=s.[a], t.[b]=s.[b] WHEN NOT MATCHED THEN INSERT ([a], [b], [id]) VALUES (s.[a], s.[b], s.[id]);", "t4.1")
+	assert.Equal(t, q.Params(), Params{"p0": 1, "p0_1": 10, "p1": 20, "p3": 2}, "t4.2")
+
+	q = b.Upsert("users", Params{"name": "James"})
+	assert.NotNil(t, q.LastError, "t5")
+
+	q = b.Upsert("users", Params{"name": "James"}, "id")
+	assert.NotNil(t, q.LastError, "t6")
+}
+
+func TestMssqlQueryBuilder_BuildReturning(t *testing.T) {
+	b := getMssqlBuilder()
+
+	q := b.Insert("users", Params{"name": "James"}).Returning("id", "created")
+	assert.Nil(t, q.LastError, "t1")
+	assert.Equal(t, "INSERT INTO [users] ([name]) OUTPUT inserted.[id], inserted.[created] VALUES ({:p0})", q.SQL(), "t2")
+
+	q = b.Insert("users", Params{}).Returning()
+	assert.Equal(t, "INSERT INTO [users] OUTPUT inserted.* DEFAULT VALUES", q.SQL(), "t3")
+
+	q = b.Update("users", Params{"name": "James"}, HashExp{"id": 1}).Returning("name")
+	assert.Equal(t, "UPDATE [users] SET [name]={:p0} OUTPUT inserted.[name] WHERE [id]={:p1}", q.SQL(), "t4")
+
+	q = b.Delete("users", HashExp{"id": 1}).Returning("id")
+	assert.Equal(t, "DELETE FROM [users] OUTPUT deleted.[id] WHERE [id]={:p0}", q.SQL(), "t5")
+
+	q = b.Upsert("users", Params{"id": 1}, "id").Returning("id")
+	assert.Equal(t, "MERGE INTO [users] WITH (HOLDLOCK) AS t USING (VALUES ({:p0})) AS s ([id]) ON t.[id]=s.[id] WHEN NOT MATCHED THEN INSERT ([id]) VALUES (s.[id]) OUTPUT inserted.[id];", q.SQL(), "t6")
+
+	q = b.NewQuery("SELECT 1").Returning("id")
+	assert.NotNil(t, q.LastError, "t7")
+}
+
 func getMssqlBuilder() Builder {
 	db := getDB()
 	b := NewMssqlBuilder(db, db.sqlDB)
@@ -61,6 +61,68 @@ func TestMysqlBuilder_DropForeignKey(t *testing.T) {
 	assert.Equal(t, q.SQL(), "ALTER TABLE `users` DROP FOREIGN KEY `fk`", "t1")
 }
 
+func TestMysqlQueryBuilder_BuildLock(t *testing.T) {
+	b := getMysqlBuilder()
+
+	q := b.Select("id").From("jobs").Limit(1).ForUpdate().SkipLocked().Build()
+	assert.Equal(t, "SELECT `id` FROM `jobs` LIMIT 1 FOR UPDATE SKIP LOCKED", q.SQL(), "t1")
+
+	q = b.Select("id").From("jobs").ForShare().NoWait().Build()
+	assert.Equal(t, "SELECT `id` FROM `jobs` FOR SHARE NOWAIT", q.SQL(), "t2")
+
+	q = b.Select("id").From("jobs").NoWait().Build()
+	assert.Equal(t, "SELECT `id` FROM `jobs`", q.SQL(), "t3")
+}
+
+func TestMysqlQueryBuilder_BuildJson(t *testing.T) {
+	b := getMysqlBuilder()
+
+	q := b.Select("id").From("params").Where(JsonExtract("value", "meta.appName").Compare("=", "ohmygo")).Build()
+	assert.Equal(t, "SELECT `id` FROM `params` WHERE JSON_UNQUOTE(JSON_EXTRACT(`value`, {:p0}))={:p1}", q.SQL(), "t1")
+	assert.Equal(t, Params{"p0": "$.meta.appName", "p1": "ohmygo"}, q.Params(), "t2")
+
+	q = b.Select("id").From("users").Where(JsonContains("roles", []string{"admin"})).Build()
+	assert.Equal(t, "SELECT `id` FROM `users` WHERE JSON_CONTAINS(`roles`, {:p0})", q.SQL(), "t3")
+	assert.Equal(t, Params{"p0": `["admin"]`}, q.Params(), "t4")
+
+	q = b.Select("id").From("params").Where(JsonContains("value", "a", "$.tags")).Build()
+	assert.Equal(t, "SELECT `id` FROM `params` WHERE JSON_CONTAINS(`value`, {:p0}, {:p1})", q.SQL(), "t5")
+	assert.Equal(t, Params{"p0": `"a"`, "p1": "$.tags"}, q.Params(), "t6")
+
+	q = b.Select("id").From("params").Where(JsonArrayLength("value", "$.tags").Compare(">", 2)).Build()
+	assert.Equal(t, "SELECT `id` FROM `params` WHERE JSON_LENGTH(`value`, {:p0})>{:p1}", q.SQL(), "t7")
+	assert.Equal(t, Params{"p0": "$.tags", "p1": 2}, q.Params(), "t8")
+}
+
+func TestMysqlBuilder_FullText(t *testing.T) {
+	b := getMysqlBuilder()
+
+	q := b.CreateFullTextIndex("users", "users_fts", "email", "name")
+	assert.Equal(t, "CREATE FULLTEXT INDEX `users_fts` ON `users` (`email`, `name`)", q.SQL(), "t1")
+
+	q = b.DropFullTextIndex("users", "users_fts")
+	assert.Equal(t, "DROP INDEX `users_fts` ON `users`", q.SQL(), "t2")
+
+	match := Match([]string{"email", "name"}, "john doe")
+	q = b.Select("id").From("users").Where(match).OrderByRelevance(match).OrderBy("id").Build()
+	assert.Equal(t, "SELECT `id` FROM `users` WHERE MATCH(`email`, `name`) AGAINST({:p0} IN NATURAL LANGUAGE MODE) ORDER BY MATCH(`email`, `name`) AGAINST({:p1} IN NATURAL LANGUAGE MODE) DESC, `id`", q.SQL(), "t3")
+	assert.Equal(t, Params{"p0": "john doe", "p1": "john doe"}, q.Params(), "t4")
+}
+
+func TestMysqlBuilder_UpsertReturning(t *testing.T) {
+	b := getMysqlBuilder()
+	q := b.UpsertReturning("users", Params{"name": "James"}, "id")
+	assert.NotNil(t, q.LastError, "t1")
+}
+
+func TestMysqlQueryBuilder_BuildReturning(t *testing.T) {
+	b := getMysqlBuilder()
+
+	q := b.Insert("users", Params{"name": "James"}).Returning("id")
+	assert.NotNil(t, q.LastError, "t1")
+	assert.Equal(t, "INSERT INTO `users` (`name`) VALUES ({:p0})", q.SQL(), "t2")
+}
+
 func getMysqlBuilder() Builder {
 	db := getDB()
 	b := NewMysqlBuilder(db, db.sqlDB)
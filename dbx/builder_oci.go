@@ -5,7 +5,9 @@
 package dbx
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 )
 
 // OciBuilder is the builder for Oracle databases.
@@ -58,6 +60,27 @@ func (b *OciBuilder) DropIndex(table, name string) *Query {
 	return b.NewQuery(sql)
 }
 
+// CreateFullTextIndex creates a Query that can be used to create a full-text search index for a table.
+// The index is an Oracle Text CONTEXT index, which supports only a single column.
+func (b *OciBuilder) CreateFullTextIndex(table, name string, cols ...string) *Query {
+	if len(cols) != 1 {
+		q := b.NewQuery("")
+		q.LastError = errors.New("Oracle full-text indexes must have exactly one column")
+		return q
+	}
+
+	sql := fmt.Sprintf("CREATE INDEX %v ON %v (%v) INDEXTYPE IS CTXSYS.CONTEXT",
+		b.db.QuoteColumnName(name),
+		b.db.QuoteTableName(table),
+		b.db.QuoteColumnName(cols[0]))
+	return b.NewQuery(sql)
+}
+
+// DropFullTextIndex creates a Query that can be used to remove the named full-text search index from a table.
+func (b *OciBuilder) DropFullTextIndex(table, name string) *Query {
+	return b.DropIndex(table, name)
+}
+
 // RenameTable creates a Query that can be used to rename a table.
 func (b *OciBuilder) RenameTable(oldName, newName string) *Query {
 	sql := fmt.Sprintf("ALTER TABLE %v RENAME TO %v", b.db.QuoteTableName(oldName), b.db.QuoteTableName(newName))
@@ -71,6 +94,12 @@ func (b *OciBuilder) AlterColumn(table, col, typ string) *Query {
 	return b.NewQuery(sql)
 }
 
+// ReleaseSavepoint returns nil since Oracle doesn't support releasing savepoints
+// (they are discarded together with the transaction).
+func (b *OciBuilder) ReleaseSavepoint(name string) *Query {
+	return nil
+}
+
 // BuildOrderByAndLimit generates the ORDER BY and LIMIT clauses.
 func (q *OciQueryBuilder) BuildOrderByAndLimit(sql string, cols []string, limit int64, offset int64) string {
 	if orderBy := q.BuildOrderBy(cols); orderBy != "" {
@@ -96,3 +125,75 @@ func (q *OciQueryBuilder) BuildOrderByAndLimit(sql string, cols []string, limit
 	PAGINATION AS (SELECT USER_SQL.*, rownum as rowNumId FROM USER_SQL)
 SELECT * FROM PAGINATION WHERE ` + c
 }
+
+// BuildWith generates a WITH clause from the given common table expressions.
+// Oracle doesn't use the RECURSIVE keyword for recursive common table expressions.
+func (q *OciQueryBuilder) BuildWith(ctes []CTEInfo, params Params) string {
+	return q.buildWith(ctes, params, "WITH")
+}
+
+// BuildLock applies the row locking options to the given FROM clause and
+// returns it together with the locking clause to be appended to the statement.
+// Oracle doesn't support shared row locks, so ForShare() is ignored.
+func (q *OciQueryBuilder) BuildLock(from string, lock LockInfo) (string, string) {
+	if lock.Mode != LockForUpdate {
+		return from, ""
+	}
+	return q.BaseQueryBuilder.BuildLock(from, lock)
+}
+
+// BuildKeyset generates the keyset pagination predicate selecting the rows after the given column values.
+// Oracle doesn't support row value comparison, so the predicate is expanded to "(a > ?) OR (a = ? AND b > ?)".
+func (q *OciQueryBuilder) BuildKeyset(cols []KeysetColumn, values []interface{}, params Params) string {
+	return q.buildExpandedKeyset(cols, values, params)
+}
+
+// BuildJsonExtract generates an expression extracting the scalar value at the JSON path of the given column.
+// Oracle requires the JSON path to be a string literal, so it is not bound as a parameter.
+func (q *OciQueryBuilder) BuildJsonExtract(col, path string, params Params) string {
+	return "JSON_VALUE(" + q.db.QuoteColumnName(col) + ", " + q.db.Builder.Quote(path) + ")"
+}
+
+// BuildJsonContains generates a condition checking whether the JSON document at the path of the given column contains value.
+// The value items are checked with JSON_EXISTS() filter expressions.
+func (q *OciQueryBuilder) BuildJsonContains(col, path string, value interface{}, params Params) string {
+	col = q.db.QuoteColumnName(col)
+
+	return jsonContainsCondition(value, path, params,
+		func(path, param string) string {
+			return "JSON_EXISTS(" + col + ", " + q.db.Builder.Quote(path+"?(@ == $v)") + " PASSING " + param + ` AS "v")`
+		},
+		func(path string) string {
+			return "JSON_VALUE(" + col + ", " + q.db.Builder.Quote(path) + ")"
+		},
+	)
+}
+
+// BuildJsonArrayLength generates an expression returning the length of the JSON array at the path of the given column.
+func (q *OciQueryBuilder) BuildJsonArrayLength(col, path string, params Params) string {
+	return "JSON_VALUE(" + q.db.QuoteColumnName(col) + ", " + q.db.Builder.Quote(path+".size()") + ")"
+}
+
+// BuildMatch generates a full-text search condition matching the plain text query against the given columns.
+// Each column must have its own full-text index.
+func (q *OciQueryBuilder) BuildMatch(cols []string, query, index string, params Params) string {
+	return "(" + q.buildContains(cols, query, params) + ")>0"
+}
+
+// BuildMatchRelevance generates an expression calculating the full-text search relevance (higher is more relevant).
+// The relevance is the sum of the CONTAINS() scores of the columns.
+func (q *OciQueryBuilder) BuildMatchRelevance(cols []string, query, index string, params Params) string {
+	return "(" + q.buildContains(cols, query, params) + ")"
+}
+
+func (q *OciQueryBuilder) buildContains(cols []string, query string, params Params) string {
+	name := paramName(params)
+	params[name] = query
+
+	parts := make([]string, len(cols))
+	for i, col := range cols {
+		parts[i] = "CONTAINS(" + q.db.QuoteColumnName(col) + ", {:" + name + "})"
+	}
+
+	return strings.Join(parts, " + ")
+}
@@ -48,6 +48,75 @@ func TestOciQueryBuilder_BuildOrderByAndLimit(t *testing.T) {
 	assert.Equal(t, sql, expected, "t4")
 }
 
+func TestOciQueryBuilder_WithAndLimit(t *testing.T) {
+	b := getOciBuilder()
+
+	active := b.Select("id").From("users").Where(HashExp{"status": 1})
+	q := b.Select("id").WithQuery("active", active).From("active").Limit(10).Build()
+
+	expected := "WITH \"active\" AS (SELECT \"id\" FROM \"users\" WHERE \"status\"={:p0}),\n\tUSER_SQL AS (SELECT \"id\" FROM \"active\"),\n\tPAGINATION AS (SELECT USER_SQL.*, rownum as rowNumId FROM USER_SQL)\nSELECT * FROM PAGINATION WHERE rowNum <= 10"
+	assert.Equal(t, expected, q.SQL(), "t1")
+	assert.Equal(t, Params{"p0": 1}, q.Params(), "t2")
+
+	// without pagination
+	q = b.Select("id").WithQuery("active", active).From("active").Build()
+	expected = "WITH \"active\" AS (SELECT \"id\" FROM \"users\" WHERE \"status\"={:p0}) SELECT \"id\" FROM \"active\""
+	assert.Equal(t, expected, q.SQL(), "t3")
+}
+
+func TestOciBuilder_Savepoint(t *testing.T) {
+	b := getOciBuilder()
+	assert.Equal(t, b.Savepoint("sp1").SQL(), `SAVEPOINT "sp1"`, "t1")
+	assert.Equal(t, b.RollbackToSavepoint("sp1").SQL(), `ROLLBACK TO SAVEPOINT "sp1"`, "t2")
+	assert.Nil(t, b.ReleaseSavepoint("sp1"), "t3")
+}
+
+func TestOciQueryBuilder_BuildLock(t *testing.T) {
+	b := getOciBuilder()
+
+	q := b.Select("id").From("jobs").ForUpdate().SkipLocked().Build()
+	assert.Equal(t, `SELECT "id" FROM "jobs" FOR UPDATE SKIP LOCKED`, q.SQL(), "t1")
+
+	q = b.Select("id").From("jobs").ForUpdate().NoWait().Build()
+	assert.Equal(t, `SELECT "id" FROM "jobs" FOR UPDATE NOWAIT`, q.SQL(), "t2")
+
+	// shared locks are not supported
+	q = b.Select("id").From("jobs").ForShare().Build()
+	assert.Equal(t, `SELECT "id" FROM "jobs"`, q.SQL(), "t3")
+	assert.Nil(t, q.LastError, "t4")
+
+	// locking is not allowed with the ROWNUM pagination
+	q = b.Select("id").From("jobs").Limit(10).ForUpdate().Build()
+	assert.NotNil(t, q.LastError, "t5")
+}
+
+func TestOciQueryBuilder_BuildJson(t *testing.T) {
+	b := getOciBuilder()
+
+	q := b.Select("id").From("params").Where(JsonExtract("value", "$.meta.appName").Compare("=", "ohmygo")).Build()
+	assert.Equal(t, `SELECT "id" FROM "params" WHERE JSON_VALUE("value", '$.meta.appName')={:p0}`, q.SQL(), "t1")
+
+	q = b.Select("id").From("users").Where(JsonContains("roles", "admin")).Build()
+	assert.Equal(t, `SELECT "id" FROM "users" WHERE JSON_EXISTS("roles", '$?(@ == $v)' PASSING {:p0} AS "v")`, q.SQL(), "t2")
+
+	q = b.Select("id").From("params").Where(JsonArrayLength("value", "$.tags").Compare(">", 2)).Build()
+	assert.Equal(t, `SELECT "id" FROM "params" WHERE JSON_VALUE("value", '$.tags.size()')>{:p0}`, q.SQL(), "t3")
+}
+
+func TestOciBuilder_FullText(t *testing.T) {
+	b := getOciBuilder()
+
+	q := b.CreateFullTextIndex("users", "users_fts", "email")
+	assert.Equal(t, `CREATE INDEX "users_fts" ON "users" ("email") INDEXTYPE IS CTXSYS.CONTEXT`, q.SQL(), "t1")
+
+	q = b.CreateFullTextIndex("users", "users_fts", "email", "name")
+	assert.NotNil(t, q.LastError, "t2")
+
+	match := Match([]string{"email", "name"}, "john")
+	q = b.Select("id").From("users").Where(match).OrderByRelevance(match).Build()
+	assert.Equal(t, "SELECT \"id\" FROM \"users\" WHERE (CONTAINS(\"email\", {:p0}) + CONTAINS(\"name\", {:p0}))>0\nORDER BY (CONTAINS(\"email\", {:p1}) + CONTAINS(\"name\", {:p1})) DESC", q.SQL(), "t3")
+}
+
 func getOciBuilder() Builder {
 	db := getDB()
 	b := NewOciBuilder(db, db.sqlDB)
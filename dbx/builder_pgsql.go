@@ -13,16 +13,21 @@ import (
 // PgsqlBuilder is the builder for PostgreSQL databases.
 type PgsqlBuilder struct {
 	*BaseBuilder
-	qb *BaseQueryBuilder
+	qb *PgsqlQueryBuilder
 }
 
 var _ Builder = &PgsqlBuilder{}
 
+// PgsqlQueryBuilder is the query builder for PostgreSQL databases.
+type PgsqlQueryBuilder struct {
+	*BaseQueryBuilder
+}
+
 // NewPgsqlBuilder creates a new PgsqlBuilder instance.
 func NewPgsqlBuilder(db *DB, executor Executor) Builder {
 	return &PgsqlBuilder{
 		NewBaseBuilder(db, executor),
-		NewBaseQueryBuilder(db),
+		&PgsqlQueryBuilder{NewBaseQueryBuilder(db)},
 	}
 }
 
@@ -85,12 +90,37 @@ func (b *PgsqlBuilder) Upsert(table string, cols Params, constraints ...string)
 	return b.NewQuery(q.sql).Bind(q.params)
 }
 
+// UpsertReturning creates a Query that represents an UPSERT SQL statement returning the inserted or updated row.
+// The returned row could be populated with the Query.One() method.
+func (b *PgsqlBuilder) UpsertReturning(table string, cols Params, constraints ...string) *Query {
+	q := b.Upsert(table, cols, constraints...)
+	if q.LastError != nil {
+		return q
+	}
+	return b.NewQuery(q.sql + " RETURNING *").Bind(q.params)
+}
+
 // DropIndex creates a Query that can be used to remove the named index from a table.
 func (b *PgsqlBuilder) DropIndex(table, name string) *Query {
 	sql := fmt.Sprintf("DROP INDEX %v", b.db.QuoteColumnName(name))
 	return b.NewQuery(sql)
 }
 
+// CreateFullTextIndex creates a Query that can be used to create a full-text search index for a table.
+// The index is a GIN index of the to_tsvector() expression of the columns (see PgsqlTextSearchConfig).
+func (b *PgsqlBuilder) CreateFullTextIndex(table, name string, cols ...string) *Query {
+	sql := fmt.Sprintf("CREATE INDEX %v ON %v USING GIN (%v)",
+		b.db.QuoteColumnName(name),
+		b.db.QuoteTableName(table),
+		pgsqlTsvector(b.db, cols))
+	return b.NewQuery(sql)
+}
+
+// DropFullTextIndex creates a Query that can be used to remove the named full-text search index from a table.
+func (b *PgsqlBuilder) DropFullTextIndex(table, name string) *Query {
+	return b.DropIndex(table, name)
+}
+
 // RenameTable creates a Query that can be used to rename a table.
 func (b *PgsqlBuilder) RenameTable(oldName, newName string) *Query {
 	sql := fmt.Sprintf("ALTER TABLE %v RENAME TO %v", b.db.QuoteTableName(oldName), b.db.QuoteTableName(newName))
@@ -103,3 +133,69 @@ func (b *PgsqlBuilder) AlterColumn(table, col, typ string) *Query {
 	sql := fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v TYPE %v", b.db.QuoteTableName(table), col, typ)
 	return b.NewQuery(sql)
 }
+
+// BuildJsonExtract generates an expression extracting the scalar value at the JSON path of the given column.
+// The value is extracted as text with the "->>" operator (or "#>>" for nested paths).
+func (q *PgsqlQueryBuilder) BuildJsonExtract(col, path string, params Params) string {
+	segments := splitJsonPath(path)
+
+	name := paramName(params)
+
+	if len(segments) == 1 && !strings.HasPrefix(path, "$[") {
+		params[name] = segments[0]
+		return q.db.QuoteColumnName(col) + "::jsonb->>{:" + name + "}"
+	}
+
+	params[name] = pgsqlTextArray(segments)
+
+	return q.db.QuoteColumnName(col) + "::jsonb#>>{:" + name + "}"
+}
+
+// BuildJsonContains generates a condition checking whether the JSON document at the path of the given column contains value.
+func (q *PgsqlQueryBuilder) BuildJsonContains(col, path string, value interface{}, params Params) string {
+	target := q.pgsqlJsonPath(col, path, params)
+
+	name := paramName(params)
+	params[name] = jsonEncode(value)
+
+	return target + " @> {:" + name + "}::jsonb"
+}
+
+// BuildJsonArrayLength generates an expression returning the length of the JSON array at the path of the given column.
+func (q *PgsqlQueryBuilder) BuildJsonArrayLength(col, path string, params Params) string {
+	return "jsonb_array_length(" + q.pgsqlJsonPath(col, path, params) + ")"
+}
+
+// pgsqlJsonPath returns the jsonb expression of the JSON document at the path of the given column.
+func (q *PgsqlQueryBuilder) pgsqlJsonPath(col, path string, params Params) string {
+	segments := splitJsonPath(path)
+	if len(segments) == 0 {
+		return q.db.QuoteColumnName(col) + "::jsonb"
+	}
+
+	name := paramName(params)
+	params[name] = pgsqlTextArray(segments)
+
+	return "(" + q.db.QuoteColumnName(col) + "::jsonb#>{:" + name + "})"
+}
+
+// BuildMatch generates a full-text search condition matching the plain text query against the given columns.
+func (q *PgsqlQueryBuilder) BuildMatch(cols []string, query, index string, params Params) string {
+	return pgsqlTsvector(q.db, cols) + " @@ " + q.buildTsquery(query, params)
+}
+
+// BuildMatchRelevance generates an expression calculating the full-text search relevance (higher is more relevant).
+func (q *PgsqlQueryBuilder) BuildMatchRelevance(cols []string, query, index string, params Params) string {
+	return "ts_rank(" + pgsqlTsvector(q.db, cols) + ", " + q.buildTsquery(query, params) + ")"
+}
+
+func (q *PgsqlQueryBuilder) buildTsquery(query string, params Params) string {
+	name := paramName(params)
+	params[name] = query
+	return "plainto_tsquery(" + q.db.Builder.Quote(PgsqlTextSearchConfig) + ", {:" + name + "})"
+}
+
+// BuildReturning adds a clause returning the given columns of the affected rows to the given statement.
+func (q *PgsqlQueryBuilder) BuildReturning(sql string, outputPos int, cols []string) (string, error) {
+	return q.buildAppendedReturning(sql, cols), nil
+}
@@ -41,6 +41,79 @@ func TestPgsqlBuilder_AlterColumn(t *testing.T) {
 	assert.Equal(t, q.SQL(), `ALTER TABLE "users" ALTER COLUMN "name" TYPE int`, "t1")
 }
 
+func TestPgsqlQueryBuilder_BuildLock(t *testing.T) {
+	b := getPgsqlBuilder()
+
+	q := b.Select("id").From("jobs").Where(HashExp{"status": 0}).OrderBy("id").Limit(10).ForUpdate().SkipLocked().Build()
+	assert.Equal(t, `SELECT "id" FROM "jobs" WHERE "status"={:p0} ORDER BY "id" LIMIT 10 FOR UPDATE SKIP LOCKED`, q.SQL(), "t1")
+
+	q = b.Select("id").From("jobs").ForShare().NoWait().Build()
+	assert.Equal(t, `SELECT "id" FROM "jobs" FOR SHARE NOWAIT`, q.SQL(), "t2")
+
+	q = b.Select("id").From("jobs").ForUpdate().Build()
+	assert.Equal(t, `SELECT "id" FROM "jobs" FOR UPDATE`, q.SQL(), "t3")
+	assert.Nil(t, q.LastError, "t4")
+
+	// locking is not allowed with union
+	q = b.Select("id").From("jobs").Union(b.Select("id").From("archived_jobs").Build()).ForUpdate().Build()
+	assert.NotNil(t, q.LastError, "t5")
+}
+
+func TestPgsqlQueryBuilder_BuildJson(t *testing.T) {
+	b := getPgsqlBuilder()
+
+	q := b.Select("id").From("params").Where(JsonExtract("value", "appName").Compare("=", "ohmygo")).Build()
+	assert.Equal(t, `SELECT "id" FROM "params" WHERE "value"::jsonb->>{:p0}={:p1}`, q.SQL(), "t1")
+	assert.Equal(t, Params{"p0": "appName", "p1": "ohmygo"}, q.Params(), "t2")
+
+	q = b.Select("id").From("params").Where(JsonExtract("value", `$.meta."app.name"[0]`)).Build()
+	assert.Equal(t, `SELECT "id" FROM "params" WHERE "value"::jsonb#>>{:p0}`, q.SQL(), "t3")
+	assert.Equal(t, Params{"p0": `{"meta","app.name","0"}`}, q.Params(), "t4")
+
+	q = b.Select("id").From("users").Where(JsonContains("roles", []string{"admin"})).Build()
+	assert.Equal(t, `SELECT "id" FROM "users" WHERE "roles"::jsonb @> {:p0}::jsonb`, q.SQL(), "t5")
+	assert.Equal(t, Params{"p0": `["admin"]`}, q.Params(), "t6")
+
+	q = b.Select("id").From("params").Where(JsonContains("value", map[string]interface{}{"enabled": true}, "$.smtp")).Build()
+	assert.Equal(t, `SELECT "id" FROM "params" WHERE ("value"::jsonb#>{:p0}) @> {:p1}::jsonb`, q.SQL(), "t7")
+	assert.Equal(t, Params{"p0": `{"smtp"}`, "p1": `{"enabled":true}`}, q.Params(), "t8")
+
+	q = b.Select("id").From("params").Where(JsonArrayLength("value", "$.tags").Compare(">", 2)).Build()
+	assert.Equal(t, `SELECT "id" FROM "params" WHERE jsonb_array_length(("value"::jsonb#>{:p0}))>{:p1}`, q.SQL(), "t9")
+	assert.Equal(t, Params{"p0": `{"tags"}`, "p1": 2}, q.Params(), "t10")
+}
+
+func TestPgsqlBuilder_FullText(t *testing.T) {
+	b := getPgsqlBuilder()
+
+	q := b.CreateFullTextIndex("users", "users_fts", "email", "name")
+	assert.Equal(t, `CREATE INDEX "users_fts" ON "users" USING GIN (to_tsvector('simple', coalesce("email", '') || ' ' || coalesce("name", '')))`, q.SQL(), "t1")
+
+	q = b.DropFullTextIndex("users", "users_fts")
+	assert.Equal(t, `DROP INDEX "users_fts"`, q.SQL(), "t2")
+
+	match := Match([]string{"email"}, "john")
+	q = b.Select("id").From("users").Where(match).OrderByRelevance(match).Build()
+	assert.Equal(t, `SELECT "id" FROM "users" WHERE to_tsvector('simple', coalesce("email", '')) @@ plainto_tsquery('simple', {:p0}) ORDER BY ts_rank(to_tsvector('simple', coalesce("email", '')), plainto_tsquery('simple', {:p1})) DESC`, q.SQL(), "t3")
+	assert.Equal(t, Params{"p0": "john", "p1": "john"}, q.Params(), "t4")
+}
+
+func TestPgsqlBuilder_UpsertReturning(t *testing.T) {
+	b := getPgsqlBuilder()
+	q := b.UpsertReturning("users", Params{"name": "James"}, "id")
+	assert.Equal(t, q.SQL(), `INSERT INTO "users" ("name") VALUES ({:p0}) ON CONFLICT ("id") DO UPDATE SET "name"={:p1} RETURNING *`, "t1")
+	assert.Equal(t, q.Params(), Params{"p0": "James", "p1": "James"}, "t2")
+}
+
+func TestPgsqlQueryBuilder_BuildReturning(t *testing.T) {
+	b := getPgsqlBuilder()
+
+	q := b.Update("users", Params{"name": "James"}, HashExp{"id": "abc"}).Returning("updated")
+	assert.Nil(t, q.LastError, "t1")
+	assert.Equal(t, `UPDATE "users" SET "name"={:p0} WHERE "id"={:p1} RETURNING "updated"`, q.SQL(), "t2")
+	assert.Equal(t, `UPDATE "users" SET "name"=$1 WHERE "id"=$2 RETURNING "updated"`, q.rawSQL, "t3")
+}
+
 func getPgsqlBuilder() Builder {
 	db := getDB()
 	b := NewPgsqlBuilder(db, db.sqlDB)
@@ -7,22 +7,28 @@ package dbx
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 )
 
 // SqliteBuilder is the builder for SQLite databases.
 type SqliteBuilder struct {
 	*BaseBuilder
-	qb *BaseQueryBuilder
+	qb *SqliteQueryBuilder
 }
 
 var _ Builder = &SqliteBuilder{}
 
+// SqliteQueryBuilder is the query builder for SQLite databases.
+type SqliteQueryBuilder struct {
+	*BaseQueryBuilder
+}
+
 // NewSqliteBuilder creates a new SqliteBuilder instance.
 func NewSqliteBuilder(db *DB, executor Executor) Builder {
 	return &SqliteBuilder{
 		NewBaseBuilder(db, executor),
-		NewBaseQueryBuilder(db),
+		&SqliteQueryBuilder{NewBaseQueryBuilder(db)},
 	}
 }
 
@@ -62,12 +68,119 @@ func (b *SqliteBuilder) QuoteSimpleColumnName(s string) string {
 	return "`" + s + "`"
 }
 
+// Upsert creates a Query that represents an UPSERT SQL statement.
+// Upsert inserts a row into the table if the primary key or unique index is not found.
+// Otherwise it will update the row with the new values.
+// The keys of cols are the column names, while the values of cols are the corresponding column
+// values to be inserted.
+//
+// The conflicting row is updated with the inserted values (except for the constraint columns).
+// The conflict target could be omitted only with SQLite 3.35 or newer.
+func (b *SqliteBuilder) Upsert(table string, cols Params, constraints ...string) *Query {
+	isConstraint := make(map[string]bool, len(constraints))
+	for _, c := range constraints {
+		isConstraint[c] = true
+	}
+
+	names := []string{}
+	for name := range cols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := Params{}
+	values := buildInsertValues(b.db, names, cols, params)
+	columns := make([]string, 0, len(names))
+	updates := []string{}
+	for _, name := range names {
+		col := b.db.QuoteColumnName(name)
+		columns = append(columns, col)
+		if !isConstraint[name] {
+			updates = append(updates, col+"=excluded."+col)
+		}
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v) ON CONFLICT",
+		b.db.QuoteTableName(table),
+		strings.Join(columns, ", "),
+		strings.Join(values, ", "),
+	)
+	if len(constraints) > 0 {
+		sql += " (" + b.quoteColumns(constraints) + ")"
+	}
+	if len(updates) > 0 {
+		sql += " DO UPDATE SET " + strings.Join(updates, ", ")
+	} else {
+		sql += " DO NOTHING"
+	}
+
+	return b.NewQuery(sql).Bind(params)
+}
+
+// UpsertReturning creates a Query that represents an UPSERT SQL statement returning the inserted or updated row.
+// The returned row could be populated with the Query.One() method.
+//
+// RETURNING is supported only by SQLite 3.35 or newer.
+func (b *SqliteBuilder) UpsertReturning(table string, cols Params, constraints ...string) *Query {
+	q := b.Upsert(table, cols, constraints...)
+	return b.NewQuery(q.sql + " RETURNING *").Bind(q.params)
+}
+
 // DropIndex creates a Query that can be used to remove the named index from a table.
 func (b *SqliteBuilder) DropIndex(table, name string) *Query {
 	sql := fmt.Sprintf("DROP INDEX %v", b.db.QuoteColumnName(name))
 	return b.NewQuery(sql)
 }
 
+// CreateFullTextIndex creates a Query that can be used to create a full-text search index for a table.
+//
+// SQLite full-text search is implemented with an external content FTS5 table named
+// after the index, which is kept in sync with the table rows using triggers.
+func (b *SqliteBuilder) CreateFullTextIndex(table, name string, cols ...string) *Query {
+	fts := b.db.QuoteTableName(name)
+	content := b.Quote(table)
+	table = b.db.QuoteTableName(table)
+
+	quoted := make([]string, len(cols))
+	newCols := make([]string, len(cols))
+	oldCols := make([]string, len(cols))
+	for i, col := range cols {
+		quoted[i] = b.db.QuoteColumnName(col)
+		newCols[i] = "new." + quoted[i]
+		oldCols[i] = "old." + quoted[i]
+	}
+	columns := strings.Join(quoted, ", ")
+
+	insertNew := fmt.Sprintf("INSERT INTO %v(rowid, %v) VALUES (new.rowid, %v);", fts, columns, strings.Join(newCols, ", "))
+	deleteOld := fmt.Sprintf("INSERT INTO %v(%v, rowid, %v) VALUES ('delete', old.rowid, %v);", fts, fts, columns, strings.Join(oldCols, ", "))
+
+	return b.newStatementsQuery(
+		fmt.Sprintf("CREATE VIRTUAL TABLE %v USING fts5(%v, content=%v)", fts, columns, content),
+		fmt.Sprintf("CREATE TRIGGER %v AFTER INSERT ON %v BEGIN %v END", b.db.QuoteTableName(name+"_ai"), table, insertNew),
+		fmt.Sprintf("CREATE TRIGGER %v AFTER DELETE ON %v BEGIN %v END", b.db.QuoteTableName(name+"_ad"), table, deleteOld),
+		fmt.Sprintf("CREATE TRIGGER %v AFTER UPDATE ON %v BEGIN %v %v END", b.db.QuoteTableName(name+"_au"), table, deleteOld, insertNew),
+		fmt.Sprintf("INSERT INTO %v(%v) VALUES ('rebuild')", fts, fts),
+	)
+}
+
+// DropFullTextIndex creates a Query that can be used to remove the named full-text search index from a table.
+func (b *SqliteBuilder) DropFullTextIndex(table, name string) *Query {
+	return b.newStatementsQuery(
+		"DROP TRIGGER IF EXISTS "+b.db.QuoteTableName(name+"_ai"),
+		"DROP TRIGGER IF EXISTS "+b.db.QuoteTableName(name+"_ad"),
+		"DROP TRIGGER IF EXISTS "+b.db.QuoteTableName(name+"_au"),
+		"DROP TABLE "+b.db.QuoteTableName(name),
+	)
+}
+
+// newStatementsQuery creates a Query that executes the provided statements one by one
+// in a transaction (see Query.Execute).
+func (b *SqliteBuilder) newStatementsQuery(statements ...string) *Query {
+	q := b.NewQuery(strings.Join(statements, ";\n"))
+	q.statements = statements
+	return q
+}
+
 // TruncateTable creates a Query that can be used to truncate a table.
 func (b *SqliteBuilder) TruncateTable(table string) *Query {
 	sql := "DELETE FROM " + b.db.QuoteTableName(table)
@@ -126,3 +239,83 @@ func (b *SqliteBuilder) DropForeignKey(table, name string) *Query {
 	q.LastError = errors.New("SQLite does not support dropping foreign keys")
 	return q
 }
+
+// BuildLock returns the FROM clause as it is since SQLite doesn't support
+// row locking (the whole database is locked by the writing transaction).
+func (q *SqliteQueryBuilder) BuildLock(from string, lock LockInfo) (string, string) {
+	return from, ""
+}
+
+// BuildJsonExtract generates an expression extracting the scalar value at the JSON path of the given column.
+func (q *SqliteQueryBuilder) BuildJsonExtract(col, path string, params Params) string {
+	name := paramName(params)
+	params[name] = path
+	return "json_extract(" + q.db.QuoteColumnName(col) + ", {:" + name + "})"
+}
+
+// BuildJsonContains generates a condition checking whether the JSON document at the path of the given column contains value.
+// SQLite doesn't have a JSON containment function, so the value items are checked with json_each().
+//
+// The column is selected in a separate subquery since otherwise a column with the
+// same name as the json_each() columns (eg. "value") would be resolved to the json_each() one.
+func (q *SqliteQueryBuilder) BuildJsonContains(col, path string, value interface{}, params Params) string {
+	col = q.db.QuoteColumnName(col)
+
+	return jsonContainsCondition(value, path, params,
+		func(path, param string) string {
+			name := paramName(params)
+			params[name] = path
+			return "EXISTS (SELECT 1 FROM (SELECT " + col + " AS doc) jd, json_each(jd.doc, {:" + name + "}) je WHERE je.value=" + param + ")"
+		},
+		func(path string) string {
+			name := paramName(params)
+			params[name] = path
+			return "json_extract(" + col + ", {:" + name + "})"
+		},
+	)
+}
+
+// BuildJsonArrayLength generates an expression returning the length of the JSON array at the path of the given column.
+func (q *SqliteQueryBuilder) BuildJsonArrayLength(col, path string, params Params) string {
+	if path == "$" {
+		return "json_array_length(" + q.db.QuoteColumnName(col) + ")"
+	}
+
+	name := paramName(params)
+	params[name] = path
+
+	return "json_array_length(" + q.db.QuoteColumnName(col) + ", {:" + name + "})"
+}
+
+// BuildMatch generates a full-text search condition matching the plain text query against the given columns.
+// The index is the name of the FTS5 table created with SqliteBuilder.CreateFullTextIndex().
+func (q *SqliteQueryBuilder) BuildMatch(cols []string, query, index string, params Params) string {
+	name := paramName(params)
+	params[name] = sqliteFtsQuery(cols, query)
+
+	fts := q.db.QuoteTableName(index)
+
+	return fmt.Sprintf("%v IN (SELECT rowid FROM %v WHERE %v MATCH {:%v})", sqliteFtsRowid(q.db, cols), fts, fts, name)
+}
+
+// BuildMatchRelevance generates an expression calculating the full-text search relevance (higher is more relevant).
+//
+// The relevance is the negated bm25() rank of the FTS5 table row. The row id is selected
+// in a separate subquery, so that it is resolved to the searched table row id.
+func (q *SqliteQueryBuilder) BuildMatchRelevance(cols []string, query, index string, params Params) string {
+	name := paramName(params)
+	params[name] = sqliteFtsQuery(cols, query)
+
+	fts := q.db.QuoteTableName(index)
+
+	return fmt.Sprintf(
+		"(SELECT -bm25(%v) FROM (SELECT %v AS rid) jr, %v WHERE %v MATCH {:%v} AND %v.rowid=jr.rid)",
+		fts, sqliteFtsRowid(q.db, cols), fts, fts, name, fts,
+	)
+}
+
+// BuildReturning adds a clause returning the given columns of the affected rows to the given statement.
+// RETURNING is supported only by SQLite 3.35 or newer.
+func (q *SqliteQueryBuilder) BuildReturning(sql string, outputPos int, cols []string) (string, error) {
+	return q.buildAppendedReturning(sql, cols), nil
+}
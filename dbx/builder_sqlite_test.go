@@ -81,6 +81,103 @@ func TestSqliteBuilder_DropForeignKey(t *testing.T) {
 	assert.NotEqual(t, q.LastError, nil, "t1")
 }
 
+func TestSqliteQueryBuilder_BuildLock(t *testing.T) {
+	b := getSqliteBuilder()
+
+	q := b.Select("id").From("jobs").ForUpdate().SkipLocked().Build()
+	assert.Equal(t, "SELECT `id` FROM `jobs`", q.SQL(), "t1")
+
+	q = b.Select("id").From("jobs").ForShare().Build()
+	assert.Equal(t, "SELECT `id` FROM `jobs`", q.SQL(), "t2")
+}
+
+func TestSqliteQueryBuilder_BuildJson(t *testing.T) {
+	b := getSqliteBuilder()
+
+	q := b.Select("id").From("params").Where(JsonExtract("value", "$.meta.appName").Compare("=", "ohmygo")).Build()
+	assert.Equal(t, "SELECT `id` FROM `params` WHERE json_extract(`value`, {:p0})={:p1}", q.SQL(), "t1")
+	assert.Equal(t, Params{"p0": "$.meta.appName", "p1": "ohmygo"}, q.Params(), "t2")
+
+	q = b.Select("id").From("users").Where(JsonContains("roles", []string{"admin", "editor"})).Build()
+	assert.Equal(t, "SELECT `id` FROM `users` WHERE (EXISTS (SELECT 1 FROM (SELECT `roles` AS doc) jd, json_each(jd.doc, {:p1}) je WHERE je.value={:p0})) AND (EXISTS (SELECT 1 FROM (SELECT `roles` AS doc) jd, json_each(jd.doc, {:p3}) je WHERE je.value={:p2}))", q.SQL(), "t3")
+	assert.Equal(t, Params{"p0": "admin", "p1": "$", "p2": "editor", "p3": "$"}, q.Params(), "t4")
+
+	q = b.Select("id").From("params").Where(JsonContains("value", map[string]interface{}{"enabled": true}, "smtp")).Build()
+	assert.Equal(t, "SELECT `id` FROM `params` WHERE json_extract(`value`, {:p0})={:p1}", q.SQL(), "t5")
+	assert.Equal(t, Params{"p0": "$.smtp.enabled", "p1": true}, q.Params(), "t6")
+
+	q = b.Select("id").From("params").Where(JsonArrayLength("value").Compare(">", 2)).Build()
+	assert.Equal(t, "SELECT `id` FROM `params` WHERE json_array_length(`value`)>{:p0}", q.SQL(), "t7")
+	assert.Equal(t, Params{"p0": 2}, q.Params(), "t8")
+}
+
+func TestSqliteBuilder_FullText(t *testing.T) {
+	b := getSqliteBuilder()
+
+	q := b.CreateFullTextIndex("users", "users_fts", "email", "name")
+	expected := "CREATE VIRTUAL TABLE `users_fts` USING fts5(`email`, `name`, content='users');\n" +
+		"CREATE TRIGGER `users_fts_ai` AFTER INSERT ON `users` BEGIN INSERT INTO `users_fts`(rowid, `email`, `name`) VALUES (new.rowid, new.`email`, new.`name`); END;\n" +
+		"CREATE TRIGGER `users_fts_ad` AFTER DELETE ON `users` BEGIN INSERT INTO `users_fts`(`users_fts`, rowid, `email`, `name`) VALUES ('delete', old.rowid, old.`email`, old.`name`); END;\n" +
+		"CREATE TRIGGER `users_fts_au` AFTER UPDATE ON `users` BEGIN INSERT INTO `users_fts`(`users_fts`, rowid, `email`, `name`) VALUES ('delete', old.rowid, old.`email`, old.`name`); INSERT INTO `users_fts`(rowid, `email`, `name`) VALUES (new.rowid, new.`email`, new.`name`); END;\n" +
+		"INSERT INTO `users_fts`(`users_fts`) VALUES ('rebuild')"
+	assert.Equal(t, expected, q.SQL(), "t1")
+	// the statements are executed one by one
+	assert.Len(t, q.statements, 5, "t1.1")
+
+	q = b.DropFullTextIndex("users", "users_fts")
+	expected = "DROP TRIGGER IF EXISTS `users_fts_ai`;\n" +
+		"DROP TRIGGER IF EXISTS `users_fts_ad`;\n" +
+		"DROP TRIGGER IF EXISTS `users_fts_au`;\n" +
+		"DROP TABLE `users_fts`"
+	assert.Equal(t, expected, q.SQL(), "t2")
+	assert.Len(t, q.statements, 4, "t2.1")
+
+	match := Match([]string{"u.email", "u.name"}, `john "doe`).Index("users_fts")
+	q = b.Select("id").From("users u").Where(match).OrderByRelevance(match).Build()
+	assert.Equal(t, "SELECT `id` FROM `users` `u` WHERE `u`.rowid IN (SELECT rowid FROM `users_fts` WHERE `users_fts` MATCH {:p0}) ORDER BY (SELECT -bm25(`users_fts`) FROM (SELECT `u`.rowid AS rid) jr, `users_fts` WHERE `users_fts` MATCH {:p1} AND `users_fts`.rowid=jr.rid) DESC", q.SQL(), "t3")
+	assert.Equal(t, Params{"p0": `{"email" "name"} : ("john" """doe")`, "p1": `{"email" "name"} : ("john" """doe")`}, q.Params(), "t4")
+}
+
+func TestSqliteBuilder_Upsert(t *testing.T) {
+	b := getSqliteBuilder()
+	q := b.Upsert("users", Params{
+		"id":   1,
+		"name": "James",
+		"age":  30,
+	}, "id")
+	assert.Equal(t, "INSERT INTO `users` (`age`, `id`, `name`) VALUES ({:p0}, {:p1}, {:p2}) ON CONFLICT (`id`) DO UPDATE SET `age`=excluded.`age`, `name`=excluded.`name`", q.SQL(), "t1")
+	assert.Equal(t, Params{"p0": 30, "p1": 1, "p2": "James"}, q.Params(), "t2")
+
+	q = b.Upsert("users", Params{"name": "James"})
+	assert.Equal(t, "INSERT INTO `users` (`name`) VALUES ({:p0}) ON CONFLICT DO UPDATE SET `name`=excluded.`name`", q.SQL(), "t3")
+
+	q = b.UpsertReturning("users", Params{"name": "James"}, "id")
+	assert.Equal(t, "INSERT INTO `users` (`name`) VALUES ({:p0}) ON CONFLICT (`id`) DO UPDATE SET `name`=excluded.`name` RETURNING *", q.SQL(), "t4")
+
+	// the expression params don't collide with the generated ones
+	q = b.Upsert("users", Params{
+		"a": 1,
+		"b": NewExp("{:p0}+1", Params{"p0": 10}),
+	}, "a")
+	assert.Equal(t, "INSERT INTO `users` (`a`, `b`) VALUES ({:p0}, {:p0_1}+1) ON CONFLICT (`a`) DO UPDATE SET `b`=excluded.`b`", q.SQL(), "t5")
+	assert.Equal(t, Params{"p0": 1, "p0_1": 10}, q.Params(), "t6")
+
+	// only constraint columns
+	q = b.Upsert("users", Params{"id": 1}, "id")
+	assert.Equal(t, "INSERT INTO `users` (`id`) VALUES ({:p0}) ON CONFLICT (`id`) DO NOTHING", q.SQL(), "t7")
+}
+
+func TestSqliteQueryBuilder_BuildReturning(t *testing.T) {
+	b := getSqliteBuilder()
+
+	q := b.Insert("users", Params{"name": "James"}).Returning("id", "created")
+	assert.Nil(t, q.LastError, "t1")
+	assert.Equal(t, "INSERT INTO `users` (`name`) VALUES ({:p0}) RETURNING `id`, `created`", q.SQL(), "t2")
+
+	q = b.Delete("users", HashExp{"id": 1}).Returning()
+	assert.Equal(t, "DELETE FROM `users` WHERE `id`={:p0} RETURNING *", q.SQL(), "t3")
+}
+
 func getSqliteBuilder() Builder {
 	db := getDB()
 	b := NewSqliteBuilder(db, db.sqlDB)
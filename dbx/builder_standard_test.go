@@ -175,6 +175,23 @@ func TestStandardBuilder_DropIndex(t *testing.T) {
 	assert.Equal(t, q.SQL(), `DROP INDEX "idx" ON "users"`, "t1")
 }
 
+func TestStandardBuilder_Savepoint(t *testing.T) {
+	b := getStandardBuilder()
+	assert.Equal(t, b.Savepoint("sp1").SQL(), `SAVEPOINT "sp1"`, "t1")
+	assert.Equal(t, b.RollbackToSavepoint("sp1").SQL(), `ROLLBACK TO SAVEPOINT "sp1"`, "t2")
+	assert.Equal(t, b.ReleaseSavepoint("sp1").SQL(), `RELEASE SAVEPOINT "sp1"`, "t3")
+}
+
+func TestStandardQueryBuilder_BuildLock(t *testing.T) {
+	b := getStandardBuilder()
+
+	q := b.Select("id").From("jobs").ForUpdate().NoWait().Build()
+	assert.Equal(t, `SELECT "id" FROM "jobs" FOR UPDATE NOWAIT`, q.SQL(), "t1")
+
+	q = b.Select("id").From("jobs").Build()
+	assert.Equal(t, `SELECT "id" FROM "jobs"`, q.SQL(), "t2")
+}
+
 func getStandardBuilder() Builder {
 	db := getDB()
 	b := NewStandardBuilder(db, db.sqlDB)
@@ -0,0 +1,257 @@
+package dbx
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/har4s/ohmygo/tools/store"
+)
+
+// DefaultQueryCacheMaxEntries is the default max number of entries of the DB query cache.
+const DefaultQueryCacheMaxEntries = 1000
+
+// QueryCache defines a cache for the SelectQuery results
+// with support for tag based invalidation.
+//
+// The cached values are the populated query result variables (eg. a struct or a slice),
+// so the implementations that don't keep the values in memory must be able to serialize them.
+type QueryCache interface {
+	// Get returns the cached value associated with key (if any and not expired).
+	Get(key string) (interface{}, bool)
+	// Set caches value for the specified ttl duration and associates it with the provided tags.
+	Set(key string, value interface{}, ttl time.Duration, tags ...string)
+	// InvalidateTags removes all cached values associated with any of the provided tags.
+	InvalidateTags(tags ...string)
+}
+
+// MemoryCache is an in-memory QueryCache built on store.Store.
+type MemoryCache struct {
+	mux        sync.Mutex
+	entries    *store.Store[*memoryCacheEntry]
+	tags       map[string]map[string]struct{} // tag -> cache keys
+	maxEntries int
+}
+
+type memoryCacheEntry struct {
+	value   interface{}
+	expires time.Time
+	tags    []string
+}
+
+var _ QueryCache = (*MemoryCache)(nil)
+
+// NewMemoryCache creates a new MemoryCache instance that holds up to maxEntries values.
+// The cache is reset when the limit is reached.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		entries:    store.New[*memoryCacheEntry](nil),
+		tags:       map[string]map[string]struct{}{},
+		maxEntries: maxEntries,
+	}
+}
+
+// Get returns the cached value associated with key (if any and not expired).
+func (c *MemoryCache) Get(key string) (interface{}, bool) {
+	entry := c.entries.Get(key)
+	if entry == nil {
+		return nil, false
+	}
+
+	if time.Now().After(entry.expires) {
+		c.mux.Lock()
+		// the entry could have been replaced in the meantime
+		if c.entries.Get(key) == entry {
+			c.remove(key, entry)
+		}
+		c.mux.Unlock()
+
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+// Set caches value for the specified ttl duration and associates it with the provided tags.
+func (c *MemoryCache) Set(key string, value interface{}, ttl time.Duration, tags ...string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	// clear the tags of the replaced entry
+	if old := c.entries.Get(key); old != nil {
+		c.remove(key, old)
+	}
+
+	entry := &memoryCacheEntry{value: value, expires: time.Now().Add(ttl), tags: tags}
+
+	if !c.entries.SetIfLessThanLimit(key, entry, c.maxEntries) {
+		c.entries.RemoveAll()
+		c.tags = map[string]map[string]struct{}{}
+		c.entries.Set(key, entry)
+	}
+
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = map[string]struct{}{}
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+}
+
+// InvalidateTags removes all cached values associated with any of the provided tags.
+func (c *MemoryCache) InvalidateTags(tags ...string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if entry := c.entries.Get(key); entry != nil {
+				c.remove(key, entry)
+			}
+		}
+		delete(c.tags, tag)
+	}
+}
+
+// remove removes the cache entry and its key from the entry tags.
+//
+// It must be called while holding the cache lock.
+func (c *MemoryCache) remove(key string, entry *memoryCacheEntry) {
+	c.entries.Remove(key)
+
+	for _, tag := range entry.tags {
+		delete(c.tags[tag], key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+// Cache enables caching of the query result for the specified ttl duration.
+//
+// The cached results are invalidated together with the specified tags
+// (see QueryCache.InvalidateTags()). If no tags are provided, the names
+// of the selected from and joined tables are used as tags.
+//
+// Only the One(), All() and Column() results are cached and the cache
+// is not used for queries executed in a transaction or if DB.QueryCache is nil.
+//
+// Note that the cached values are shallow copied, so the nested
+// pointers, slices and maps must not be modified.
+func (s *SelectQuery) Cache(ttl time.Duration, tags ...string) *SelectQuery {
+	s.cacheTTL = ttl
+	s.cacheTags = tags
+	return s
+}
+
+// queryCache returns the query cache to use (if any).
+func (s *SelectQuery) queryCache() QueryCache {
+	if s.cacheTTL <= 0 || s.db == nil || s.db.QueryCache == nil {
+		return nil
+	}
+
+	// a transaction may read its own uncommitted changes
+	if b, ok := s.builder.(interface{ Executor() Executor }); ok {
+		if _, isTx := b.Executor().(*sql.Tx); isTx {
+			return nil
+		}
+	}
+
+	return s.db.QueryCache
+}
+
+// withCache executes the built query with fn and caches the populated a value.
+// If a cached value exists, it is copied into a without executing the query.
+func (s *SelectQuery) withCache(method string, a interface{}, fn func(q *Query) error) error {
+	q := s.Build().WithContext(s.ctx)
+
+	cache := s.queryCache()
+	if cache == nil {
+		return fn(q)
+	}
+
+	v := reflect.ValueOf(a)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fn(q)
+	}
+
+	key := fmt.Sprintf("%s:%v:%s:%v", method, v.Type(), q.SQL(), q.Params())
+
+	if cached, ok := cache.Get(key); ok {
+		if cv := reflect.ValueOf(cached); cv.Type() == v.Elem().Type() {
+			v.Elem().Set(copyCacheValue(cv))
+			return nil
+		}
+	}
+
+	if err := fn(q); err != nil {
+		return err
+	}
+
+	tags := s.cacheTags
+	if len(tags) == 0 {
+		tags = s.tableTags()
+	}
+
+	cache.Set(key, copyCacheValue(v.Elem()).Interface(), s.cacheTTL, tags...)
+
+	return nil
+}
+
+// tableTags returns the names of the from and joined tables.
+func (s *SelectQuery) tableTags() []string {
+	// the subquery aliases are skipped as they are not tables
+	from := append([]string{}, s.from...)
+	for _, sub := range s.fromQueries {
+		from[sub.pos] = ""
+	}
+	join := make([]string, len(s.join))
+	for i, j := range s.join {
+		join[i] = j.Table
+	}
+	for _, sub := range s.joinQueries {
+		join[sub.pos] = ""
+	}
+	tables := append(from, join...)
+
+	tags := make([]string, 0, len(tables))
+	for _, table := range tables {
+		// strip the alias and the quotes, eg. "{{users}} u" -> "users"
+		name := strings.Fields(table)
+		if len(name) == 0 || strings.HasPrefix(name[0], "(") {
+			continue
+		}
+		tags = append(tags, strings.Trim(name[0], "{}`\"[]"))
+	}
+
+	return tags
+}
+
+// copyCacheValue returns a shallow copy of v which doesn't share
+// the slice and map elements with the original value.
+func copyCacheValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return c
+	}
+
+	return v
+}
@@ -0,0 +1,134 @@
+package dbx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache(t *testing.T) {
+	c := NewMemoryCache(3)
+
+	_, ok := c.Get("missing")
+	assert.False(t, ok, "t1")
+
+	c.Set("a", 1, time.Minute, "users")
+	c.Set("b", 2, time.Minute, "users", "posts")
+	c.Set("c", 3, time.Minute, "posts")
+	c.Set("expired", 4, -time.Second)
+
+	// the cache is reset when the limit is reached
+	_, ok = c.Get("a")
+	assert.False(t, ok, "t2")
+	_, ok = c.Get("expired")
+	assert.False(t, ok, "t3")
+
+	c.Set("a", 1, time.Minute, "users")
+	c.Set("b", 2, time.Minute, "users", "posts")
+	c.Set("c", 3, time.Minute, "posts")
+
+	v, ok := c.Get("b")
+	assert.True(t, ok, "t4")
+	assert.Equal(t, 2, v, "t5")
+
+	c.InvalidateTags("users")
+	_, ok = c.Get("a")
+	assert.False(t, ok, "t6")
+	_, ok = c.Get("b")
+	assert.False(t, ok, "t7")
+	_, ok = c.Get("c")
+	assert.True(t, ok, "t8")
+	assert.Equal(t, map[string]map[string]struct{}{"posts": {"c": {}}}, c.tags, "t9")
+}
+
+func TestMemoryCache_tagsCleanup(t *testing.T) {
+	c := NewMemoryCache(10)
+
+	// the same key cached multiple times is tracked once
+	for i := 0; i < 5; i++ {
+		c.Set("a", i, time.Minute, "users", "users")
+	}
+	assert.Equal(t, map[string]map[string]struct{}{"users": {"a": {}}}, c.tags, "t1")
+
+	// the tags of the replaced entry are cleared
+	c.Set("a", 1, time.Minute, "posts")
+	assert.Equal(t, map[string]map[string]struct{}{"posts": {"a": {}}}, c.tags, "t2")
+
+	// the tags of the expired entry are cleared
+	c.Set("b", 2, -time.Second, "users")
+	_, ok := c.Get("b")
+	assert.False(t, ok, "t3")
+	assert.Equal(t, map[string]map[string]struct{}{"posts": {"a": {}}}, c.tags, "t4")
+
+	// the other tags of the invalidated entry are cleared
+	c.Set("c", 3, time.Minute, "users", "comments")
+	c.InvalidateTags("users")
+	assert.Equal(t, map[string]map[string]struct{}{"posts": {"a": {}}}, c.tags, "t5")
+}
+
+func TestSelectQuery_tableTags(t *testing.T) {
+	db := getDB()
+
+	q := db.Select().
+		From("users u", "{{posts}}").
+		FromSelect(db.Select().From("comments"), "c").
+		InnerJoin("`tags` t", nil).
+		JoinSelect("LEFT JOIN", db.Select().From("likes"), "l", nil)
+	assert.Equal(t, []string{"users", "posts", "tags"}, q.tableTags())
+}
+
+func TestSelectQuery_Cache(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	type customer struct {
+		ID    int
+		Email string
+	}
+
+	var c1 customer
+	err := db.Select().From("customer").Where(HashExp{"id": 1}).Cache(time.Minute).One(&c1)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "user1@example.com", c1.Email)
+	}
+
+	// change the row without invalidating the cache
+	_, err = db.Update("customer", Params{"email": "changed@example.com"}, HashExp{"id": 1}).Execute()
+	assert.Nil(t, err)
+
+	var c2 customer
+	err = db.Select().From("customer").Where(HashExp{"id": 1}).Cache(time.Minute).One(&c2)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "user1@example.com", c2.Email)
+	}
+
+	// not cached
+	var c3 customer
+	err = db.Select().From("customer").Where(HashExp{"id": 1}).One(&c3)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "changed@example.com", c3.Email)
+	}
+
+	var emails []string
+	err = db.Select("email").From("customer").OrderBy("id").Cache(time.Minute).Column(&emails)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "changed@example.com", emails[0])
+	}
+	emails[0] = "modified"
+
+	// the cached slice is not shared
+	var emails2 []string
+	err = db.Select("email").From("customer").OrderBy("id").Cache(time.Minute).Column(&emails2)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "changed@example.com", emails2[0])
+	}
+
+	db.QueryCache.InvalidateTags("customer")
+
+	var c4 customer
+	err = db.Select().From("customer").Where(HashExp{"id": 1}).Cache(time.Minute).One(&c4)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "changed@example.com", c4.Email)
+	}
+}
@@ -59,6 +59,21 @@ type (
 		QueryLogFunc QueryLogFunc
 		// ExecLogFunc is called each time when a SQL statement is executed.
 		ExecLogFunc ExecLogFunc
+		// SlowQueryThreshold is the min duration of a SQL statement to be considered slow
+		// and reported to SlowQueryLogFunc. Defaults to 0, meaning no slow query detection.
+		SlowQueryThreshold time.Duration
+		// SlowQueryLogFunc is called each time when a SQL statement takes longer than SlowQueryThreshold.
+		// Defaults to nil, meaning DefaultSlowQueryLogFunc.
+		SlowQueryLogFunc SlowQueryLogFunc
+		// Metrics collects the executed statements counters and latencies.
+		// Defaults to nil, meaning no metrics collection.
+		Metrics *QueryMetrics
+		// StmtCache caches the prepared statements of the executed queries.
+		// Defaults to nil, meaning no statements caching.
+		StmtCache *StmtCache
+		// QueryCache caches the results of the SelectQuery calls with enabled Cache().
+		// Defaults to an in-memory cache. Set it to nil to disable the caching.
+		QueryCache QueryCache
 
 		sqlDB      *sql.DB
 		driverName string
@@ -88,6 +103,7 @@ func NewFromDB(sqlDB *sql.DB, driverName string) *DB {
 		sqlDB:       sqlDB,
 		FieldMapper: DefaultFieldMapFunc,
 		TableMapper: GetTableName,
+		QueryCache:  NewMemoryCache(DefaultQueryCacheMaxEntries),
 	}
 	db.Builder = db.newBuilder(db.sqlDB)
 	return db
@@ -129,6 +145,12 @@ func (db *DB) Clone() *DB {
 		LogFunc:      db.LogFunc,
 		QueryLogFunc: db.QueryLogFunc,
 		ExecLogFunc:  db.ExecLogFunc,
+		QueryCache:   db.QueryCache,
+		StmtCache:    db.StmtCache,
+
+		SlowQueryThreshold: db.SlowQueryThreshold,
+		SlowQueryLogFunc:   db.SlowQueryLogFunc,
+		Metrics:            db.Metrics,
 	}
 	db2.Builder = db2.newBuilder(db.sqlDB)
 	return db2
@@ -156,6 +178,9 @@ func (db *DB) DB() *sql.DB {
 // It is rare to Close a DB, as the DB handle is meant to be
 // long-lived and shared between many goroutines.
 func (db *DB) Close() error {
+	if db.StmtCache != nil {
+		db.StmtCache.Clear()
+	}
 	return db.sqlDB.Close()
 }
 
@@ -171,7 +196,7 @@ func (db *DB) Begin() (*Tx, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Tx{db.newBuilder(tx), tx}, nil
+	return &Tx{Builder: db.newBuilder(tx), tx: tx}, nil
 }
 
 // BeginTx starts a transaction with the given context and transaction options.
@@ -180,12 +205,12 @@ func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Tx{db.newBuilder(tx), tx}, nil
+	return &Tx{Builder: db.newBuilder(tx), tx: tx}, nil
 }
 
 // Wrap encapsulates an existing transaction.
 func (db *DB) Wrap(sqlTx *sql.Tx) *Tx {
-	return &Tx{db.newBuilder(sqlTx), sqlTx}
+	return &Tx{Builder: db.newBuilder(sqlTx), tx: sqlTx}
 }
 
 // Transactional starts a transaction and executes the given function.
@@ -325,6 +325,37 @@ func TestDB_Transactional(t *testing.T) {
 	}
 }
 
+func TestTx_Transactional(t *testing.T) {
+	db := getPreparedDB()
+
+	var name string
+
+	err := db.Transactional(func(tx *Tx) error {
+		// rolled back to the savepoint
+		nestedErr := tx.Transactional(func(tx *Tx) error {
+			if _, err := tx.NewQuery("DELETE FROM item WHERE id=2").Execute(); err != nil {
+				return err
+			}
+			_, err := tx.NewQuery("DELETE FROM items WHERE id=2").Execute()
+			return err
+		})
+		assert.NotNil(t, nestedErr)
+
+		// released
+		return tx.Transactional(func(tx *Tx) error {
+			_, err := tx.Update("item", Params{"name": "nested"}, HashExp{"id": 3}).Execute()
+			return err
+		})
+	})
+
+	if assert.Nil(t, err) {
+		db.NewQuery("SELECT name FROM item WHERE id=2").Row(&name)
+		assert.Equal(t, "Go in Action", name)
+		db.NewQuery("SELECT name FROM item WHERE id=3").Row(&name)
+		assert.Equal(t, "nested", name)
+	}
+}
+
 func TestErrors_Error(t *testing.T) {
 	errs := Errors{}
 	assert.Equal(t, "", errs.Error())
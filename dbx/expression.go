@@ -52,6 +52,7 @@ func Or(exps ...Expression) Expression {
 
 // In generates an IN expression for the specified column and the list of allowed values.
 // If values is empty, a SQL "0=1" will be generated which represents a false expression.
+// A single *SelectQuery value generates an IN subquery expression, eg. "id" IN (SELECT ...).
 func In(col string, values ...interface{}) Expression {
 	return &InExp{col, values, false}
 }
@@ -200,8 +201,11 @@ func (e HashExp) Build(db *DB, params Params) string {
 			if sql := in.Build(db, params); sql != "" {
 				parts = append(parts, sql)
 			}
+		case *SelectQuery:
+			in := In(name, value)
+			parts = append(parts, in.Build(db, params))
 		default:
-			pn := fmt.Sprintf("p%v", len(params))
+			pn := paramName(params)
 			name = db.QuoteColumnName(name)
 			parts = append(parts, name+"={:"+pn+"}")
 			params[pn] = value
@@ -269,6 +273,20 @@ func (e *InExp) Build(db *DB, params Params) string {
 		return "0=1"
 	}
 
+	col := db.QuoteColumnName(e.col)
+
+	in := "IN"
+	if e.not {
+		in = "NOT IN"
+	}
+
+	// a single subquery could return multiple rows
+	if len(e.values) == 1 {
+		if sub, ok := e.values[0].(*SelectQuery); ok {
+			return fmt.Sprintf("%v %v (%v)", col, in, sub.buildSub(params))
+		}
+	}
+
 	var values []string
 	for _, value := range e.values {
 		switch value.(type) {
@@ -277,23 +295,20 @@ func (e *InExp) Build(db *DB, params Params) string {
 		case Expression:
 			sql := value.(Expression).Build(db, params)
 			values = append(values, sql)
+		case *SelectQuery:
+			values = append(values, "("+value.(*SelectQuery).buildSub(params)+")")
 		default:
-			name := fmt.Sprintf("p%v", len(params))
+			name := paramName(params)
 			params[name] = value
 			values = append(values, "{:"+name+"}")
 		}
 	}
-	col := db.QuoteColumnName(e.col)
 	if len(values) == 1 {
 		if e.not {
 			return col + "<>" + values[0]
 		}
 		return col + "=" + values[0]
 	}
-	in := "IN"
-	if e.not {
-		in = "NOT IN"
-	}
 	return fmt.Sprintf("%v %v (%v)", col, in, strings.Join(values, ", "))
 }
 
@@ -337,7 +352,7 @@ func (e *LikeExp) Build(db *DB, params Params) string {
 	var parts []string
 	col := db.QuoteColumnName(e.col)
 	for _, value := range e.values {
-		name := fmt.Sprintf("p%v", len(params))
+		name := paramName(params)
 		for i := 0; i < len(e.escape); i += 2 {
 			value = strings.Replace(value, e.escape[i], e.escape[i+1], -1)
 		}
@@ -391,10 +406,138 @@ func (e *BetweenExp) Build(db *DB, params Params) string {
 	if e.not {
 		between = "NOT BETWEEN"
 	}
-	name1 := fmt.Sprintf("p%v", len(params))
-	name2 := fmt.Sprintf("p%v", len(params)+1)
+	name1 := paramName(params)
 	params[name1] = e.from
+	name2 := paramName(params)
 	params[name2] = e.to
 	col := db.QuoteColumnName(e.col)
 	return fmt.Sprintf("%v %v {:%v} AND {:%v}", col, between, name1, name2)
 }
+
+// Compare generates a comparison expression between a column and a value.
+// The value could also be a *SelectQuery returning a single value. For example,
+// Compare("age", ">", 30) will generate: "age">{:p0} and
+// Compare("age", ">", db.Select("AVG(age)").From("users")) will generate: "age">(SELECT AVG(age) FROM "users").
+// A nil value generates IS NULL or IS NOT NULL for the "=" and "<>" operators, eg.
+// Compare("age", "=", nil) will generate: "age" IS NULL.
+func Compare(col, op string, value interface{}) Expression {
+	return &CompareExp{col, op, value}
+}
+
+// CompareExp represents a comparison expression between a column and a value or a subquery.
+type CompareExp struct {
+	col   string
+	op    string
+	value interface{}
+}
+
+// Build converts an expression into a SQL fragment.
+func (e *CompareExp) Build(db *DB, params Params) string {
+	return db.QuoteColumnName(e.col) + buildCompare(db, e.op, e.value, params)
+}
+
+// buildCompare builds the operator and the right operand of a comparison expression.
+//
+// A nil value is compared with IS NULL (for "=") or IS NOT NULL (for "<>" and "!=")
+// because any other comparison with NULL never matches.
+// The other operators (except IS and IS NOT) cannot be used with a nil value
+// and fail the built query with an error.
+func buildCompare(db *DB, op string, value interface{}, params Params) string {
+	if value == nil {
+		switch normalized := strings.ToUpper(strings.TrimSpace(op)); normalized {
+		case "=":
+			return " IS NULL"
+		case "<>", "!=":
+			return " IS NOT NULL"
+		case "IS", "IS NOT":
+			return " " + normalized + " NULL"
+		default:
+			panic(&expressionError{fmt.Errorf("the %q operator cannot be used to compare with NULL", op)})
+		}
+	}
+
+	return op + buildCompareValue(db, value, params)
+}
+
+// buildCompareValue builds the right operand of a comparison expression.
+func buildCompareValue(db *DB, value interface{}, params Params) string {
+	switch v := value.(type) {
+	case Expression:
+		return "(" + v.Build(db, params) + ")"
+	case *SelectQuery:
+		return "(" + v.buildSub(params) + ")"
+	default:
+		name := paramName(params)
+		params[name] = v
+		return "{:" + name + "}"
+	}
+}
+
+// expressionError is panicked by the expressions that cannot be built
+// and it is reported as the LastError of the built query.
+type expressionError struct {
+	err error
+}
+
+// recoverExpressionError converts a recovered expressionError panic value to an error.
+// The other panic values are panicked again.
+func recoverExpressionError(r interface{}) error {
+	if e, ok := r.(*expressionError); ok {
+		return e.err
+	}
+
+	panic(r)
+}
+
+// paramName returns the next generated parameter name that is not already used in params.
+func paramName(params Params) string {
+	for i := len(params); ; i++ {
+		name := fmt.Sprintf("p%v", i)
+		if _, ok := params[name]; !ok {
+			return name
+		}
+	}
+}
+
+// Over generates a window function expression, eg.:
+//
+//	Over("ROW_NUMBER()").PartitionBy("dept").OrderBy("salary DESC")
+//
+// will generate: ROW_NUMBER() OVER (PARTITION BY "dept" ORDER BY "salary" DESC).
+// The optional params are bound to the function SQL fragment.
+func Over(fn string, params ...Params) *WindowExp {
+	return &WindowExp{fn: NewExp(fn, params...)}
+}
+
+// WindowExp represents a window function call with an OVER clause.
+type WindowExp struct {
+	fn          Expression
+	partitionBy []string
+	orderBy     []string
+	frame       string
+}
+
+// PartitionBy specifies the PARTITION BY columns of the window.
+// Column names will be properly quoted.
+func (e *WindowExp) PartitionBy(cols ...string) *WindowExp {
+	e.partitionBy = cols
+	return e
+}
+
+// OrderBy specifies the ORDER BY columns of the window.
+// Column names will be properly quoted. A column name can contain "ASC" or "DESC" to indicate its ordering direction.
+func (e *WindowExp) OrderBy(cols ...string) *WindowExp {
+	e.orderBy = cols
+	return e
+}
+
+// Frame specifies the frame clause of the window, eg. "ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW".
+func (e *WindowExp) Frame(frame string) *WindowExp {
+	e.frame = frame
+	return e
+}
+
+// Build converts an expression into a SQL fragment.
+func (e *WindowExp) Build(db *DB, params Params) string {
+	return e.fn.Build(db, params) + " " + db.QueryBuilder().BuildOver(e.partitionBy, e.orderBy, e.frame)
+}
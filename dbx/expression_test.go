@@ -183,3 +183,93 @@ func TestExistsExp(t *testing.T) {
 	e4 := NotExists(NewExp(""))
 	assert.Equal(t, e4.Build(nil, nil), "", `e4.Build()`)
 }
+
+func TestWindowExp(t *testing.T) {
+	db := getDB()
+
+	params := Params{}
+	e := Over("ROW_NUMBER()").PartitionBy("dept", "team").OrderBy("salary DESC", "id")
+	assert.Equal(t, "ROW_NUMBER() OVER (PARTITION BY `dept`, `team` ORDER BY `salary` DESC, `id`)", e.Build(db, params), "t1")
+	assert.Equal(t, 0, len(params), "t2")
+
+	e = Over("NTILE({:buckets})", Params{"buckets": 4}).Frame("ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW")
+	assert.Equal(t, "NTILE({:buckets}) OVER (ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW)", e.Build(db, params), "t3")
+	assert.Equal(t, 4, params["buckets"], "t4")
+
+	e = Over("COUNT(*)")
+	assert.Equal(t, "COUNT(*) OVER ()", e.Build(db, params), "t5")
+}
+
+func TestCompareExp(t *testing.T) {
+	db := getDB()
+
+	params := Params{}
+	e := Compare("age", ">", 30)
+	assert.Equal(t, "`age`>{:p0}", e.Build(db, params), "t1")
+	assert.Equal(t, 30, params["p0"], "t2")
+
+	e = Compare("age", ">", db.Select("AVG(age)").From("users").Where(HashExp{"status": 1}))
+	assert.Equal(t, "`age`>(SELECT AVG(age) FROM `users` WHERE `status`={:p0_1})", e.Build(db, params), "t3")
+	assert.Equal(t, 1, params["p0_1"], "t4")
+
+	e = Compare("age", "=", nil)
+	assert.Equal(t, "`age` IS NULL", e.Build(db, params), "t5")
+
+	e = Compare("created", "<", NewExp("NOW()"))
+	assert.Equal(t, "`created`<(NOW())", e.Build(db, params), "t6")
+
+	e = Compare("age", "<>", nil)
+	assert.Equal(t, "`age` IS NOT NULL", e.Build(db, params), "t7")
+
+	e = Compare("age", " != ", nil)
+	assert.Equal(t, "`age` IS NOT NULL", e.Build(db, params), "t8")
+
+	e = Compare("age", " is not ", nil)
+	assert.Equal(t, "`age` IS NOT NULL", e.Build(db, params), "t9")
+}
+
+func TestCompareExp_nilWithInvalidOperator(t *testing.T) {
+	db := getDB()
+
+	q := db.Select("id").From("users").Where(Compare("age", ">", nil)).Build()
+	assert.EqualError(t, q.LastError, `the ">" operator cannot be used to compare with NULL`, "t1")
+
+	// the error of a subquery fails the outer query
+	sub := db.Select("user_id").From("orders").Where(Compare("total", "<", nil))
+	q = db.Select("id").From("users").Where(In("id", sub)).Build()
+	assert.EqualError(t, q.LastError, `the "<" operator cannot be used to compare with NULL`, "t2")
+
+	q = db.Update("users", Params{"name": "test"}, Compare("age", ">=", nil))
+	assert.EqualError(t, q.LastError, `the ">=" operator cannot be used to compare with NULL`, "t3")
+
+	q = db.Delete("users", Compare("age", "<=", nil))
+	assert.EqualError(t, q.LastError, `the "<=" operator cannot be used to compare with NULL`, "t4")
+
+	_, err := q.Execute()
+	assert.Error(t, err, "t5")
+}
+
+func TestInExp_subquery(t *testing.T) {
+	db := getDB()
+
+	sub := db.Select("user_id").From("orders").Where(HashExp{"total": 100})
+
+	params := Params{"p0": "a"}
+	e := In("id", sub)
+	assert.Equal(t, "`id` IN (SELECT `user_id` FROM `orders` WHERE `total`={:p0_1})", e.Build(db, params), "t1")
+	assert.Equal(t, Params{"p0": "a", "p0_1": 100}, params, "t2")
+
+	params = Params{}
+	e = NotIn("id", sub)
+	assert.Equal(t, "`id` NOT IN (SELECT `user_id` FROM `orders` WHERE `total`={:p0})", e.Build(db, params), "t3")
+
+	// generated param names don't overwrite the merged ones
+	params = Params{}
+	e = And(In("id", db.Select("id").From("a").Where(NewExp("x={:p1}", Params{"p1": 1}))), HashExp{"b": 2, "c": 3})
+	assert.Equal(t, "(`id` IN (SELECT `id` FROM `a` WHERE x={:p1})) AND (`b`={:p2} AND `c`={:p3})", e.Build(db, params), "t4")
+	assert.Equal(t, Params{"p1": 1, "p2": 2, "p3": 3}, params, "t5")
+
+	params = Params{}
+	e = HashExp{"id": sub}
+	assert.Equal(t, "`id` IN (SELECT `user_id` FROM `orders` WHERE `total`={:p0})", e.Build(db, params), "t6")
+}
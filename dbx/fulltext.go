@@ -0,0 +1,126 @@
+package dbx
+
+import (
+	"strings"
+)
+
+// PgsqlTextSearchConfig is the PostgreSQL text search configuration used
+// by the full-text indexes and Match expressions (eg. "simple", "english").
+//
+// Note that the indexes are used only when they are created with the same configuration.
+var PgsqlTextSearchConfig = "simple"
+
+// Match generates a full-text search expression matching the plain text query against the specified columns, eg.:
+//
+//	Match([]string{"email", "name"}, "john doe")
+//
+// The expression is rendered per dialect:
+//   - MySQL: MATCH(`email`, `name`) AGAINST({:p0} IN NATURAL LANGUAGE MODE)
+//   - PostgreSQL: to_tsvector('simple', ...) @@ plainto_tsquery('simple', {:p0})
+//   - SQLite: rowid IN (SELECT rowid FROM `users_fts` WHERE `users_fts` MATCH {:p0})
+//
+// SQLite requires the name of the FTS5 table created with CreateFullTextIndex (see MatchExp.Index()).
+// The columns of the expression should be the same as the columns of the full-text index,
+// otherwise the index may not be used (or in MySQL the query will fail).
+func Match(cols []string, query string) *MatchExp {
+	return &MatchExp{cols: cols, query: query}
+}
+
+// MatchExp represents a full-text search expression.
+type MatchExp struct {
+	cols  []string
+	query string
+	index string
+}
+
+// Index specifies the name of the full-text index to search in.
+// It is required only for SQLite where it is the name of the FTS5 table.
+func (e *MatchExp) Index(name string) *MatchExp {
+	e.index = name
+	return e
+}
+
+// Build converts an expression into a SQL fragment.
+func (e *MatchExp) Build(db *DB, params Params) string {
+	return db.Builder.QueryBuilder().BuildMatch(e.cols, e.query, e.index, params)
+}
+
+// Relevance returns an expression calculating the relevance of the matched
+// rows (the higher value means more relevant), eg. to be used with AndSelectExp().
+func (e *MatchExp) Relevance() Expression {
+	return &matchRelevanceExp{e}
+}
+
+type matchRelevanceExp struct {
+	match *MatchExp
+}
+
+// Build converts an expression into a SQL fragment.
+func (e *matchRelevanceExp) Build(db *DB, params Params) string {
+	m := e.match
+	return db.Builder.QueryBuilder().BuildMatchRelevance(m.cols, m.query, m.index, params)
+}
+
+// OrderByRelevance orders the query rows by the relevance of the provided full-text
+// search expression (most relevant first) before the ones specified with OrderBy().
+func (s *SelectQuery) OrderByRelevance(m *MatchExp) *SelectQuery {
+	s.relevance = m
+	return s
+}
+
+// buildOrderBy returns the ORDER BY columns of the query including the relevance one (if any).
+func (s *SelectQuery) buildOrderBy(params Params) []string {
+	if s.relevance == nil {
+		return s.orderBy
+	}
+
+	relevance := s.relevance.Relevance().Build(s.db, params) + " DESC"
+
+	return append([]string{relevance}, s.orderBy...)
+}
+
+// sqliteFtsQuery converts the provided plain text query into a FTS5 query
+// searching for all of its words in the specified columns.
+func sqliteFtsQuery(cols []string, query string) string {
+	words := strings.Fields(query)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"`
+	}
+
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		if pos := strings.LastIndex(col, "."); pos != -1 {
+			col = col[pos+1:]
+		}
+		names[i] = `"` + strings.Trim(col, "`\"[]") + `"`
+	}
+
+	if len(words) == 0 {
+		words = []string{`""`}
+	}
+
+	if len(names) == 0 {
+		return strings.Join(words, " ")
+	}
+
+	return "{" + strings.Join(names, " ") + "} : (" + strings.Join(words, " ") + ")"
+}
+
+// sqliteFtsRowid returns the rowid column of the table of the provided columns.
+func sqliteFtsRowid(db *DB, cols []string) string {
+	for _, col := range cols {
+		if pos := strings.LastIndex(col, "."); pos != -1 {
+			return db.QuoteTableName(col[:pos]) + ".rowid"
+		}
+	}
+	return "rowid"
+}
+
+// pgsqlTsvector returns the to_tsvector() expression of the provided columns.
+func pgsqlTsvector(db *DB, cols []string) string {
+	parts := make([]string, len(cols))
+	for i, col := range cols {
+		parts[i] = "coalesce(" + db.QuoteColumnName(col) + ", '')"
+	}
+	return "to_tsvector(" + db.Builder.Quote(PgsqlTextSearchConfig) + ", " + strings.Join(parts, " || ' ' || ") + ")"
+}
@@ -0,0 +1,153 @@
+package dbx
+
+import (
+	"errors"
+)
+
+// RowsQuery is a query that could be executed to retrieve its result
+// row by row (eg. *Query or *SelectQuery).
+type RowsQuery interface {
+	Rows() (*Rows, error)
+}
+
+// Iterator iterates over a query result scanning one row at a time
+// into a new T value, which must be a struct or a NullStringMap.
+//
+// Unlike All(), only the current row is kept in memory, which makes
+// it suitable for exporting or processing large result sets, eg.:
+//
+//	it, err := dbx.Iterate[User](db.Select().From("users"))
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//
+//	for it.Next() {
+//		user := it.Value()
+//		...
+//	}
+//
+//	return it.Err()
+//
+// The iteration stops with the context error as soon as the query context is canceled.
+type Iterator[T any] struct {
+	rows  *Rows
+	value *T
+	err   error
+}
+
+// Iterate executes the provided query and returns an iterator over its result.
+//
+// If the query is a SelectQuery without a FROM clause, the table name is inferred from T.
+// Note that the SelectQuery relations are not eager loaded.
+func Iterate[T any](q RowsQuery) (*Iterator[T], error) {
+	if s, ok := q.(*SelectQuery); ok && len(s.from) == 0 {
+		if tableName := s.TableMapper(new(T)); tableName != "" {
+			s.from = []string{tableName}
+		}
+	}
+
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Iterator[T]{rows: rows}, nil
+}
+
+// Next scans the next row of the result, making it available via Value().
+//
+// It returns false when there are no more rows, the query context was canceled
+// or an error occurred while scanning. Err() should be checked to distinguish between the cases.
+// The iterator is closed automatically when Next returns false.
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.value = nil
+
+	if ctx := it.rows.ctx; ctx != nil {
+		if err := ctx.Err(); err != nil {
+			it.fail(err)
+			return false
+		}
+	}
+
+	if !it.rows.Next() {
+		if err := it.rows.Err(); err != nil {
+			it.fail(err)
+		} else {
+			it.rows.Close()
+		}
+		return false
+	}
+
+	value := new(T)
+
+	var err error
+	switch v := any(value).(type) {
+	case *NullStringMap:
+		*v = NullStringMap{}
+		err = it.rows.ScanMap(*v)
+	default:
+		err = it.rows.ScanStruct(value)
+	}
+	if err != nil {
+		it.fail(err)
+		return false
+	}
+
+	it.value = value
+
+	return true
+}
+
+// Value returns the row scanned by the last Next() call.
+func (it *Iterator[T]) Value() *T {
+	return it.value
+}
+
+// Err returns the error (if any) that stopped the iteration.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Close closes the iterator and releases its DB resources.
+// It is safe to call Close multiple times.
+func (it *Iterator[T]) Close() error {
+	return it.rows.Close()
+}
+
+// fail stores the provided error and closes the iterator.
+func (it *Iterator[T]) fail(err error) {
+	it.err = err
+	it.rows.Close()
+}
+
+// ErrStopIteration could be returned by an Each callback to stop the iteration without an error.
+var ErrStopIteration = errors.New("stop iteration")
+
+// Each executes the provided query and calls fn for each row of its result,
+// scanned one at a time into a new T value (see Iterator).
+//
+// The iteration stops on the first fn error, which is returned as it is
+// (except ErrStopIteration that stops the iteration without an error).
+func Each[T any](q RowsQuery, fn func(*T) error) error {
+	it, err := Iterate[T](q)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for it.Next() {
+		if err := fn(it.Value()); err != nil {
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return it.Err()
+}
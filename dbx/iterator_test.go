@@ -0,0 +1,93 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterate(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	type customer struct {
+		ID    int
+		Email string
+	}
+
+	it, err := Iterate[customer](db.Select("id", "email").From("customer").OrderBy("id"))
+	if assert.Nil(t, err) {
+		ids := []int{}
+		for it.Next() {
+			ids = append(ids, it.Value().ID)
+		}
+		assert.Nil(t, it.Err())
+		assert.Equal(t, []int{1, 2, 3}, ids)
+		assert.Nil(t, it.Value())
+		assert.Nil(t, it.Close())
+	}
+
+	// canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	it, err = Iterate[customer](db.Select().From("customer").WithContext(ctx))
+	if assert.Nil(t, err) {
+		assert.True(t, it.Next())
+		cancel()
+		assert.False(t, it.Next())
+		assert.Equal(t, context.Canceled, it.Err())
+	}
+
+	// NullStringMap
+	it2, err := Iterate[NullStringMap](db.NewQuery("SELECT email FROM customer WHERE id=2"))
+	if assert.Nil(t, err) && assert.True(t, it2.Next()) {
+		assert.Equal(t, "user2@example.com", (*it2.Value())["email"].String)
+		assert.False(t, it2.Next())
+	}
+}
+
+func TestEach(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	type customer struct {
+		ID int
+	}
+
+	// table name inferred from the struct
+	count := 0
+	err := Each(db.Select().Where(HashExp{"status": 1}), func(c *relCustomer) error {
+		count++
+		assert.NotEmpty(t, c.Email)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	// stop the iteration
+	ids := []int{}
+	err = Each(db.Select().From("customer").OrderBy("id"), func(c *customer) error {
+		ids = append(ids, c.ID)
+		if len(ids) == 2 {
+			return ErrStopIteration
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, ids)
+
+	// callback error
+	testErr := errors.New("test")
+	err = Each(db.Select().From("customer"), func(c *customer) error {
+		return testErr
+	})
+	assert.Equal(t, testErr, err)
+
+	// query error
+	err = Each(db.Select().From("missing"), func(c *customer) error {
+		return nil
+	})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,288 @@
+package dbx
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	jsonExtract     = "extract"
+	jsonContains    = "contains"
+	jsonArrayLength = "arrayLength"
+)
+
+// JsonExtract generates an expression that extracts the scalar value
+// at the specified JSON path of a JSON column, eg.:
+//
+//	JsonExtract("value", "$.meta.appName").Compare("=", "ohmygo")
+//
+// The path is in the "$.key.nested[0]" format (the leading "$." could be omitted).
+// The extracted value is rendered per dialect:
+//   - MySQL: JSON_UNQUOTE(JSON_EXTRACT(`value`, {:p0}))
+//   - PostgreSQL: "value"::jsonb#>>{:p0} ("->>" for a single key)
+//   - SQLite: json_extract(`value`, {:p0})
+//   - SQL Server and Oracle: JSON_VALUE([value], {:p0})
+//
+// Note that PostgreSQL and MySQL extract the value as text.
+func JsonExtract(col, path string) *JsonExp {
+	return &JsonExp{kind: jsonExtract, col: col, path: normalizeJsonPath(path)}
+}
+
+// JsonContains generates an expression that checks whether the JSON
+// document at the optional JSON path of a JSON column contains value, eg.:
+//
+//	JsonContains("roles", "admin")
+//	JsonContains("value", map[string]any{"enabled": true}, "$.smtp")
+//
+// The value is rendered per dialect:
+//   - MySQL: JSON_CONTAINS(`roles`, {:p0})
+//   - PostgreSQL: "roles"::jsonb @> {:p0}::jsonb
+//   - SQLite: EXISTS (SELECT 1 FROM (SELECT `roles` AS doc) jd, json_each(jd.doc, {:p0}) je WHERE je.value={:p1})
+//   - SQL Server: EXISTS (SELECT 1 FROM OPENJSON([roles], {:p0}) AS je WHERE je.[value]={:p1})
+//
+// SQLite, SQL Server and Oracle don't have a JSON containment operator, so only
+// scalar values and flat arrays (all items must be contained) and objects
+// (all keys must match) are supported there.
+func JsonContains(col string, value interface{}, path ...string) *JsonExp {
+	p := "$"
+	if len(path) > 0 {
+		p = normalizeJsonPath(path[0])
+	}
+	return &JsonExp{kind: jsonContains, col: col, path: p, value: value}
+}
+
+// JsonArrayLength generates an expression that returns the length of
+// the JSON array at the optional JSON path of a JSON column, eg.:
+//
+//	JsonArrayLength("value", "$.tags").Compare(">", 2)
+func JsonArrayLength(col string, path ...string) *JsonExp {
+	p := "$"
+	if len(path) > 0 {
+		p = normalizeJsonPath(path[0])
+	}
+	return &JsonExp{kind: jsonArrayLength, col: col, path: p}
+}
+
+// JsonExp represents a JSON column expression created with JsonExtract, JsonContains or JsonArrayLength.
+type JsonExp struct {
+	kind  string
+	col   string
+	path  string
+	value interface{}
+
+	op      string
+	operand interface{}
+}
+
+// Compare compares the expression result with a value, eg. Compare(">", 2).
+// The value could also be an Expression or a *SelectQuery returning a single value.
+func (e *JsonExp) Compare(op string, value interface{}) *JsonExp {
+	e.op = op
+	e.operand = value
+	return e
+}
+
+// Build converts an expression into a SQL fragment.
+func (e *JsonExp) Build(db *DB, params Params) string {
+	qb := db.Builder.QueryBuilder()
+
+	var sql string
+	switch e.kind {
+	case jsonContains:
+		sql = qb.BuildJsonContains(e.col, e.path, e.value, params)
+	case jsonArrayLength:
+		sql = qb.BuildJsonArrayLength(e.col, e.path, params)
+	default:
+		sql = qb.BuildJsonExtract(e.col, e.path, params)
+	}
+
+	if e.op == "" {
+		return sql
+	}
+
+	return sql + buildCompare(db, e.op, e.operand, params)
+}
+
+// normalizeJsonPath returns the provided path in the "$.key" format.
+func normalizeJsonPath(path string) string {
+	if path == "" || path == "$" {
+		return "$"
+	}
+	if strings.HasPrefix(path, "$") {
+		return path
+	}
+	if strings.HasPrefix(path, "[") {
+		return "$" + path
+	}
+	return "$." + path
+}
+
+// splitJsonPath splits the provided "$.key.nested[0]" path into its
+// key and array index segments, eg. ["key", "nested", "0"].
+func splitJsonPath(path string) []string {
+	path = strings.TrimPrefix(path, "$")
+
+	segments := []string{}
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			if strings.HasPrefix(path, `"`) {
+				end := strings.Index(path[1:], `"`)
+				if end < 0 {
+					segments = append(segments, path[1:])
+					return segments
+				}
+				segments = append(segments, path[1:end+1])
+				path = path[end+2:]
+				continue
+			}
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			segments = append(segments, path[:end])
+			path = path[end:]
+		case '[':
+			end := strings.Index(path, "]")
+			if end < 0 {
+				end = len(path) - 1
+			}
+			segments = append(segments, strings.Trim(path[1:end], `"`))
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			segments = append(segments, path[:end])
+			path = path[end:]
+		}
+	}
+
+	return segments
+}
+
+// joinJsonPath appends the provided object key to a "$.key" path.
+func joinJsonPath(path, key string) string {
+	for _, r := range key {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return path + `."` + key + `"`
+		}
+	}
+	return path + "." + key
+}
+
+// jsonEncode returns the JSON representation of value.
+// Strings that are already valid JSON documents (eg. types.JsonRaw values) are returned as they are.
+func jsonEncode(value interface{}) string {
+	switch v := value.(type) {
+	case json.RawMessage:
+		return string(v)
+	case []byte:
+		if json.Valid(v) {
+			return string(v)
+		}
+	}
+
+	encoded, _ := json.Marshal(value)
+
+	return string(encoded)
+}
+
+// jsonContainsCondition builds a JSON containment condition for the dialects
+// without a JSON containment operator by checking each item of value.
+//
+// The each callback generates the condition checking whether the JSON
+// array (or scalar) at path contains a single scalar parameter, while the extract
+// callback generates the expression of the scalar value at path.
+func jsonContainsCondition(
+	value interface{},
+	path string,
+	params Params,
+	each func(path, param string) string,
+	extract func(path string) string,
+) string {
+	bind := func(v interface{}) string {
+		name := paramName(params)
+		if isJsonScalar(v) {
+			params[name] = v
+		} else {
+			params[name] = jsonEncode(v)
+		}
+		return "{:" + name + "}"
+	}
+
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	// raw JSON document (eg. types.JsonRaw)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+		var decoded interface{}
+		if err := json.Unmarshal(rv.Bytes(), &decoded); err == nil {
+			value = decoded
+			rv = reflect.ValueOf(decoded)
+		}
+	}
+
+	var conds []string
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			conds = append(conds, each(path, bind(rv.Index(i).Interface())))
+		}
+	case reflect.Map:
+		keys := make([]string, 0, rv.Len())
+		values := map[string]interface{}{}
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			keys = append(keys, k)
+			values[k] = iter.Value().Interface()
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			conds = append(conds, extract(joinJsonPath(path, k))+"="+bind(values[k]))
+		}
+	default:
+		conds = append(conds, each(path, bind(value)))
+	}
+
+	switch len(conds) {
+	case 0:
+		return "1=1"
+	case 1:
+		return conds[0]
+	}
+
+	return "(" + strings.Join(conds, ") AND (") + ")"
+}
+
+// isJsonScalar checks whether v is encoded as a JSON scalar value.
+func isJsonScalar(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		return false
+	}
+
+	return true
+}
+
+// pgsqlTextArray returns the PostgreSQL text array literal of the provided JSON path segments.
+func pgsqlTextArray(segments []string) string {
+	quoted := make([]string, len(segments))
+	for i, s := range segments {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		quoted[i] = `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}"
+}
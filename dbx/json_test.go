@@ -0,0 +1,37 @@
+package dbx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeJsonPath(t *testing.T) {
+	assert.Equal(t, "$", normalizeJsonPath(""), "t1")
+	assert.Equal(t, "$", normalizeJsonPath("$"), "t2")
+	assert.Equal(t, "$.a.b", normalizeJsonPath("a.b"), "t3")
+	assert.Equal(t, "$.a.b", normalizeJsonPath("$.a.b"), "t4")
+	assert.Equal(t, "$[0]", normalizeJsonPath("[0]"), "t5")
+}
+
+func TestSplitJsonPath(t *testing.T) {
+	assert.Equal(t, []string{}, splitJsonPath("$"), "t1")
+	assert.Equal(t, []string{"a", "b"}, splitJsonPath("$.a.b"), "t2")
+	assert.Equal(t, []string{"a", "0", "b"}, splitJsonPath("$.a[0].b"), "t3")
+	assert.Equal(t, []string{"a.b", "c"}, splitJsonPath(`$."a.b".c`), "t4")
+	assert.Equal(t, []string{"0", "a"}, splitJsonPath("$[0].a"), "t5")
+}
+
+func TestJsonExp_Compare(t *testing.T) {
+	db := getDB()
+
+	params := Params{}
+	sql := JsonArrayLength("tags").Compare(">", db.Select("MAX(n)").From("limits")).Build(db, params)
+	assert.Equal(t, "JSON_LENGTH(`tags`)>(SELECT MAX(n) FROM `limits`)", sql, "t1")
+	assert.Equal(t, 0, len(params), "t2")
+
+	params = Params{}
+	sql = JsonExtract("value", "a").Compare(" IS ", nil).Build(db, params)
+	assert.Equal(t, "JSON_UNQUOTE(JSON_EXTRACT(`value`, {:p0})) IS NULL", sql, "t3")
+	assert.Equal(t, Params{"p0": "$.a"}, params, "t4")
+}
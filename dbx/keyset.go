@@ -0,0 +1,272 @@
+package dbx
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidCursor is returned when a keyset pagination cursor cannot be decoded
+// or doesn't match the keyset columns of the query.
+var ErrInvalidCursor = errors.New("invalid keyset cursor")
+
+// KeysetColumn describes a keyset pagination column.
+type KeysetColumn struct {
+	Name string
+	Desc bool
+}
+
+// KeysetQuery represents a keyset (aka. cursor) paginated SELECT query.
+//
+// Instead of skipping the rows with OFFSET, the rows of each page are selected
+// with a "(a, b) > (?, ?)" predicate based on the last row of the previous page,
+// which keeps the query fast no matter how deep the page is.
+type KeysetQuery struct {
+	query  *SelectQuery
+	cols   []KeysetColumn
+	limit  int64
+	cursor string
+}
+
+// KeysetResult contains the cursors of the pages next to the one populated by KeysetQuery.All().
+// An empty cursor means that there is no such page.
+type KeysetResult struct {
+	NextCursor string `json:"nextCursor"`
+	PrevCursor string `json:"prevCursor"`
+}
+
+// keysetCursor is the decoded cursor data.
+type keysetCursor struct {
+	Values   []interface{} `json:"v"`
+	Backward bool          `json:"b,omitempty"`
+}
+
+// Keyset creates a new keyset paginated query ordered by the specified columns.
+//
+// A column could be prefixed with "-" or suffixed with " DESC" for descending order, eg.:
+//
+//	db.Select().From("posts").Keyset("-created", "id")
+//
+// The columns must not be nullable and their combination must be unique
+// (usually the last column is the primary key).
+// The ORDER BY clause of the query is replaced with the keyset columns.
+func (s *SelectQuery) Keyset(cols ...string) *KeysetQuery {
+	q := &KeysetQuery{query: s, limit: 30}
+
+	for _, col := range cols {
+		c := KeysetColumn{Name: strings.TrimSpace(col)}
+		if strings.HasPrefix(c.Name, "-") {
+			c.Name, c.Desc = c.Name[1:], true
+		} else if upper := strings.ToUpper(c.Name); strings.HasSuffix(upper, " DESC") {
+			c.Name, c.Desc = strings.TrimSpace(c.Name[:len(c.Name)-5]), true
+		} else if strings.HasSuffix(upper, " ASC") {
+			c.Name = strings.TrimSpace(c.Name[:len(c.Name)-4])
+		}
+		q.cols = append(q.cols, c)
+	}
+
+	return q
+}
+
+// Limit specifies the max number of rows per page (default to 30).
+func (q *KeysetQuery) Limit(limit int64) *KeysetQuery {
+	q.limit = limit
+	return q
+}
+
+// Cursor specifies the opaque cursor of the page to be populated,
+// as returned by a previous KeysetQuery.All() call.
+// An empty cursor means the first page.
+func (q *KeysetQuery) Cursor(cursor string) *KeysetQuery {
+	q.cursor = cursor
+	return q
+}
+
+// All executes the query and populates the rows of the current page into the specified slice,
+// which must be a pointer to a slice of structs (or pointers to structs) or NullStringMaps.
+//
+// The returned result contains the cursors of the next and the previous pages.
+func (q *KeysetQuery) All(slice interface{}) (*KeysetResult, error) {
+	if len(q.cols) == 0 {
+		return nil, errors.New("at least one keyset column must be specified")
+	}
+
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return nil, VarTypeError("must be a pointer to a slice")
+	}
+	v = v.Elem()
+	// the rows are appended to the slice so it must not contain the rows of a previous page
+	v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+
+	var cursor *keysetCursor
+	if q.cursor != "" {
+		var err error
+		if cursor, err = decodeKeysetCursor(q.cursor, len(q.cols)); err != nil {
+			return nil, err
+		}
+	}
+	backward := cursor != nil && cursor.Backward
+
+	// backward pages are selected in reversed order and then reversed back
+	cols := make([]KeysetColumn, len(q.cols))
+	orderBy := make([]string, len(q.cols))
+	for i, c := range q.cols {
+		cols[i] = KeysetColumn{c.Name, c.Desc != backward}
+		if cols[i].Desc {
+			orderBy[i] = c.Name + " DESC"
+		} else {
+			orderBy[i] = c.Name + " ASC"
+		}
+	}
+
+	// work on a copy so that the wrapped query is not modified
+	// and the KeysetQuery could be executed multiple times
+	sq := *q.query
+	s := sq.OrderBy(orderBy...)
+	if cursor != nil {
+		s.AndWhere(&keysetExp{cols, cursor.Values})
+	}
+	if q.limit > 0 {
+		s.Limit(q.limit + 1)
+	}
+
+	if err := s.All(slice); err != nil {
+		return nil, err
+	}
+
+	hasMore := q.limit > 0 && int64(v.Len()) > q.limit
+	if hasMore {
+		v.Set(v.Slice(0, int(q.limit)))
+	}
+
+	if backward {
+		swap := reflect.Swapper(v.Interface())
+		for i, j := 0, v.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	result := &KeysetResult{}
+	if v.Len() == 0 {
+		return result, nil
+	}
+
+	var err error
+	if hasMore || backward {
+		if result.NextCursor, err = q.encodeCursor(v.Index(v.Len()-1), false); err != nil {
+			return nil, err
+		}
+	}
+	if (hasMore && backward) || (cursor != nil && !backward) {
+		if result.PrevCursor, err = q.encodeCursor(v.Index(0), true); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// encodeCursor encodes the keyset column values of the provided row into an opaque cursor.
+func (q *KeysetQuery) encodeCursor(row reflect.Value, backward bool) (string, error) {
+	for row.Kind() == reflect.Ptr || row.Kind() == reflect.Interface {
+		row = row.Elem()
+	}
+
+	var si *structInfo
+	if row.Kind() == reflect.Struct {
+		si = getStructInfo(row.Type(), q.query.FieldMapper)
+	}
+
+	cursor := keysetCursor{Values: make([]interface{}, len(q.cols)), Backward: backward}
+	for i, c := range q.cols {
+		// use the unqualified column name, eg. "p.id" -> "id"
+		name := c.Name
+		if pos := strings.LastIndex(name, "."); pos >= 0 {
+			name = name[pos+1:]
+		}
+
+		var value interface{}
+		switch {
+		case si != nil:
+			fi, ok := si.dbNameMap[name]
+			if !ok {
+				return "", errors.New("missing keyset column " + name + " in " + row.Type().String())
+			}
+			value = fi.getValue(row)
+		case row.Type() == reflect.TypeOf(NullStringMap{}):
+			if ns := row.Interface().(NullStringMap)[name]; ns.Valid {
+				value = ns.String
+			}
+		default:
+			return "", VarTypeError("must be a slice of structs or NullStringMaps")
+		}
+
+		if valuer, ok := value.(driver.Valuer); ok {
+			var err error
+			if value, err = valuer.Value(); err != nil {
+				return "", err
+			}
+		}
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+
+		cursor.Values[i] = value
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeKeysetCursor decodes the provided opaque cursor and checks that it has n values.
+func decodeKeysetCursor(cursor string, n int) (*keysetCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	result := &keysetCursor{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(result); err != nil || len(result.Values) != n {
+		return nil, ErrInvalidCursor
+	}
+
+	for i, value := range result.Values {
+		switch v := value.(type) {
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				result.Values[i] = n
+			} else if f, err := v.Float64(); err == nil {
+				result.Values[i] = f
+			} else {
+				return nil, ErrInvalidCursor
+			}
+		case string, bool, nil:
+		default:
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return result, nil
+}
+
+// keysetExp represents the keyset predicate selecting the rows after the specified column values.
+type keysetExp struct {
+	cols   []KeysetColumn
+	values []interface{}
+}
+
+// Build converts an expression into a SQL fragment.
+func (e *keysetExp) Build(db *DB, params Params) string {
+	return db.Builder.QueryBuilder().BuildKeyset(e.cols, e.values, params)
+}
@@ -0,0 +1,133 @@
+package dbx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectQuery_Keyset(t *testing.T) {
+	db := getDB()
+
+	q := db.Select().Keyset("-created", "name DESC", "id ASC", " status ")
+	assert.Equal(t, []KeysetColumn{
+		{"created", true},
+		{"name", true},
+		{"id", false},
+		{"status", false},
+	}, q.cols)
+}
+
+func TestQB_BuildKeyset(t *testing.T) {
+	db := getDB()
+	qb := db.Builder.QueryBuilder()
+
+	params := Params{}
+	sql := qb.BuildKeyset([]KeysetColumn{{"created", false}, {"id", false}}, []interface{}{"2022", 5}, params)
+	assert.Equal(t, "(`created`, `id`)>({:p0}, {:p1})", sql, "t1")
+	assert.Equal(t, Params{"p0": "2022", "p1": 5}, params, "t2")
+
+	params = Params{"p0": 1}
+	sql = qb.BuildKeyset([]KeysetColumn{{"created", true}, {"id", true}}, []interface{}{"2022", 5}, params)
+	assert.Equal(t, "(`created`, `id`)<({:p1}, {:p2})", sql, "t3")
+
+	params = Params{}
+	sql = qb.BuildKeyset([]KeysetColumn{{"id", true}}, []interface{}{5}, params)
+	assert.Equal(t, "`id`<{:p0}", sql, "t4")
+
+	// mixed order
+	params = Params{}
+	sql = qb.BuildKeyset([]KeysetColumn{{"created", true}, {"id", false}}, []interface{}{"2022", 5}, params)
+	assert.Equal(t, "(`created`<{:p0}) OR (`created`={:p0} AND `id`>{:p1})", sql, "t5")
+
+	// without row value comparison support
+	mssql := getMssqlBuilder().QueryBuilder()
+	params = Params{}
+	sql = mssql.BuildKeyset([]KeysetColumn{{"a", false}, {"b", false}, {"c", false}}, []interface{}{1, 2, 3}, params)
+	assert.Equal(t, "([a]>{:p0}) OR ([a]={:p0} AND [b]>{:p1}) OR ([a]={:p0} AND [b]={:p1} AND [c]>{:p2})", sql, "t6")
+}
+
+func Test_decodeKeysetCursor(t *testing.T) {
+	q := &KeysetQuery{query: getDB().Select(), cols: []KeysetColumn{{"email", false}, {"id", false}}}
+
+	cursor, err := q.encodeCursor(reflect.ValueOf(NullStringMap{}), false)
+	if assert.Nil(t, err) {
+		c, err := decodeKeysetCursor(cursor, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, &keysetCursor{Values: []interface{}{nil, nil}}, c)
+	}
+
+	type row struct {
+		ID    int
+		Email string
+	}
+	cursor, err = q.encodeCursor(reflect.ValueOf(&row{5, "test@example.com"}), true)
+	if assert.Nil(t, err) {
+		c, err := decodeKeysetCursor(cursor, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, &keysetCursor{Values: []interface{}{"test@example.com", int64(5)}, Backward: true}, c)
+
+		// columns mismatch
+		_, err = decodeKeysetCursor(cursor, 1)
+		assert.Equal(t, ErrInvalidCursor, err)
+	}
+
+	_, err = decodeKeysetCursor("invalid!", 2)
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestKeysetQuery_All(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	type customer struct {
+		ID    int
+		Email string
+	}
+
+	var customers []customer
+
+	// first page
+	result, err := db.Select("id", "email").From("customer").Keyset("-id").Limit(2).All(&customers)
+	if assert.Nil(t, err) && assert.Equal(t, 2, len(customers)) {
+		assert.Equal(t, 3, customers[0].ID)
+		assert.Equal(t, 2, customers[1].ID)
+		assert.NotEmpty(t, result.NextCursor)
+		assert.Empty(t, result.PrevCursor)
+	}
+
+	// last page
+	next := result.NextCursor
+	result, err = db.Select("id", "email").From("customer").Keyset("-id").Limit(2).Cursor(next).All(&customers)
+	if assert.Nil(t, err) && assert.Equal(t, 1, len(customers)) {
+		assert.Equal(t, 1, customers[0].ID)
+		assert.Empty(t, result.NextCursor)
+		assert.NotEmpty(t, result.PrevCursor)
+	}
+
+	// back to the first page
+	result, err = db.Select("id", "email").From("customer").Keyset("-id").Limit(2).Cursor(result.PrevCursor).All(&customers)
+	if assert.Nil(t, err) && assert.Equal(t, 2, len(customers)) {
+		assert.Equal(t, 3, customers[0].ID)
+		assert.Equal(t, 2, customers[1].ID)
+		assert.Equal(t, next, result.NextCursor)
+		assert.Empty(t, result.PrevCursor)
+	}
+
+	// the wrapped query is not modified so the keyset query could be executed multiple times
+	sq := db.Select("id", "email").From("customer")
+	kq := sq.Keyset("-id").Limit(2).Cursor(next)
+	for i := 0; i < 2; i++ {
+		_, err = kq.All(&customers)
+		if assert.Nil(t, err) && assert.Equal(t, 1, len(customers)) {
+			assert.Equal(t, 1, customers[0].ID)
+		}
+	}
+	assert.Nil(t, sq.where)
+	assert.Nil(t, sq.orderBy)
+	assert.Equal(t, int64(-1), sq.limit)
+
+	_, err = db.Select().From("customer").Keyset("id").Cursor("invalid").All(&customers)
+	assert.Equal(t, ErrInvalidCursor, err)
+}
@@ -0,0 +1,255 @@
+package dbx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SlowQueryLogFunc is called each time when a SQL statement takes longer than DB.SlowQueryThreshold.
+// The "sql" parameter is the SQL statement with the bound parameters and the "caller"
+// parameter is the location ("file:line") of the code that executed the statement.
+type SlowQueryLogFunc func(ctx context.Context, t time.Duration, sql string, caller string)
+
+// DefaultSlowQueryLogFunc logs the slow SQL statements with the standard logger.
+func DefaultSlowQueryLogFunc(ctx context.Context, t time.Duration, sql string, caller string) {
+	log.Printf("[SLOW %.2fms] %s (%s)\n", float64(t.Microseconds())/1000, sql, caller)
+}
+
+// DefaultLatencyBuckets are the default upper bounds of the QueryMetrics latency histogram buckets.
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+}
+
+// DefaultMaxMetricsStatements is the default max number of distinct statements tracked by QueryMetrics.
+const DefaultMaxMetricsStatements = 1000
+
+// OtherStatements is the key of the statements that exceeded the QueryMetrics statements limit.
+const OtherStatements = "other"
+
+// StatementStats contains the execution stats of a normalized SQL statement.
+type StatementStats struct {
+	SQL       string        `json:"sql"`
+	Count     int64         `json:"count"`
+	Errors    int64         `json:"errors"`
+	TotalTime time.Duration `json:"totalTime"`
+	MaxTime   time.Duration `json:"maxTime"`
+	// Buckets contains the number of executions that took up to the
+	// corresponding QueryMetrics bucket duration (the last one is +Inf).
+	Buckets []int64 `json:"buckets"`
+}
+
+// QueryMetrics collects per statement counters and latency histograms
+// keyed by the normalized SQL of the executed statements (see NormalizeSQL).
+//
+// QueryMetrics implements http.Handler and serves the metrics in the Prometheus text format.
+type QueryMetrics struct {
+	// MaxStatements limits the number of the distinct tracked statements
+	// to prevent unbounded memory usage. The statements over the limit
+	// are tracked together under the OtherStatements key.
+	MaxStatements int
+
+	mux     sync.Mutex
+	buckets []time.Duration
+	stats   map[string]*StatementStats
+}
+
+// NewQueryMetrics creates a new QueryMetrics instance with the specified
+// latency histogram buckets (default to DefaultLatencyBuckets).
+func NewQueryMetrics(buckets ...time.Duration) *QueryMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	sorted := append([]time.Duration{}, buckets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return &QueryMetrics{
+		MaxStatements: DefaultMaxMetricsStatements,
+		buckets:       sorted,
+		stats:         map[string]*StatementStats{},
+	}
+}
+
+// Buckets returns the upper bounds of the latency histogram buckets.
+func (m *QueryMetrics) Buckets() []time.Duration {
+	return append([]time.Duration{}, m.buckets...)
+}
+
+// Observe records a single execution of the provided SQL statement.
+func (m *QueryMetrics) Observe(sql string, t time.Duration, err error) {
+	key := NormalizeSQL(sql)
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	s, ok := m.stats[key]
+	if !ok {
+		if m.MaxStatements > 0 && len(m.stats) >= m.MaxStatements {
+			key = OtherStatements
+			s = m.stats[key]
+		}
+		if s == nil {
+			s = &StatementStats{SQL: key, Buckets: make([]int64, len(m.buckets)+1)}
+			m.stats[key] = s
+		}
+	}
+
+	s.Count++
+	if err != nil {
+		s.Errors++
+	}
+	s.TotalTime += t
+	if t > s.MaxTime {
+		s.MaxTime = t
+	}
+
+	i := sort.Search(len(m.buckets), func(i int) bool { return t <= m.buckets[i] })
+	s.Buckets[i]++
+}
+
+// Stats returns a copy of the collected statement stats sorted by their total time (descending).
+func (m *QueryMetrics) Stats() []StatementStats {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	result := make([]StatementStats, 0, len(m.stats))
+	for _, s := range m.stats {
+		c := *s
+		c.Buckets = append([]int64{}, s.Buckets...)
+		result = append(result, c)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalTime == result[j].TotalTime {
+			return result[i].SQL < result[j].SQL
+		}
+		return result[i].TotalTime > result[j].TotalTime
+	})
+
+	return result
+}
+
+// Reset removes all collected stats.
+func (m *QueryMetrics) Reset() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.stats = map[string]*StatementStats{}
+}
+
+// WritePrometheus writes the collected stats in the Prometheus text exposition format.
+func (m *QueryMetrics) WritePrometheus(w io.Writer) error {
+	stats := m.Stats()
+
+	var b strings.Builder
+
+	b.WriteString("# HELP dbx_statement_errors_total The number of failed SQL statement executions.\n")
+	b.WriteString("# TYPE dbx_statement_errors_total counter\n")
+	for _, s := range stats {
+		fmt.Fprintf(&b, "dbx_statement_errors_total{sql=%s} %d\n", promLabel(s.SQL), s.Errors)
+	}
+
+	b.WriteString("# HELP dbx_statement_duration_seconds The SQL statement execution latency.\n")
+	b.WriteString("# TYPE dbx_statement_duration_seconds histogram\n")
+	for _, s := range stats {
+		label := promLabel(s.SQL)
+		var cumulative int64
+		for i, bucket := range m.buckets {
+			cumulative += s.Buckets[i]
+			fmt.Fprintf(&b, "dbx_statement_duration_seconds_bucket{sql=%s,le=\"%s\"} %d\n", label, strconv.FormatFloat(bucket.Seconds(), 'f', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "dbx_statement_duration_seconds_bucket{sql=%s,le=\"+Inf\"} %d\n", label, s.Count)
+		fmt.Fprintf(&b, "dbx_statement_duration_seconds_sum{sql=%s} %s\n", label, strconv.FormatFloat(s.TotalTime.Seconds(), 'f', -1, 64))
+		fmt.Fprintf(&b, "dbx_statement_duration_seconds_count{sql=%s} %d\n", label, s.Count)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// ServeHTTP serves the collected stats in the Prometheus text exposition format.
+func (m *QueryMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WritePrometheus(w)
+}
+
+// promLabel quotes and escapes the provided Prometheus label value.
+func promLabel(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return `"` + value + `"`
+}
+
+var (
+	normalizePlaceholderRegex = regexp.MustCompile(`\{:\w+\}`)
+	normalizeStringRegex      = regexp.MustCompile(`'(?:[^']|'')*'`)
+	normalizeNumberRegex      = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	normalizeListRegex        = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	normalizeSpaceRegex       = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeSQL normalizes the provided SQL statement so that the executions of the same
+// statement with different parameters (and number of IN values) could be grouped together.
+//
+// The named parameters and the string and number literals are replaced with "?",
+// lists of placeholders are collapsed to "(?)" and the whitespaces are collapsed to a single space.
+// For example "SELECT * FROM users WHERE id IN ({:p0}, {:p1}) LIMIT 10"
+// is normalized to "SELECT * FROM users WHERE id IN (?) LIMIT ?".
+func NormalizeSQL(sql string) string {
+	sql = normalizePlaceholderRegex.ReplaceAllString(sql, "?")
+	sql = normalizeStringRegex.ReplaceAllString(sql, "?")
+	sql = normalizeNumberRegex.ReplaceAllString(sql, "?")
+	sql = normalizeListRegex.ReplaceAllString(sql, "(?)")
+	sql = normalizeSpaceRegex.ReplaceAllString(sql, " ")
+	return strings.TrimSpace(sql)
+}
+
+// callerLocation returns the "file:line" location of the first caller outside of the dbx package.
+func callerLocation() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if !isDbxFrame(frame) {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+
+	return "unknown"
+}
+
+var dbxPackagePrefix = reflect.TypeOf(Query{}).PkgPath() + "."
+
+// isDbxFrame checks whether the provided frame is from the dbx package (excluding its tests).
+func isDbxFrame(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, dbxPackagePrefix) &&
+		!strings.HasSuffix(frame.File, "_test.go")
+}
@@ -0,0 +1,97 @@
+package dbx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	scenarios := []struct {
+		sql      string
+		expected string
+	}{
+		{"SELECT * FROM `users`", "SELECT * FROM `users`"},
+		{"SELECT * FROM users WHERE id IN ({:p0}, {:p1}) LIMIT 10", "SELECT * FROM users WHERE id IN (?) LIMIT ?"},
+		{"SELECT * FROM users WHERE id IN ({:p0})", "SELECT * FROM users WHERE id IN (?)"},
+		{"SELECT * FROM t2 WHERE name='it''s' AND score>1.5", "SELECT * FROM t2 WHERE name=? AND score>?"},
+		{"SELECT *\n  FROM users\nORDER BY id", "SELECT * FROM users ORDER BY id"},
+	}
+
+	for _, s := range scenarios {
+		assert.Equal(t, s.expected, NormalizeSQL(s.sql), s.sql)
+	}
+}
+
+func TestQueryMetrics(t *testing.T) {
+	m := NewQueryMetrics(10*time.Millisecond, time.Millisecond)
+	assert.Equal(t, []time.Duration{time.Millisecond, 10 * time.Millisecond}, m.Buckets())
+
+	m.Observe("SELECT * FROM users WHERE id={:p0}", 500*time.Microsecond, nil)
+	m.Observe("SELECT * FROM users WHERE id={:id}", 5*time.Millisecond, nil)
+	m.Observe("SELECT * FROM users WHERE id={:p0}", time.Second, errors.New("test"))
+	m.Observe("DELETE FROM users", time.Millisecond, nil)
+
+	stats := m.Stats()
+	if assert.Equal(t, 2, len(stats)) {
+		assert.Equal(t, StatementStats{
+			SQL:       "SELECT * FROM users WHERE id=?",
+			Count:     3,
+			Errors:    1,
+			TotalTime: time.Second + 5500*time.Microsecond,
+			MaxTime:   time.Second,
+			Buckets:   []int64{1, 1, 1},
+		}, stats[0])
+		assert.Equal(t, []int64{1, 0, 0}, stats[1].Buckets)
+	}
+
+	// statements over the limit
+	m.MaxStatements = 2
+	m.Observe("SELECT 1", time.Millisecond, nil)
+	m.Observe("SELECT * FROM posts", time.Millisecond, nil)
+	stats = m.Stats()
+	if assert.Equal(t, 3, len(stats)) {
+		assert.Equal(t, OtherStatements, stats[1].SQL)
+		assert.Equal(t, int64(2), stats[1].Count)
+	}
+
+	var b bytes.Buffer
+	assert.Nil(t, m.WritePrometheus(&b))
+	output := b.String()
+	assert.Contains(t, output, `dbx_statement_errors_total{sql="SELECT * FROM users WHERE id=?"} 1`)
+	assert.Contains(t, output, `dbx_statement_duration_seconds_bucket{sql="SELECT * FROM users WHERE id=?",le="0.01"} 2`)
+	assert.Contains(t, output, `dbx_statement_duration_seconds_bucket{sql="SELECT * FROM users WHERE id=?",le="+Inf"} 3`)
+	assert.Contains(t, output, `dbx_statement_duration_seconds_count{sql="DELETE FROM users"} 1`)
+
+	m.Reset()
+	assert.Equal(t, 0, len(m.Stats()))
+}
+
+func TestQuery_observe(t *testing.T) {
+	db := getDB()
+	db.Metrics = NewQueryMetrics()
+	db.SlowQueryThreshold = 100 * time.Millisecond
+
+	var logged []string
+	db.SlowQueryLogFunc = func(ctx context.Context, t time.Duration, sql string, caller string) {
+		logged = append(logged, sql+" "+caller)
+	}
+
+	q := db.NewQuery("SELECT * FROM users WHERE id={:id}").Bind(Params{"id": 1})
+
+	q.observe(50*time.Millisecond, nil)
+	assert.Equal(t, 0, len(logged))
+
+	q.observe(100*time.Millisecond, nil)
+	if assert.Equal(t, 1, len(logged)) {
+		assert.True(t, strings.HasPrefix(logged[0], "SELECT * FROM users WHERE id=1 "), logged[0])
+		assert.Contains(t, logged[0], "metrics_test.go:")
+	}
+
+	assert.Equal(t, int64(2), db.Metrics.Stats()[0].Count)
+}
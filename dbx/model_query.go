@@ -2,8 +2,8 @@ package dbx
 
 import (
 	"context"
+	"database/sql"
 	"errors"
-	"fmt"
 	"reflect"
 )
 
@@ -64,14 +64,34 @@ func (q *ModelQuery) Exclude(attrs ...string) *ModelQuery {
 // By default, it inserts *all* public fields into the table, including those nil or empty ones.
 // You may pass a list of the fields to this method to indicate that only those fields should be inserted.
 // You may also call Exclude to exclude some fields from being inserted.
+// The "readonly" tagged fields are never inserted and the "omitempty" tagged fields are skipped if empty.
 //
 // If a model has an empty primary key, it is considered auto-incremental and the corresponding struct
 // field will be filled with the generated primary key value after a successful insertion.
+//
+// If the DB supports returning the inserted row (see Query.Returning()), all struct fields are
+// refreshed with the inserted row values (eg. the column defaults) and an empty string primary key
+// is also considered as generated by the DB.
 func (q *ModelQuery) Insert(attrs ...string) error {
 	if q.lastError != nil {
 		return q.lastError
 	}
 	cols := q.model.columns(attrs, q.exclude)
+	q.model.omitEmpty(cols)
+
+	if q.supportsReturning() {
+		for name, value := range q.model.pk() {
+			if isEmptyPK(value) {
+				delete(cols, name)
+			}
+		}
+		query := q.builder.Insert(q.model.tableName, Params(cols)).WithContext(q.ctx)
+		if err := query.returning([]string{"*"}); err != nil {
+			return err
+		}
+		return query.One(q.model.value.Addr().Interface())
+	}
+
 	pkName := ""
 	for name, value := range q.model.pk() {
 		if isAutoInc(value) {
@@ -88,7 +108,7 @@ func (q *ModelQuery) Insert(attrs ...string) error {
 
 	// handle auto-incremental PK
 	query := q.builder.Insert(q.model.tableName, Params(cols)).WithContext(q.ctx)
-	pkValue, err := insertAndReturnPK(q.db, query, pkName)
+	pkValue, err := insertAndReturnPK(query)
 	if err != nil {
 		return err
 	}
@@ -104,22 +124,26 @@ func (q *ModelQuery) Insert(attrs ...string) error {
 	return nil
 }
 
-func insertAndReturnPK(db *DB, query *Query, pkName string) (int64, error) {
-	if db.DriverName() != "postgres" && db.DriverName() != "pgx" {
-		result, err := query.Execute()
-		if err != nil {
-			return 0, err
-		}
-		return result.LastInsertId()
+func insertAndReturnPK(query *Query) (int64, error) {
+	result, err := query.Execute()
+	if err != nil {
+		return 0, err
 	}
+	return result.LastInsertId()
+}
 
-	// specially handle postgres (lib/pq) as it doesn't support LastInsertId
-	returning := fmt.Sprintf(" RETURNING %s", db.QuoteColumnName(pkName))
-	query.sql += returning
-	query.rawSQL += returning
-	var pkValue int64
-	err := query.Row(&pkValue)
-	return pkValue, err
+// supportsReturning checks whether the DB supports returning the inserted and updated rows.
+func (q *ModelQuery) supportsReturning() bool {
+	_, err := q.builder.QueryBuilder().BuildReturning("", 0, []string{"*"})
+	return err == nil
+}
+
+// isEmptyPK checks whether the primary key value is empty and should be generated by the DB.
+func isEmptyPK(value interface{}) bool {
+	if v := reflect.Indirect(reflect.ValueOf(value)); v.Kind() == reflect.String {
+		return v.String() == ""
+	}
+	return isAutoInc(value)
 }
 
 func isAutoInc(value interface{}) bool {
@@ -143,6 +167,10 @@ func isAutoInc(value interface{}) bool {
 // By default, it updates *all* public fields in the table, including those nil or empty ones.
 // You may pass a list of the fields to this method to indicate that only those fields should be updated.
 // You may also call Exclude to exclude some fields from being updated.
+// The "readonly" tagged fields are never updated.
+//
+// If the DB supports returning the updated row (see Query.Returning()), all struct fields
+// are refreshed with the updated row values (eg. the columns updated by triggers).
 func (q *ModelQuery) Update(attrs ...string) error {
 	if q.lastError != nil {
 		return q.lastError
@@ -156,7 +184,21 @@ func (q *ModelQuery) Update(attrs ...string) error {
 	for name := range pk {
 		delete(cols, name)
 	}
-	_, err := q.builder.Update(q.model.tableName, Params(cols), HashExp(pk)).WithContext(q.ctx).Execute()
+	query := q.builder.Update(q.model.tableName, Params(cols), HashExp(pk)).WithContext(q.ctx)
+
+	if q.supportsReturning() {
+		if err := query.returning([]string{"*"}); err != nil {
+			return err
+		}
+		err := query.One(q.model.value.Addr().Interface())
+		if errors.Is(err, sql.ErrNoRows) {
+			// no matching row, the same as executing the UPDATE statement
+			return nil
+		}
+		return err
+	}
+
+	_, err := query.Execute()
 	return err
 }
 
@@ -42,6 +42,18 @@ type Query struct {
 
 	stmt *sql.Stmt
 	ctx  context.Context
+	db   *DB
+
+	// the position of the OUTPUT clause in sql for the dialects that don't
+	// append the returning clause at the end of the statement (-1 if unknown)
+	outputPos int
+
+	// the statements of a multi-statement query executed one by one instead of sql (see Execute)
+	statements []string
+
+	// the DB statement cache (if any) and the connections pool it prepares the statements with
+	stmtCache *StmtCache
+	sqlDB     *sql.DB
 
 	// FieldMapper maps struct field names to DB column names.
 	FieldMapper FieldMapFunc
@@ -57,6 +69,14 @@ type Query struct {
 	QueryLogFunc QueryLogFunc
 	// ExecLogFunc is called each time when a SQL statement is executed.
 	ExecLogFunc ExecLogFunc
+	// SlowQueryThreshold is the min duration of the SQL statement to be reported to SlowQueryLogFunc.
+	// It is ignored if not positive.
+	SlowQueryThreshold time.Duration
+	// SlowQueryLogFunc is called when the SQL statement takes longer than SlowQueryThreshold.
+	// If nil, DefaultSlowQueryLogFunc is used.
+	SlowQueryLogFunc SlowQueryLogFunc
+	// Metrics collects the SQL statement execution stats. It is ignored if nil.
+	Metrics *QueryMetrics
 }
 
 // NewQuery creates a new Query with the given SQL statement.
@@ -69,11 +89,19 @@ func NewQuery(db *DB, executor Executor, sql string) *Query {
 		placeholders: placeholders,
 		params:       Params{},
 		ctx:          db.ctx,
+		db:           db,
+		outputPos:    -1,
+		stmtCache:    db.StmtCache,
+		sqlDB:        db.sqlDB,
 		FieldMapper:  db.FieldMapper,
 		LogFunc:      db.LogFunc,
 		PerfFunc:     db.PerfFunc,
 		QueryLogFunc: db.QueryLogFunc,
 		ExecLogFunc:  db.ExecLogFunc,
+
+		SlowQueryThreshold: db.SlowQueryThreshold,
+		SlowQueryLogFunc:   db.SlowQueryLogFunc,
+		Metrics:            db.Metrics,
 	}
 }
 
@@ -116,6 +144,43 @@ func (q *Query) logSQL() string {
 	return s
 }
 
+// Returning adds a clause returning the specified columns (or all columns if none are specified)
+// of the rows inserted, updated or deleted by the query, eg.:
+//
+//	var user User
+//	err := db.Insert("users", dbx.Params{"name": "James"}).Returning("id", "created").One(&user)
+//
+// It is rendered as RETURNING in PostgreSQL and SQLite (3.35 or newer) and as OUTPUT in SQL Server.
+// The other databases set LastError. Returning must be called before Prepare().
+func (q *Query) Returning(cols ...string) *Query {
+	if q.LastError != nil {
+		return q
+	}
+
+	if len(cols) == 0 {
+		cols = []string{"*"}
+	}
+
+	if err := q.returning(cols); err != nil {
+		q.LastError = err
+	}
+
+	return q
+}
+
+// returning adds a clause returning the specified columns to the query statement.
+func (q *Query) returning(cols []string) error {
+	sql, err := q.db.Builder.QueryBuilder().BuildReturning(q.sql, q.outputPos, cols)
+	if err != nil {
+		return err
+	}
+
+	q.sql = sql
+	q.rawSQL, q.placeholders = q.db.processSQL(sql)
+
+	return nil
+}
+
 // Params returns the parameters to be bound to the SQL statement represented by this query.
 func (q *Query) Params() Params {
 	return q.params
@@ -166,6 +231,10 @@ func (q *Query) Execute() (result sql.Result, err error) {
 		return
 	}
 
+	if len(q.statements) > 0 {
+		return q.executeStatements()
+	}
+
 	var params []interface{}
 	params, err = replacePlaceholders(q.placeholders, q.params)
 	if err != nil {
@@ -174,20 +243,15 @@ func (q *Query) Execute() (result sql.Result, err error) {
 
 	start := time.Now()
 
-	if q.ctx == nil {
-		if q.stmt == nil {
-			result, err = q.executor.Exec(q.rawSQL, params...)
-		} else {
-			result, err = q.stmt.Exec(params...)
-		}
-	} else {
-		if q.stmt == nil {
-			result, err = q.executor.ExecContext(q.ctx, q.rawSQL, params...)
-		} else {
-			result, err = q.stmt.ExecContext(q.ctx, params...)
-		}
+	stmt, cached := q.cachedStmt()
+	result, err = q.exec(stmt, params)
+	if cached && isStaleStmtError(err) {
+		q.stmtCache.Remove(q.rawSQL)
+		result, err = q.exec(nil, params)
 	}
 
+	q.observe(time.Now().Sub(start), err)
+
 	if q.ExecLogFunc != nil {
 		q.ExecLogFunc(q.ctx, time.Now().Sub(start), q.logSQL(), result, err)
 	}
@@ -261,21 +325,15 @@ func (q *Query) Rows() (rows *Rows, err error) {
 
 	start := time.Now()
 
-	var rr *sql.Rows
-	if q.ctx == nil {
-		if q.stmt == nil {
-			rr, err = q.executor.Query(q.rawSQL, params...)
-		} else {
-			rr, err = q.stmt.Query(params...)
-		}
-	} else {
-		if q.stmt == nil {
-			rr, err = q.executor.QueryContext(q.ctx, q.rawSQL, params...)
-		} else {
-			rr, err = q.stmt.QueryContext(q.ctx, params...)
-		}
+	stmt, cached := q.cachedStmt()
+	rr, err := q.query(stmt, params)
+	if cached && isStaleStmtError(err) {
+		q.stmtCache.Remove(q.rawSQL)
+		rr, err = q.query(nil, params)
 	}
-	rows = &Rows{rr, q.FieldMapper}
+	rows = &Rows{rr, q.FieldMapper, q.ctx}
+
+	q.observe(time.Now().Sub(start), err)
 
 	if q.QueryLogFunc != nil {
 		q.QueryLogFunc(q.ctx, time.Now().Sub(start), q.logSQL(), rr, err)
@@ -289,6 +347,134 @@ func (q *Query) Rows() (rows *Rows, err error) {
 	return
 }
 
+// exec executes the SQL statement with the provided prepared statement (if not nil).
+func (q *Query) exec(stmt *sql.Stmt, params []interface{}) (sql.Result, error) {
+	if q.ctx == nil {
+		if stmt == nil {
+			return q.executor.Exec(q.rawSQL, params...)
+		}
+		return stmt.Exec(params...)
+	}
+
+	if stmt == nil {
+		return q.executor.ExecContext(q.ctx, q.rawSQL, params...)
+	}
+	return stmt.ExecContext(q.ctx, params...)
+}
+
+// executeStatements executes the statements of a multi-statement query one by one
+// (most drivers execute only the first statement of a prepared multi-statement SQL).
+//
+// The statements are executed in a new transaction, unless the query is already part of one.
+func (q *Query) executeStatements() (sql.Result, error) {
+	if q.sqlDB == nil || q.executor != q.sqlDB {
+		return q.execStatements(q.executor)
+	}
+
+	ctx := q.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	tx, err := q.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := q.execStatements(tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return result, tx.Commit()
+}
+
+// execStatements executes the statements of a multi-statement query with the provided executor
+// and returns the result of the last one.
+func (q *Query) execStatements(executor Executor) (result sql.Result, err error) {
+	for _, statement := range q.statements {
+		sq := *q
+		sq.statements = nil
+		sq.executor = executor
+		sq.sql = statement
+		sq.rawSQL, sq.placeholders = q.db.processSQL(statement)
+
+		if result, err = sq.Execute(); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// query performs the SQL query with the provided prepared statement (if not nil).
+func (q *Query) query(stmt *sql.Stmt, params []interface{}) (*sql.Rows, error) {
+	if q.ctx == nil {
+		if stmt == nil {
+			return q.executor.Query(q.rawSQL, params...)
+		}
+		return stmt.Query(params...)
+	}
+
+	if stmt == nil {
+		return q.executor.QueryContext(q.ctx, q.rawSQL, params...)
+	}
+	return stmt.QueryContext(q.ctx, params...)
+}
+
+// cachedStmt returns the prepared statement to execute the query with.
+//
+// If the query was not explicitly prepared, it returns the cached DB statement
+// (bound to the transaction if the query executor is a transaction) and true.
+// It returns nil if there is no statement cache or the statement could not be prepared.
+func (q *Query) cachedStmt() (*sql.Stmt, bool) {
+	if q.stmt != nil {
+		return q.stmt, false
+	}
+
+	if q.stmtCache == nil || q.sqlDB == nil {
+		return nil, false
+	}
+
+	tx, isTx := q.executor.(*sql.Tx)
+	if !isTx && q.executor != q.sqlDB {
+		return nil, false
+	}
+
+	stmt, err := q.stmtCache.Get(q.ctx, q.sqlDB, q.rawSQL)
+	if err != nil {
+		// execute without preparing (the error, if persistent, will be reported then)
+		return nil, false
+	}
+
+	if isTx {
+		// the transaction statement is closed together with the transaction
+		if q.ctx == nil {
+			return tx.Stmt(stmt), true
+		}
+		return tx.StmtContext(q.ctx, stmt), true
+	}
+
+	return stmt, true
+}
+
+// observe reports the execution of the SQL statement to the query metrics
+// and to the slow query log (if it took longer than the slow query threshold).
+func (q *Query) observe(t time.Duration, err error) {
+	if q.Metrics != nil {
+		q.Metrics.Observe(q.sql, t, err)
+	}
+
+	if q.SlowQueryThreshold > 0 && t >= q.SlowQueryThreshold {
+		logFunc := q.SlowQueryLogFunc
+		if logFunc == nil {
+			logFunc = DefaultSlowQueryLogFunc
+		}
+		logFunc(q.ctx, t, q.logSQL(), callerLocation())
+	}
+}
+
 // replacePlaceholders converts a list of named parameters into a list of anonymous parameters.
 func replacePlaceholders(placeholders []string, params Params) ([]interface{}, error) {
 	if len(placeholders) == 0 {
@@ -6,8 +6,11 @@ package dbx
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -29,6 +32,28 @@ type QueryBuilder interface {
 	BuildOrderByAndLimit(string, []string, int64, int64) string
 	// BuildUnion generates a UNION clause from the given union information.
 	BuildUnion([]UnionInfo, Params) string
+	// BuildWith generates a WITH clause from the given common table expressions.
+	BuildWith([]CTEInfo, Params) string
+	// BuildOver generates an OVER clause from the given partition-by and order-by columns and frame.
+	BuildOver(partitionBy []string, orderBy []string, frame string) string
+	// BuildLock applies the row locking options to the given FROM clause and
+	// returns it together with the locking clause to be appended to the statement.
+	BuildLock(from string, lock LockInfo) (string, string)
+	// BuildKeyset generates the keyset pagination predicate selecting the rows after the given column values.
+	BuildKeyset(cols []KeysetColumn, values []interface{}, params Params) string
+	// BuildJsonExtract generates an expression extracting the scalar value at the JSON path of the given column.
+	BuildJsonExtract(col, path string, params Params) string
+	// BuildJsonContains generates a condition checking whether the JSON document at the path of the given column contains value.
+	BuildJsonContains(col, path string, value interface{}, params Params) string
+	// BuildJsonArrayLength generates an expression returning the length of the JSON array at the path of the given column.
+	BuildJsonArrayLength(col, path string, params Params) string
+	// BuildMatch generates a full-text search condition matching the plain text query against the given columns.
+	BuildMatch(cols []string, query, index string, params Params) string
+	// BuildMatchRelevance generates an expression calculating the full-text search relevance (higher is more relevant).
+	BuildMatchRelevance(cols []string, query, index string, params Params) string
+	// BuildReturning adds a clause returning the given columns of the affected rows to the given statement.
+	// The outputPos is the position for the dialects requiring the clause in the middle of the statement (-1 if unknown).
+	BuildReturning(sql string, outputPos int, cols []string) (string, error)
 }
 
 // BaseQueryBuilder provides a basic implementation of QueryBuilder.
@@ -178,18 +203,256 @@ func (q *BaseQueryBuilder) BuildUnion(unions []UnionInfo, params Params) string
 		if i > 0 {
 			sql += " "
 		}
-		for k, v := range union.Query.params {
-			params[k] = v
-		}
 		u := "UNION"
 		if union.All {
 			u = "UNION ALL"
 		}
-		sql += fmt.Sprintf("%v (%v)", u, union.Query.sql)
+		sql += fmt.Sprintf("%v (%v)", u, mergeParams(union.Query.sql, union.Query.params, params))
 	}
 	return sql
 }
 
+// BuildWith generates a WITH clause from the given common table expressions.
+func (q *BaseQueryBuilder) BuildWith(ctes []CTEInfo, params Params) string {
+	return q.buildWith(ctes, params, "WITH RECURSIVE")
+}
+
+// buildWith generates a WITH clause using the provided keyword
+// if any of the common table expressions is recursive.
+func (q *BaseQueryBuilder) buildWith(ctes []CTEInfo, params Params, recursiveKeyword string) string {
+	if len(ctes) == 0 {
+		return ""
+	}
+
+	keyword := "WITH"
+	parts := make([]string, 0, len(ctes))
+	for _, cte := range ctes {
+		if cte.Recursive {
+			keyword = recursiveKeyword
+		}
+
+		name := cte.Name
+		if !strings.Contains(name, "(") {
+			name = q.db.QuoteSimpleTableName(name)
+		}
+
+		parts = append(parts, fmt.Sprintf("%v AS (%v)", name, cte.Query.buildSub(params)))
+	}
+
+	return keyword + " " + strings.Join(parts, ", ")
+}
+
+// BuildOver generates an OVER clause from the given partition-by and order-by columns and frame.
+func (q *BaseQueryBuilder) BuildOver(partitionBy []string, orderBy []string, frame string) string {
+	parts := []string{}
+	if len(partitionBy) > 0 {
+		cols := make([]string, len(partitionBy))
+		for i, col := range partitionBy {
+			cols[i] = q.db.QuoteColumnName(col)
+		}
+		parts = append(parts, "PARTITION BY "+strings.Join(cols, ", "))
+	}
+	if orderBy := q.BuildOrderBy(orderBy); orderBy != "" {
+		parts = append(parts, orderBy)
+	}
+	if frame != "" {
+		parts = append(parts, frame)
+	}
+	return "OVER (" + strings.Join(parts, " ") + ")"
+}
+
+// BuildLock applies the row locking options to the given FROM clause and
+// returns it together with the locking clause to be appended to the statement.
+func (q *BaseQueryBuilder) BuildLock(from string, lock LockInfo) (string, string) {
+	if lock.Mode == "" {
+		return from, ""
+	}
+	if lock.Option == "" {
+		return from, lock.Mode
+	}
+	return from, lock.Mode + " " + lock.Option
+}
+
+// BuildKeyset generates the keyset pagination predicate selecting the rows after the given column values.
+//
+// When all columns have the same order a row value comparison is used, eg. "(a, b) > (?, ?)".
+func (q *BaseQueryBuilder) BuildKeyset(cols []KeysetColumn, values []interface{}, params Params) string {
+	for _, c := range cols[1:] {
+		if c.Desc != cols[0].Desc {
+			return q.buildExpandedKeyset(cols, values, params)
+		}
+	}
+
+	if len(cols) == 1 {
+		return q.buildExpandedKeyset(cols, values, params)
+	}
+
+	names := make([]string, len(cols))
+	placeholders := make([]string, len(cols))
+	for i, c := range cols {
+		name := paramName(params)
+		params[name] = values[i]
+		names[i] = q.db.QuoteColumnName(c.Name)
+		placeholders[i] = "{:" + name + "}"
+	}
+
+	op := ">"
+	if cols[0].Desc {
+		op = "<"
+	}
+
+	return "(" + strings.Join(names, ", ") + ")" + op + "(" + strings.Join(placeholders, ", ") + ")"
+}
+
+// buildExpandedKeyset generates the keyset pagination predicate without row value comparison,
+// eg. "(a > ?) OR (a = ? AND b > ?)".
+func (q *BaseQueryBuilder) buildExpandedKeyset(cols []KeysetColumn, values []interface{}, params Params) string {
+	placeholders := make([]string, len(cols))
+	for i := range cols {
+		name := paramName(params)
+		params[name] = values[i]
+		placeholders[i] = "{:" + name + "}"
+	}
+
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		op := ">"
+		if c.Desc {
+			op = "<"
+		}
+
+		conds := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conds = append(conds, q.db.QuoteColumnName(cols[j].Name)+"="+placeholders[j])
+		}
+		conds = append(conds, q.db.QuoteColumnName(c.Name)+op+placeholders[i])
+
+		parts[i] = strings.Join(conds, " AND ")
+	}
+
+	if len(parts) == 1 {
+		return parts[0]
+	}
+
+	return "(" + strings.Join(parts, ") OR (") + ")"
+}
+
+// BuildJsonExtract generates an expression extracting the scalar value at the JSON path of the given column.
+func (q *BaseQueryBuilder) BuildJsonExtract(col, path string, params Params) string {
+	name := paramName(params)
+	params[name] = path
+	return "JSON_UNQUOTE(JSON_EXTRACT(" + q.db.QuoteColumnName(col) + ", {:" + name + "}))"
+}
+
+// BuildJsonContains generates a condition checking whether the JSON document at the path of the given column contains value.
+func (q *BaseQueryBuilder) BuildJsonContains(col, path string, value interface{}, params Params) string {
+	name := paramName(params)
+	params[name] = jsonEncode(value)
+
+	if path == "$" {
+		return "JSON_CONTAINS(" + q.db.QuoteColumnName(col) + ", {:" + name + "})"
+	}
+
+	pathName := paramName(params)
+	params[pathName] = path
+
+	return "JSON_CONTAINS(" + q.db.QuoteColumnName(col) + ", {:" + name + "}, {:" + pathName + "})"
+}
+
+// BuildJsonArrayLength generates an expression returning the length of the JSON array at the path of the given column.
+func (q *BaseQueryBuilder) BuildJsonArrayLength(col, path string, params Params) string {
+	if path == "$" {
+		return "JSON_LENGTH(" + q.db.QuoteColumnName(col) + ")"
+	}
+
+	name := paramName(params)
+	params[name] = path
+
+	return "JSON_LENGTH(" + q.db.QuoteColumnName(col) + ", {:" + name + "})"
+}
+
+// BuildMatch generates a full-text search condition matching the plain text query against the given columns.
+func (q *BaseQueryBuilder) BuildMatch(cols []string, query, index string, params Params) string {
+	name := paramName(params)
+	params[name] = query
+	return "MATCH(" + q.quoteColumns(cols) + ") AGAINST({:" + name + "} IN NATURAL LANGUAGE MODE)"
+}
+
+// BuildMatchRelevance generates an expression calculating the full-text search relevance (higher is more relevant).
+func (q *BaseQueryBuilder) BuildMatchRelevance(cols []string, query, index string, params Params) string {
+	return q.BuildMatch(cols, query, index, params)
+}
+
+// BuildReturning adds a clause returning the given columns of the affected rows to the given statement.
+func (q *BaseQueryBuilder) BuildReturning(sql string, outputPos int, cols []string) (string, error) {
+	return "", errors.New("Returning is not supported")
+}
+
+// buildAppendedReturning appends a RETURNING clause with the given columns to the given statement.
+func (q *BaseQueryBuilder) buildAppendedReturning(sql string, cols []string) string {
+	return sql + " RETURNING " + q.quoteColumns(cols)
+}
+
+// quoteColumns quotes a list of columns and concatenates them with commas.
+func (q *BaseQueryBuilder) quoteColumns(cols []string) string {
+	s := make([]string, len(cols))
+	for i, col := range cols {
+		s[i] = q.db.QuoteColumnName(col)
+	}
+	return strings.Join(s, ", ")
+}
+
+// mergeParams merges the src params of the provided sql into dst and returns the sql.
+//
+// The src params whose names are already used in dst for a different value
+// are renamed (including their placeholders in the returned sql) to avoid
+// overwriting the dst values.
+func mergeParams(sql string, src, dst Params) string {
+	if len(src) == 0 {
+		return sql
+	}
+
+	// iterate in a stable order so that the renamed parameters
+	// (and therefore the built SQL) are the same on every build
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	renamed := map[string]string{}
+	for _, k := range keys {
+		v := src[k]
+		existing, ok := dst[k]
+		if !ok || reflect.DeepEqual(existing, v) {
+			dst[k] = v
+			continue
+		}
+
+		for i := 1; ; i++ {
+			name := fmt.Sprintf("%v_%v", k, i)
+			_, inDst := dst[name]
+			_, inSrc := src[name]
+			if !inDst && !inSrc {
+				renamed[k] = name
+				dst[name] = v
+				break
+			}
+		}
+	}
+
+	if len(renamed) == 0 {
+		return sql
+	}
+
+	return plRegex.ReplaceAllStringFunc(sql, func(m string) string {
+		if name, ok := renamed[m[2:len(m)-1]]; ok {
+			return "{:" + name + "}"
+		}
+		return m
+	})
+}
+
 var orderRegex = regexp.MustCompile(`\s+((?i)ASC|DESC)$`)
 
 // BuildOrderBy generates the ORDER BY clause.
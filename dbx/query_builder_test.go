@@ -226,3 +226,40 @@ func TestQB_BuildUnion(t *testing.T) {
 	expected = "UNION ALL (SELECT names) UNION (SELECT ages)"
 	assert.Equal(t, sql, expected, "BuildUnion@4")
 }
+
+func TestQB_BuildUnionParams(t *testing.T) {
+	db := getDB()
+	qb := db.QueryBuilder()
+
+	params := Params{"p0": 1}
+	ui := UnionInfo{false, db.NewQuery("SELECT * FROM a WHERE id={:p0} OR id={:p1}").Bind(Params{"p0": 2, "p1": 3})}
+	sql := qb.BuildUnion([]UnionInfo{ui}, params)
+	assert.Equal(t, "UNION (SELECT * FROM a WHERE id={:p0_1} OR id={:p1})", sql, "t1")
+	assert.Equal(t, Params{"p0": 1, "p0_1": 2, "p1": 3}, params, "t2")
+}
+
+func TestQB_BuildWith(t *testing.T) {
+	db := getDB()
+	qb := db.QueryBuilder()
+
+	sql := qb.BuildWith(nil, nil)
+	assert.Equal(t, "", sql, "t1")
+
+	params := Params{}
+	ctes := []CTEInfo{
+		{"active", false, db.Select("id").From("users").Where(HashExp{"status": 1})},
+		{"tree(id, parent_id)", true, db.Select("id", "parent_id").From("nodes").Where(HashExp{"parent_id": 2})},
+	}
+	sql = qb.BuildWith(ctes, params)
+	expected := "WITH RECURSIVE `active` AS (SELECT `id` FROM `users` WHERE `status`={:p0}), tree(id, parent_id) AS (SELECT `id`, `parent_id` FROM `nodes` WHERE `parent_id`={:p0_1})"
+	assert.Equal(t, expected, sql, "t2")
+	assert.Equal(t, Params{"p0": 1, "p0_1": 2}, params, "t3")
+}
+
+func TestQB_BuildOver(t *testing.T) {
+	qb := getDB().QueryBuilder()
+
+	assert.Equal(t, "OVER ()", qb.BuildOver(nil, nil, ""), "t1")
+	assert.Equal(t, "OVER (PARTITION BY `a`, `t`.`b`)", qb.BuildOver([]string{"a", "t.b"}, nil, ""), "t2")
+	assert.Equal(t, "OVER (ORDER BY `c` DESC ROWS 2 PRECEDING)", qb.BuildOver(nil, []string{"c DESC"}, "ROWS 2 PRECEDING"), "t3")
+}
@@ -0,0 +1,319 @@
+package dbx
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RelTag is the name of the struct tag used to declare a relation of the associated struct field.
+//
+// The tag value has the format "kind,key[,refKey]", where kind is one of
+// RelationHasOne, RelationHasMany or RelationBelongsTo. For example:
+//
+//	type User struct {
+//		Id            string
+//		Profile       *Profile       `rel:"hasOne,userId"`      // profile.userId = user.id
+//		ExternalAuths []ExternalAuth `rel:"hasMany,userId"`     // externalAuth.userId = user.id
+//		Team          *Team          `rel:"belongsTo,teamId"`   // user.teamId = team.id
+//		Manager       *User          `rel:"belongsTo,managerId,id"`
+//	}
+//
+// For hasOne and hasMany relations, key is the related table column referencing
+// the model and the optional refKey is the referenced model column (default to the model PK).
+//
+// For belongsTo relations, key is the model column referencing the related
+// table and the optional refKey is the referenced related table column
+// (default to the related model PK).
+//
+// Relation fields are not mapped to columns and could be eager loaded with SelectQuery.With().
+var RelTag = "rel"
+
+// Supported relation kinds.
+const (
+	RelationHasOne    = "hasOne"
+	RelationHasMany   = "hasMany"
+	RelationBelongsTo = "belongsTo"
+)
+
+// relationInfo describes a struct field relation.
+type relationInfo struct {
+	name   string       // struct field name
+	kind   string       // one of the Relation* constants
+	key    string       // the referencing column name
+	refKey string       // the optional referenced column name
+	path   []int        // index path to the struct field reflection
+	typ    reflect.Type // the struct field type
+}
+
+// parseRelTag parses the provided RelTag value.
+// It returns an error if the tag is not a valid relation declaration.
+func parseRelTag(tag string) (*relationInfo, error) {
+	parts := strings.Split(tag, ",")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("expected \"kind,key[,refKey]\" format, got %q", tag)
+	}
+
+	rel := &relationInfo{
+		kind: strings.TrimSpace(parts[0]),
+		key:  strings.TrimSpace(parts[1]),
+	}
+	if len(parts) == 3 {
+		rel.refKey = strings.TrimSpace(parts[2])
+	}
+
+	switch rel.kind {
+	case RelationHasOne, RelationHasMany, RelationBelongsTo:
+	default:
+		return nil, fmt.Errorf("unknown relation kind %q", rel.kind)
+	}
+
+	if rel.key == "" {
+		return nil, fmt.Errorf("missing relation key in %q", tag)
+	}
+
+	return rel, nil
+}
+
+// With specifies the struct relations to be eager loaded together
+// with the models populated by One() and All().
+//
+// Each relation is loaded with a single additional query no matter
+// of the number of the populated models.
+// Nested relations could be loaded using dot notation, eg. "Posts.Comments".
+//
+// See RelTag for how to declare a relation.
+func (s *SelectQuery) With(relations ...string) *SelectQuery {
+	s.with = append(s.with, relations...)
+	return s
+}
+
+// loadRelations eager loads the query relations into a, which must be
+// a pointer to a struct or a pointer to a slice of structs (or pointers to structs).
+func (s *SelectQuery) loadRelations(a interface{}) error {
+	if len(s.with) == 0 {
+		return nil
+	}
+
+	v := reflect.ValueOf(a)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return VarTypeError("must be a pointer")
+	}
+	v = v.Elem()
+
+	var items []reflect.Value
+
+	t := v.Type()
+	switch t.Kind() {
+	case reflect.Struct:
+		items = []reflect.Value{v}
+	case reflect.Slice:
+		t = t.Elem()
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			if item.Kind() == reflect.Ptr {
+				if item.IsNil() {
+					continue
+				}
+				item = item.Elem()
+			}
+			items = append(items, item)
+		}
+	}
+
+	if t.Kind() != reflect.Struct {
+		return VarTypeError("must be a pointer to a struct or a slice of structs")
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	// group the nested relations by their top level relation
+	// (eg. "Posts.Comments" is loaded as "Comments" of "Posts")
+	names := []string{}
+	nested := map[string][]string{}
+	for _, r := range s.with {
+		name, rest, _ := strings.Cut(r, ".")
+		if _, ok := nested[name]; !ok {
+			names = append(names, name)
+			nested[name] = []string{}
+		}
+		if rest != "" {
+			nested[name] = append(nested[name], rest)
+		}
+	}
+
+	si := getStructInfo(t, s.FieldMapper)
+
+	for _, name := range names {
+		rel, ok := si.relations[name]
+		if !ok {
+			return fmt.Errorf("unknown relation %q of %v", name, t)
+		}
+
+		if err := s.loadRelation(si, rel, items, nested[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadRelation loads the specified relation of all items with a single IN query.
+func (s *SelectQuery) loadRelation(si *structInfo, rel *relationInfo, items []reflect.Value, nested []string) error {
+	isSlice := rel.typ.Kind() == reflect.Slice
+	if isSlice != (rel.kind == RelationHasMany) {
+		return VarTypeError(fmt.Sprintf("relation %q must be a slice only for %s relations", rel.name, RelationHasMany))
+	}
+
+	relType := rel.typ
+	if isSlice {
+		relType = relType.Elem()
+	}
+	isPtr := relType.Kind() == reflect.Ptr
+	if isPtr {
+		relType = relType.Elem()
+	}
+	if relType.Kind() != reflect.Struct {
+		return VarTypeError(fmt.Sprintf("relation %q must be a struct or a slice of structs", rel.name))
+	}
+
+	relSI := getStructInfo(relType, s.FieldMapper)
+
+	var localCol, relatedCol string
+	if rel.kind == RelationBelongsTo {
+		localCol, relatedCol = rel.key, rel.refKey
+		if relatedCol == "" {
+			relatedCol = pkColumn(relSI)
+		}
+	} else {
+		localCol, relatedCol = rel.refKey, rel.key
+		if localCol == "" {
+			localCol = pkColumn(si)
+		}
+	}
+
+	localField, ok := si.dbNameMap[localCol]
+	if !ok {
+		return fmt.Errorf("missing %q column for relation %q", localCol, rel.name)
+	}
+	relatedField, ok := relSI.dbNameMap[relatedCol]
+	if !ok {
+		return fmt.Errorf("missing %q column in the related model of relation %q", relatedCol, rel.name)
+	}
+
+	// collect the unique keys of the items
+	keys := []interface{}{}
+	seen := map[string]struct{}{}
+	for _, item := range items {
+		value := localField.getValue(item)
+		if k, ok := relationKey(value); ok {
+			if _, exists := seen[k]; !exists {
+				seen[k] = struct{}{}
+				keys = append(keys, value)
+			}
+		}
+	}
+
+	// load and group the related models by their key
+	related := reflect.New(reflect.SliceOf(relType))
+	if len(keys) > 0 {
+		q := s.builder.Select().
+			From(s.TableMapper(related.Interface())).
+			Where(In(relatedCol, keys...)).
+			WithContext(s.ctx).
+			With(nested...)
+		q.FieldMapper = s.FieldMapper
+		q.TableMapper = s.TableMapper
+		if err := q.All(related.Interface()); err != nil {
+			return err
+		}
+	}
+
+	grouped := map[string][]reflect.Value{}
+	relatedSlice := related.Elem()
+	for i := 0; i < relatedSlice.Len(); i++ {
+		r := relatedSlice.Index(i).Addr()
+		if k, ok := relationKey(relatedField.getValue(r.Elem())); ok {
+			grouped[k] = append(grouped[k], r)
+		}
+	}
+
+	// populate the relation fields
+	for _, item := range items {
+		var matches []reflect.Value
+		if k, ok := relationKey(localField.getValue(item)); ok {
+			matches = grouped[k]
+		}
+
+		field := relationField(item, rel.path)
+
+		if isSlice {
+			list := reflect.MakeSlice(rel.typ, 0, len(matches))
+			for _, m := range matches {
+				if isPtr {
+					list = reflect.Append(list, m)
+				} else {
+					list = reflect.Append(list, m.Elem())
+				}
+			}
+			field.Set(list)
+			continue
+		}
+
+		if len(matches) == 0 {
+			field.Set(reflect.Zero(rel.typ))
+		} else if isPtr {
+			field.Set(matches[0])
+		} else {
+			field.Set(matches[0].Elem())
+		}
+	}
+
+	return nil
+}
+
+// pkColumn returns the column name of the first primary key of the struct (if any).
+func pkColumn(si *structInfo) string {
+	if len(si.pkNames) == 0 {
+		return ""
+	}
+	return si.nameMap[si.pkNames[0]].dbName
+}
+
+// relationField returns the reflection value of the relation field for the given struct value.
+func relationField(a reflect.Value, path []int) reflect.Value {
+	i := 0
+	for ; i < len(path)-1; i++ {
+		a = indirect(a.Field(path[i]))
+	}
+	return a.Field(path[i])
+}
+
+// relationKey normalizes the provided relation column value
+// so that it could be used as a map key.
+//
+// It returns false if the value is nil.
+func relationKey(value interface{}) (string, bool) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", false
+		}
+		value = v
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case []byte:
+		return string(v), true
+	}
+
+	return fmt.Sprint(value), true
+}
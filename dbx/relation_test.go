@@ -0,0 +1,123 @@
+package dbx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type relOrderItem struct {
+	OrderID  int
+	ItemID   int
+	Quantity int
+}
+
+func (relOrderItem) TableName() string {
+	return "order_item"
+}
+
+type relOrder struct {
+	ID         int
+	CustomerID int
+	Total      int
+	Customer   *relCustomer   `rel:"belongsTo,customer_id"`
+	Items      []relOrderItem `rel:"hasMany,order_id"`
+}
+
+func (relOrder) TableName() string {
+	return "order"
+}
+
+type relCustomer struct {
+	ID         int
+	Email      string
+	Orders     []*relOrder `rel:"hasMany,customer_id"`
+	FirstOrder relOrder    `rel:"hasOne,customer_id,id"`
+}
+
+func (relCustomer) TableName() string {
+	return "customer"
+}
+
+func Test_parseRelTag(t *testing.T) {
+	scenarios := []struct {
+		tag      string
+		expected *relationInfo
+	}{
+		{"", nil},
+		{"hasMany", nil},
+		{"unknown,userId", nil},
+		{"hasMany,", nil},
+		{"hasMany,userId,id,extra", nil},
+		{"hasOne,userId", &relationInfo{kind: RelationHasOne, key: "userId"}},
+		{"hasMany, userId", &relationInfo{kind: RelationHasMany, key: "userId"}},
+		{"belongsTo,teamId,id", &relationInfo{kind: RelationBelongsTo, key: "teamId", refKey: "id"}},
+	}
+
+	for _, s := range scenarios {
+		rel, err := parseRelTag(s.tag)
+		assert.Equal(t, s.expected, rel, s.tag)
+		assert.Equal(t, s.expected == nil, err != nil, s.tag)
+	}
+}
+
+func TestStructInfo_invalidRelation(t *testing.T) {
+	type invalidRel struct {
+		ID     int
+		Orders []*relOrder `rel:"hasManyy,customer_id"`
+	}
+
+	assert.PanicsWithValue(t, `dbx: invalid rel tag of field invalidRel.Orders: unknown relation kind "hasManyy"`, func() {
+		getStructInfo(reflect.TypeOf(invalidRel{}), DefaultFieldMapFunc)
+	})
+}
+
+func TestStructInfo_relations(t *testing.T) {
+	si := getStructInfo(reflect.TypeOf(relCustomer{}), DefaultFieldMapFunc)
+
+	// relation fields are not columns
+	assert.Equal(t, 2, len(si.dbNameMap))
+	assert.NotContains(t, si.nameMap, "Orders")
+	assert.NotContains(t, si.nameMap, "FirstOrder")
+
+	if assert.Contains(t, si.relations, "Orders") {
+		assert.Equal(t, RelationHasMany, si.relations["Orders"].kind)
+		assert.Equal(t, "customer_id", si.relations["Orders"].key)
+		assert.Equal(t, []int{2}, si.relations["Orders"].path)
+	}
+
+	if assert.Contains(t, si.relations, "FirstOrder") {
+		assert.Equal(t, RelationHasOne, si.relations["FirstOrder"].kind)
+		assert.Equal(t, "id", si.relations["FirstOrder"].refKey)
+	}
+}
+
+func TestSelectQuery_With(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	// has many + nested has many
+	var customers []relCustomer
+	err := db.Select().OrderBy("id").With("Orders.Items", "FirstOrder").All(&customers)
+	if assert.Nil(t, err) && assert.Equal(t, 3, len(customers)) {
+		assert.Equal(t, 1, len(customers[0].Orders))
+		assert.Equal(t, 2, len(customers[1].Orders))
+		assert.Equal(t, 0, len(customers[2].Orders))
+		assert.NotNil(t, customers[2].Orders)
+		assert.Equal(t, 2, len(customers[0].Orders[0].Items))
+		assert.Equal(t, 1, customers[0].FirstOrder.ID)
+		assert.Equal(t, 0, customers[2].FirstOrder.ID)
+	}
+
+	// belongs to
+	var order relOrder
+	err = db.Select().Where(HashExp{"id": 2}).With("Customer").One(&order)
+	if assert.Nil(t, err) && assert.NotNil(t, order.Customer) {
+		assert.Equal(t, "user2@example.com", order.Customer.Email)
+	}
+
+	// unknown relation
+	err = db.Select().With("Missing").All(&customers)
+	assert.NotNil(t, err)
+}
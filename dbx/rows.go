@@ -5,6 +5,7 @@
 package dbx
 
 import (
+	"context"
 	"database/sql"
 	"reflect"
 )
@@ -21,11 +22,27 @@ func (s VarTypeError) Error() string {
 // The map keys correspond to the DB column names, while the map values are their corresponding column values.
 type NullStringMap map[string]sql.NullString
 
+// PostScanner is an optional interface used by ScanStruct.
+type PostScanner interface {
+	// PostScan executes right after the struct has been populated
+	// with the DB values, allowing you to further normalize or validate
+	// the loaded data.
+	PostScan() error
+}
+
+// Snapshotter is an optional interface used by ScanStruct.
+type Snapshotter interface {
+	// Snapshot receives the scanned row values (indexed by their DB column names)
+	// right before PostScan, allowing the struct to keep track of its original state.
+	Snapshot(values map[string]interface{})
+}
+
 // Rows enhances sql.Rows by providing additional data query methods.
 // Rows can be obtained by calling Query.Rows(). It is mainly used to populate data row by row.
 type Rows struct {
 	*sql.Rows
 	fieldMapFunc FieldMapFunc
+	ctx          context.Context
 }
 
 // ScanMap populates the current row of data into a NullStringMap.
@@ -79,13 +96,17 @@ func (r *Rows) ScanStruct(a interface{}) error {
 
 	for i, col := range cols {
 		if fi, ok := si.dbNameMap[col]; ok {
-			refs[i] = fi.getField(rv).Addr().Interface()
+			refs[i] = fi.scanTarget(rv)
 		} else {
 			refs[i] = &sql.NullString{}
 		}
 	}
 
-	return r.Scan(refs...)
+	if err := r.Scan(refs...); err != nil {
+		return err
+	}
+
+	return postScan(rv, si, cols)
 }
 
 // all populates all rows of query result into a slice of struct or NullStringMap.
@@ -136,7 +157,7 @@ func (r *Rows) all(slice interface{}) error {
 		refs := make([]interface{}, len(cols))
 		for i, col := range cols {
 			if fi, ok := si.dbNameMap[col]; ok {
-				refs[i] = fi.getField(ev).Addr().Interface()
+				refs[i] = fi.scanTarget(ev)
 			} else {
 				refs[i] = &sql.NullString{}
 			}
@@ -144,12 +165,37 @@ func (r *Rows) all(slice interface{}) error {
 		if err := r.Scan(refs...); err != nil {
 			return err
 		}
+		if err := postScan(ev, si, cols); err != nil {
+			return err
+		}
 		v.Set(reflect.Append(v, ev))
 	}
 
 	return r.Close()
 }
 
+// postScan invokes the Snapshotter and PostScanner hooks (if implemented)
+// of the provided freshly scanned struct value.
+func postScan(rv reflect.Value, si *structInfo, cols []string) error {
+	a := rv.Addr().Interface()
+
+	if v, ok := a.(Snapshotter); ok {
+		values := make(map[string]interface{}, len(cols))
+		for _, col := range cols {
+			if fi, ok := si.dbNameMap[col]; ok {
+				values[col] = fi.columnValue(rv)
+			}
+		}
+		v.Snapshot(values)
+	}
+
+	if v, ok := a.(PostScanner); ok {
+		return v.PostScan()
+	}
+
+	return nil
+}
+
 // column populates the given slice with the first column of the query result.
 // Note that the slice must be given as a pointer.
 func (r *Rows) column(slice interface{}) error {
@@ -6,8 +6,11 @@ package dbx
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
+	"time"
 )
 
 // SelectQuery represents a DB-agnostic SELECT query.
@@ -19,6 +22,7 @@ type SelectQuery struct {
 	TableMapper TableMapFunc
 
 	builder Builder
+	db      *DB
 	ctx     context.Context
 
 	selects      []string
@@ -34,6 +38,15 @@ type SelectQuery struct {
 	limit        int64
 	offset       int64
 	params       Params
+	with         []string
+	ctes         []CTEInfo
+	selectExps   []selectExpInfo
+	fromQueries  []subqueryInfo // subqueries in the order they were added to from
+	joinQueries  []subqueryInfo // subqueries in the order they were added to join
+	lock         LockInfo
+	relevance    *MatchExp
+	cacheTTL     time.Duration
+	cacheTags    []string
 }
 
 // JoinInfo contains the specification for a JOIN clause.
@@ -43,16 +56,50 @@ type JoinInfo struct {
 	On    Expression
 }
 
+// subqueryInfo contains a subquery and the position of its alias in the from or join list.
+type subqueryInfo struct {
+	pos   int
+	query *SelectQuery
+}
+
 // UnionInfo contains the specification for a UNION clause.
 type UnionInfo struct {
 	All   bool
 	Query *Query
 }
 
+// CTEInfo contains the specification for a common table expression of a WITH clause.
+type CTEInfo struct {
+	Name      string
+	Recursive bool
+	Query     *SelectQuery
+}
+
+// Supported row locking modes and options.
+const (
+	LockForUpdate  = "FOR UPDATE"
+	LockForShare   = "FOR SHARE"
+	LockSkipLocked = "SKIP LOCKED"
+	LockNoWait     = "NOWAIT"
+)
+
+// LockInfo contains the specification for the row locking clause of a SELECT query.
+type LockInfo struct {
+	Mode   string // LockForUpdate, LockForShare or empty for no locking
+	Option string // LockSkipLocked, LockNoWait or empty to wait for the locked rows
+}
+
+// selectExpInfo contains the specification for a selected expression.
+type selectExpInfo struct {
+	exp   Expression
+	alias string
+}
+
 // NewSelectQuery creates a new SelectQuery instance.
 func NewSelectQuery(builder Builder, db *DB) *SelectQuery {
 	return &SelectQuery{
 		builder:     builder,
+		db:          db,
 		selects:     []string{},
 		from:        []string{},
 		join:        []JoinInfo{},
@@ -82,6 +129,7 @@ func (q *SelectQuery) WithContext(ctx context.Context) *SelectQuery {
 // Column names will be automatically quoted.
 func (s *SelectQuery) Select(cols ...string) *SelectQuery {
 	s.selects = cols
+	s.selectExps = nil
 	return s
 }
 
@@ -92,6 +140,13 @@ func (s *SelectQuery) AndSelect(cols ...string) *SelectQuery {
 	return s
 }
 
+// AndSelectExp adds an additional expression (eg. a window function) to be selected with the specified alias.
+// The expression parameters are merged with the query parameters.
+func (s *SelectQuery) AndSelectExp(e Expression, alias string) *SelectQuery {
+	s.selectExps = append(s.selectExps, selectExpInfo{e, alias})
+	return s
+}
+
 // Distinct specifies whether to select columns distinctively.
 // By default, distinct is false.
 func (s *SelectQuery) Distinct(v bool) *SelectQuery {
@@ -105,10 +160,59 @@ func (s *SelectQuery) SelectOption(option string) *SelectQuery {
 	return s
 }
 
+// ForUpdate locks the selected rows for update until the end of the current transaction.
+//
+// The locking clause is rendered according to the current DB
+// (eg. "FOR UPDATE" or a "WITH (UPDLOCK, ROWLOCK)" table hint for SQL Server)
+// and it is ignored for SQLite, which locks the whole database instead.
+//
+// The locking clause cannot be combined with UNION, nor with LIMIT/OFFSET for Oracle,
+// in which case the built query fails with an error.
+func (s *SelectQuery) ForUpdate() *SelectQuery {
+	s.lock.Mode = LockForUpdate
+	return s
+}
+
+// ForShare locks the selected rows in shared mode until the end of the current transaction,
+// preventing other transactions from modifying them.
+//
+// It is ignored for Oracle and SQLite.
+func (s *SelectQuery) ForShare() *SelectQuery {
+	s.lock.Mode = LockForShare
+	return s
+}
+
+// SkipLocked skips the rows that are already locked by other transactions
+// instead of waiting for them (eg. when building a work queue).
+//
+// It has effect only together with ForUpdate() or ForShare().
+func (s *SelectQuery) SkipLocked() *SelectQuery {
+	s.lock.Option = LockSkipLocked
+	return s
+}
+
+// NoWait makes the query fail immediately instead of waiting
+// if any of the selected rows is locked by another transaction.
+//
+// It has effect only together with ForUpdate() or ForShare().
+func (s *SelectQuery) NoWait() *SelectQuery {
+	s.lock.Option = LockNoWait
+	return s
+}
+
 // From specifies which tables to select from.
 // Table names will be automatically quoted.
 func (s *SelectQuery) From(tables ...string) *SelectQuery {
 	s.from = tables
+	s.fromQueries = nil
+	return s
+}
+
+// FromSelect adds a subquery with the specified alias to the tables to select from.
+// The subquery parameters are merged with the query parameters.
+func (s *SelectQuery) FromSelect(q *SelectQuery, alias string) *SelectQuery {
+	s.fromQueries = append(s.fromQueries, subqueryInfo{len(s.from), q})
+	s.from = append(s.from, alias)
 	return s
 }
 
@@ -137,6 +241,14 @@ func (s *SelectQuery) Join(typ string, table string, on Expression) *SelectQuery
 	return s
 }
 
+// JoinSelect specifies a JOIN clause with a subquery as the join target.
+// The "typ" parameter specifies the JOIN type (e.g. "INNER JOIN", "LEFT JOIN").
+// The subquery parameters are merged with the query parameters.
+func (s *SelectQuery) JoinSelect(typ string, q *SelectQuery, alias string, on Expression) *SelectQuery {
+	s.joinQueries = append(s.joinQueries, subqueryInfo{len(s.join), q})
+	return s.Join(typ, alias, on)
+}
+
 // InnerJoin specifies an INNER JOIN clause.
 // This is a shortcut method for Join.
 func (s *SelectQuery) InnerJoin(table string, on Expression) *SelectQuery {
@@ -213,6 +325,21 @@ func (s *SelectQuery) UnionAll(q *Query) *SelectQuery {
 	return s
 }
 
+// WithQuery specifies a common table expression of the WITH clause that could be referred by name in the query.
+// The name may also contain the CTE column names, eg. "tree(id, parent_id)".
+// The subquery parameters are merged with the query parameters.
+func (s *SelectQuery) WithQuery(name string, q *SelectQuery) *SelectQuery {
+	s.ctes = append(s.ctes, CTEInfo{name, false, q})
+	return s
+}
+
+// WithRecursive specifies a recursive common table expression of the WITH clause.
+// The subquery is usually a UNION ALL of the anchor and the recursive query referring the CTE by name.
+func (s *SelectQuery) WithRecursive(name string, q *SelectQuery) *SelectQuery {
+	s.ctes = append(s.ctes, CTEInfo{name, true, q})
+	return s
+}
+
 // Limit specifies the LIMIT clause.
 // A negative limit means no limit.
 func (s *SelectQuery) Limit(limit int64) *SelectQuery {
@@ -246,7 +373,17 @@ func (s *SelectQuery) AndBind(params Params) *SelectQuery {
 }
 
 // Build builds the SELECT query and returns an executable Query object.
-func (s *SelectQuery) Build() *Query {
+//
+// If some of the query expressions cannot be built, the error
+// is set as the LastError of the returned query.
+func (s *SelectQuery) Build() (q *Query) {
+	defer func() {
+		if r := recover(); r != nil {
+			q = s.builder.NewQuery("")
+			q.LastError = recoverExpressionError(r)
+		}
+	}()
+
 	params := Params{}
 	for k, v := range s.params {
 		params[k] = v
@@ -254,13 +391,47 @@ func (s *SelectQuery) Build() *Query {
 
 	qb := s.builder.QueryBuilder()
 
+	selects := s.selects
+	if len(s.selectExps) > 0 {
+		selects = append(append([]string{}, s.selects...), s.buildSelectExps(params)...)
+	}
+
+	// the expressions of the query are built before merging the subqueries
+	// so that the subquery parameters are renamed on collision instead of
+	// being overwritten by the parameters of the outer query
+	join := s.join
+	if len(s.joinQueries) > 0 {
+		join = append([]JoinInfo{}, s.join...)
+		for i := range join {
+			if join[i].On != nil {
+				join[i].On = NewExp(join[i].On.Build(s.db, params))
+			}
+		}
+	}
+	where := qb.BuildWhere(s.where, params)
+	having := qb.BuildHaving(s.having, params)
+	orderBy := s.buildOrderBy(params)
+
+	from := s.from
+	if len(s.fromQueries) > 0 {
+		from = append([]string{}, s.from...)
+		for _, sub := range s.fromQueries {
+			from[sub.pos] = "(" + sub.query.buildSub(params) + ") " + from[sub.pos]
+		}
+	}
+	for _, sub := range s.joinQueries {
+		join[sub.pos].Table = "(" + sub.query.buildSub(params) + ") " + join[sub.pos].Table
+	}
+
+	fromClause, lockClause := qb.BuildLock(qb.BuildFrom(from), s.lock)
+
 	clauses := []string{
-		qb.BuildSelect(s.selects, s.distinct, s.selectOption),
-		qb.BuildFrom(s.from),
-		qb.BuildJoin(s.join, params),
-		qb.BuildWhere(s.where, params),
+		qb.BuildSelect(selects, s.distinct, s.selectOption),
+		fromClause,
+		qb.BuildJoin(join, params),
+		where,
 		qb.BuildGroupBy(s.groupBy),
-		qb.BuildHaving(s.having, params),
+		having,
 	}
 	sql := ""
 	for _, clause := range clauses {
@@ -272,12 +443,59 @@ func (s *SelectQuery) Build() *Query {
 			}
 		}
 	}
-	sql = qb.BuildOrderByAndLimit(sql, s.orderBy, s.limit, s.offset)
+	var lockErr error
+	limited := qb.BuildOrderByAndLimit(sql, orderBy, s.limit, s.offset)
+	if lockClause != "" {
+		if !strings.HasPrefix(limited, sql) {
+			// the query was wrapped for the pagination (eg. Oracle ROWNUM)
+			// and the locking clause is not allowed for the wrapper query
+			lockErr = errors.New("the row locking clause cannot be combined with the LIMIT/OFFSET of the current DB")
+		}
+		limited += " " + lockClause
+	}
+	sql = limited
 	if union := qb.BuildUnion(s.union, params); union != "" {
+		if lockClause != "" {
+			lockErr = errors.New("the row locking clause cannot be combined with UNION")
+		}
 		sql = fmt.Sprintf("(%v) %v", sql, union)
 	}
+	if with := qb.BuildWith(s.ctes, params); with != "" {
+		if strings.HasPrefix(sql, "WITH ") {
+			// the query is already wrapped in a WITH clause (eg. Oracle pagination)
+			// so the CTEs are prepended to its list to keep a single WITH clause
+			sql = with + ",\n\t" + sql[len("WITH "):]
+		} else {
+			sql = with + " " + sql
+		}
+	}
+
+	q = s.builder.NewQuery(sql).Bind(params)
+	if lockErr != nil {
+		q.LastError = lockErr
+	}
+
+	return q
+}
+
+// buildSub builds the query as a subquery of another query,
+// merging its parameters into the provided params of the outer query.
+func (s *SelectQuery) buildSub(params Params) string {
+	q := s.Build()
+	if q.LastError != nil {
+		// fail the outer query
+		panic(&expressionError{q.LastError})
+	}
+	return mergeParams(q.sql, q.params, params)
+}
 
-	return s.builder.NewQuery(sql).Bind(params)
+// buildSelectExps builds the selected expressions into columns with aliases.
+func (s *SelectQuery) buildSelectExps(params Params) []string {
+	cols := make([]string, 0, len(s.selectExps))
+	for _, se := range s.selectExps {
+		cols = append(cols, se.exp.Build(s.db, params)+" AS "+se.alias)
+	}
+	return cols
 }
 
 // One executes the SELECT query and populates the first row of the result into the specified variable.
@@ -287,13 +505,20 @@ func (s *SelectQuery) Build() *Query {
 // or the TableName() method if the variable implements the TableModel interface.
 //
 // Note that when the query has no rows in the result set, an sql.ErrNoRows will be returned.
+//
+// The relations specified with With() are eager loaded after the variable is populated.
 func (s *SelectQuery) One(a interface{}) error {
 	if len(s.from) == 0 {
 		if tableName := s.TableMapper(a); tableName != "" {
 			s.from = []string{tableName}
 		}
 	}
-	return s.Build().WithContext(s.ctx).One(a)
+	return s.withCache("One", a, func(q *Query) error {
+		if err := q.One(a); err != nil {
+			return err
+		}
+		return s.loadRelations(a)
+	})
 }
 
 // Model selects the row with the specified primary key and populates the model with the row data.
@@ -328,13 +553,20 @@ func (s *SelectQuery) Model(pk, model interface{}) error {
 // If the query does not specify a "from" clause, the method will try to infer the name of the table
 // to be selected from by calling getTableName() which will return either the type name of the slice elements
 // or the TableName() method if the slice element implements the TableModel interface.
+//
+// The relations specified with With() are eager loaded after the slice is populated.
 func (s *SelectQuery) All(slice interface{}) error {
 	if len(s.from) == 0 {
 		if tableName := s.TableMapper(slice); tableName != "" {
 			s.from = []string{tableName}
 		}
 	}
-	return s.Build().WithContext(s.ctx).All(slice)
+	return s.withCache("All", slice, func(q *Query) error {
+		if err := q.All(slice); err != nil {
+			return err
+		}
+		return s.loadRelations(slice)
+	})
 }
 
 // Rows builds and executes the SELECT query and returns a Rows object for data retrieval purpose.
@@ -353,5 +585,7 @@ func (s *SelectQuery) Row(a ...interface{}) error {
 // Note that the parameter must be a pointer to a slice.
 // This is a shortcut to SelectQuery.Build().Column()
 func (s *SelectQuery) Column(a interface{}) error {
-	return s.Build().WithContext(s.ctx).Column(a)
+	return s.withCache("Column", a, func(q *Query) error {
+		return q.Column(a)
+	})
 }
@@ -61,6 +61,87 @@ func TestSelectQuery(t *testing.T) {
 	assert.Equal(t, q.SQL(), expected, "t5")
 }
 
+func TestSelectQuery_CTEAndWindow(t *testing.T) {
+	db := getDB()
+
+	active := db.Select("id", "dept").From("users").Where(HashExp{"status": 1})
+
+	q := db.Select("id").
+		AndSelectExp(Over("RANK()").PartitionBy("dept").OrderBy("id DESC"), "rank").
+		WithQuery("active", active).
+		From("active").
+		Where(HashExp{"dept": 2}).
+		Build()
+
+	expected := "WITH `active` AS (SELECT `id`, `dept` FROM `users` WHERE `status`={:p0_1}) SELECT `id`, RANK() OVER (PARTITION BY `dept` ORDER BY `id` DESC) AS `rank` FROM `active` WHERE `dept`={:p0}"
+	assert.Equal(t, expected, q.SQL(), "t1")
+	assert.Equal(t, Params{"p0": 2, "p0_1": 1}, q.Params(), "t2")
+
+	// recursive
+	anchor := db.Select("id", "parent_id").From("nodes").Where(HashExp{"id": 1})
+	recursive := db.Select("n.id", "n.parent_id").From("nodes n").InnerJoin("tree t", NewExp("n.parent_id=t.id"))
+	q = db.Select().
+		WithRecursive("tree", anchor.UnionAll(recursive.Build())).
+		From("tree").
+		Build()
+
+	expected = "WITH RECURSIVE `tree` AS ((SELECT `id`, `parent_id` FROM `nodes` WHERE `id`={:p0}) UNION ALL (SELECT `n`.`id`, `n`.`parent_id` FROM `nodes` `n` INNER JOIN `tree` `t` ON n.parent_id=t.id)) SELECT * FROM `tree`"
+	assert.Equal(t, expected, q.SQL(), "t3")
+
+	// Select() resets the selected expressions
+	q = db.Select("id").AndSelectExp(Over("RANK()"), "rank").Select("name").From("users").Build()
+	assert.Equal(t, "SELECT `name` FROM `users`", q.SQL(), "t4")
+}
+
+func TestSelectQuery_Subquery(t *testing.T) {
+	db := getDB()
+
+	totals := db.Select("customer_id", "SUM(total) AS total").From("order").Where(NewExp("total>{:min}", Params{"min": 10})).GroupBy("customer_id")
+	active := db.Select("id").From("customer").Where(HashExp{"status": 1})
+
+	q := db.Select("c.email", "t.total").
+		From("customer c").
+		JoinSelect("INNER JOIN", totals, "t", NewExp("t.customer_id=c.id")).
+		Where(In("c.id", active)).
+		Build()
+
+	expected := "SELECT `c`.`email`, `t`.`total` FROM `customer` `c` INNER JOIN (SELECT `customer_id`, SUM(total) AS `total` FROM `order` WHERE total>{:min} GROUP BY `customer_id`) `t` ON t.customer_id=c.id WHERE `c`.`id` IN (SELECT `id` FROM `customer` WHERE `status`={:p0})"
+	assert.Equal(t, expected, q.SQL(), "t1")
+	assert.Equal(t, Params{"min": 10, "p0": 1}, q.Params(), "t2")
+
+	q = db.Select().FromSelect(active, "a").Where(HashExp{"id": 2}).Build()
+	expected = "SELECT * FROM (SELECT `id` FROM `customer` WHERE `status`={:p0_1}) `a` WHERE `id`={:p0}"
+	assert.Equal(t, expected, q.SQL(), "t3")
+	assert.Equal(t, Params{"p0": 2, "p0_1": 1}, q.Params(), "t4")
+
+	// the placeholders are generated after the merge
+	assert.Equal(t, "SELECT * FROM (SELECT `id` FROM `customer` WHERE `status`=?) `a` WHERE `id`=?", q.rawSQL, "t5")
+
+	// From resets the subqueries
+	q = db.Select().FromSelect(active, "a").From("users").Build()
+	assert.Equal(t, "SELECT * FROM `users`", q.SQL(), "t6")
+
+	// the outer parameters are not overwritten by the subquery parameters with the same name
+	sub := db.Select("id").From("order").Where(NewExp("customer_id={:id}", Params{"id": 1}))
+	q = db.Select().
+		FromSelect(sub, "o").
+		JoinSelect("INNER JOIN", sub, "o2", NewExp("o2.id=o.id AND o2.id<>{:id}", Params{"id": 2})).
+		Where(NewExp("o.id={:id}", Params{"id": 2})).
+		Build()
+	expected = "SELECT * FROM (SELECT `id` FROM `order` WHERE customer_id={:id_1}) `o` INNER JOIN (SELECT `id` FROM `order` WHERE customer_id={:id_2}) `o2` ON o2.id=o.id AND o2.id<>{:id} WHERE o.id={:id}"
+	assert.Equal(t, expected, q.SQL(), "t7")
+	assert.Equal(t, Params{"id": 2, "id_1": 1, "id_2": 1}, q.Params(), "t8")
+
+	// the renamed parameters are deterministic
+	multi := db.Select("id").From("order").Where(HashExp{"a": 1, "b": 2, "c": 3, "d": 4})
+	q = db.Select().FromSelect(multi, "m1").FromSelect(multi, "m2").Where(HashExp{"x": 5, "y": 6, "z": 7, "w": 8}).Build()
+	for i := 0; i < 10; i++ {
+		next := db.Select().FromSelect(multi, "m1").FromSelect(multi, "m2").Where(HashExp{"x": 5, "y": 6, "z": 7, "w": 8}).Build()
+		assert.Equal(t, q.SQL(), next.SQL(), "t9")
+		assert.Equal(t, q.Params(), next.Params(), "t10")
+	}
+}
+
 func TestSelectQuery_Data(t *testing.T) {
 	db := getPreparedDB()
 	defer db.Close()
@@ -0,0 +1,138 @@
+package dbx
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// DefaultStmtCacheCapacity is the default max number of prepared statements cached by the app DB.
+const DefaultStmtCacheCapacity = 100
+
+// StmtCache is a concurrent safe LRU cache of prepared statements keyed by their raw SQL.
+//
+// The cached statements are prepared on the DB connections pool and
+// re-prepared automatically by database/sql when a new connection is used.
+// Inside a transaction the cached statements are bound to the transaction
+// connection with sql.Tx.StmtContext().
+type StmtCache struct {
+	capacity int
+
+	mux   sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+}
+
+type stmtCacheItem struct {
+	sql  string
+	stmt *sql.Stmt
+}
+
+// NewStmtCache creates a new StmtCache instance that holds up to capacity statements.
+// The least recently used statement is closed when the capacity is exceeded.
+func NewStmtCache(capacity int) *StmtCache {
+	return &StmtCache{
+		capacity: capacity,
+		lru:      list.New(),
+		items:    map[string]*list.Element{},
+	}
+}
+
+// Get returns the cached prepared statement for the provided raw SQL.
+// If there is no such statement, a new one is prepared with db and cached.
+func (c *StmtCache) Get(ctx context.Context, db *sql.DB, rawSQL string) (*sql.Stmt, error) {
+	c.mux.Lock()
+	if elem, ok := c.items[rawSQL]; ok {
+		c.lru.MoveToFront(elem)
+		c.mux.Unlock()
+		return elem.Value.(*stmtCacheItem).stmt, nil
+	}
+	c.mux.Unlock()
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// prepare without holding the lock to not block the other statements
+	stmt, err := db.PrepareContext(ctx, rawSQL)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	// already prepared by another goroutine
+	if elem, ok := c.items[rawSQL]; ok {
+		stmt.Close()
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*stmtCacheItem).stmt, nil
+	}
+
+	c.items[rawSQL] = c.lru.PushFront(&stmtCacheItem{rawSQL, stmt})
+
+	for c.capacity > 0 && c.lru.Len() > c.capacity {
+		c.removeElement(c.lru.Back())
+	}
+
+	return stmt, nil
+}
+
+// Remove closes and removes the cached statement of the provided raw SQL (if any).
+func (c *StmtCache) Remove(rawSQL string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if elem, ok := c.items[rawSQL]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Len returns the number of the cached statements.
+func (c *StmtCache) Len() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.lru.Len()
+}
+
+// Clear closes and removes all cached statements.
+func (c *StmtCache) Clear() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for elem := c.lru.Front(); elem != nil; elem = c.lru.Front() {
+		c.removeElement(elem)
+	}
+}
+
+func (c *StmtCache) removeElement(elem *list.Element) {
+	item := c.lru.Remove(elem).(*stmtCacheItem)
+	delete(c.items, item.sql)
+	item.stmt.Close()
+}
+
+// isStaleStmtError checks whether the provided error is caused by an
+// invalidated prepared statement (eg. after a schema change or a connection reset),
+// which could be executed again without preparing.
+func isStaleStmtError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	msg := err.Error()
+
+	return strings.Contains(msg, "statement is closed") ||
+		// MySQL error 1615
+		strings.Contains(msg, "needs to be re-prepared") ||
+		// PostgreSQL after a schema change
+		strings.Contains(msg, "cached plan must not change result type")
+}
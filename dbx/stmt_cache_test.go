@@ -0,0 +1,91 @@
+package dbx
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isStaleStmtError(t *testing.T) {
+	scenarios := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("test"), false},
+		{driver.ErrBadConn, true},
+		{fmt.Errorf("wrapped: %w", driver.ErrBadConn), true},
+		{errors.New("sql: statement is closed"), true},
+		{errors.New("Error 1615: Prepared statement needs to be re-prepared"), true},
+		{errors.New("pq: cached plan must not change result type"), true},
+	}
+
+	for _, s := range scenarios {
+		assert.Equal(t, s.expected, isStaleStmtError(s.err), fmt.Sprint(s.err))
+	}
+}
+
+func TestStmtCache(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	c := NewStmtCache(2)
+
+	s1, err := c.Get(nil, db.sqlDB, "SELECT 1")
+	assert.Nil(t, err)
+	s1Again, _ := c.Get(nil, db.sqlDB, "SELECT 1")
+	assert.Equal(t, s1, s1Again, "the statement is reused")
+
+	c.Get(nil, db.sqlDB, "SELECT 2")
+	c.Get(nil, db.sqlDB, "SELECT 1") // mark as recently used
+	c.Get(nil, db.sqlDB, "SELECT 3") // evicts "SELECT 2"
+	assert.Equal(t, 2, c.Len())
+	assert.Contains(t, c.items, "SELECT 1")
+	assert.NotContains(t, c.items, "SELECT 2")
+
+	_, err = c.Get(nil, db.sqlDB, "SELECT * FROM missing")
+	assert.NotNil(t, err)
+
+	c.Remove("SELECT 1")
+	assert.Equal(t, 1, c.Len())
+
+	c.Clear()
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestQuery_stmtCache(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	db.StmtCache = NewStmtCache(10)
+
+	var email string
+	err := db.NewQuery("SELECT email FROM customer WHERE id={:id}").Bind(Params{"id": 2}).Row(&email)
+	assert.Nil(t, err)
+	assert.Equal(t, "user2@example.com", email)
+	assert.Equal(t, 1, db.StmtCache.Len())
+
+	// invalidated statement
+	stmt, _ := db.StmtCache.Get(nil, db.sqlDB, "SELECT email FROM customer WHERE id=?")
+	stmt.Close()
+	err = db.NewQuery("SELECT email FROM customer WHERE id={:id}").Bind(Params{"id": 3}).Row(&email)
+	assert.Nil(t, err)
+	assert.Equal(t, "user3@example.com", email)
+
+	// in transaction
+	err = db.Transactional(func(tx *Tx) error {
+		if _, err := tx.Update("customer", Params{"email": "tx@example.com"}, HashExp{"id": 2}).Execute(); err != nil {
+			return err
+		}
+		return tx.NewQuery("SELECT email FROM customer WHERE id={:id}").Bind(Params{"id": 2}).Row(&email)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "tx@example.com", email)
+	assert.Equal(t, 2, db.StmtCache.Len())
+
+	db.Close()
+	assert.Equal(t, 0, db.StmtCache.Len())
+}
@@ -6,6 +6,9 @@ package dbx
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"reflect"
 	"regexp"
 	"strings"
@@ -20,9 +23,11 @@ type (
 	TableMapFunc func(a interface{}) string
 
 	structInfo struct {
-		nameMap   map[string]*fieldInfo // mapping from struct field names to field infos
-		dbNameMap map[string]*fieldInfo // mapping from db column names to field infos
-		pkNames   []string              // struct field names representing PKs
+		nameMap   map[string]*fieldInfo    // mapping from struct field names to field infos
+		dbNameMap map[string]*fieldInfo    // mapping from db column names to field infos
+		pkNames   []string                 // struct field names representing PKs
+		relations map[string]*relationInfo // mapping from struct field names to relation infos
+		ignored   map[string]int           // mapping from ignored struct field names to their depth
 	}
 
 	structValue struct {
@@ -32,11 +37,20 @@ type (
 	}
 
 	fieldInfo struct {
+		tagOptions
 		name   string // field name
 		dbName string // db column name
 		path   []int  // index path to the struct field reflection
 	}
 
+	// tagOptions represents the options of a db struct tag, eg. `db:"name,readonly"`.
+	tagOptions struct {
+		pk        bool // the field is a primary key
+		readonly  bool // the field is only read from the DB and never inserted or updated
+		omitEmpty bool // the field is not inserted if it has a zero value
+		json      bool // the field is stored as a JSON encoded column
+	}
+
 	structInfoMapKey struct {
 		t reflect.Type
 		m reflect.Value
@@ -44,7 +58,8 @@ type (
 )
 
 var (
-	// DbTag is the name of the struct tag used to specify the column name for the associated struct field
+	// DbTag is the name of the struct tag used to specify the column name and the column options
+	// for the associated struct field, eg. `db:"name,readonly"` (see parseTag for the supported options).
 	DbTag = "db"
 
 	fieldRegex      = regexp.MustCompile(`([^A-Z_])([A-Z])`)
@@ -73,8 +88,10 @@ func getStructInfo(a reflect.Type, mapper FieldMapFunc) *structInfo {
 	si := &structInfo{
 		nameMap:   map[string]*fieldInfo{},
 		dbNameMap: map[string]*fieldInfo{},
+		relations: map[string]*relationInfo{},
+		ignored:   map[string]int{},
 	}
-	si.build(a, make([]int, 0), "", "", mapper)
+	si.build(a, make([]int, 0), "", "", tagOptions{}, mapper)
 	structInfoMap[key] = si
 
 	return si
@@ -93,25 +110,51 @@ func newStructValue(model interface{}, fieldMapFunc FieldMapFunc, tableMapFunc T
 	}
 }
 
+// StructColumns returns the field values of the provided struct model
+// (must be a pointer to a struct) indexed by their DB column names.
+//
+// It returns nil if the model is not a pointer to a struct.
+func StructColumns(model interface{}, fieldMapFunc FieldMapFunc) map[string]interface{} {
+	value := reflect.ValueOf(model)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	sv := &structValue{
+		structInfo: getStructInfo(value.Elem().Type(), fieldMapFunc),
+		value:      value.Elem(),
+	}
+
+	return sv.columns(nil, nil)
+}
+
 // pk returns the primary key values indexed by the corresponding primary key column names.
 func (s *structValue) pk() map[string]interface{} {
 	if len(s.pkNames) == 0 {
 		return nil
 	}
-	return s.columns(s.pkNames, nil)
+	v := make(map[string]interface{}, len(s.pkNames))
+	for _, name := range s.pkNames {
+		fi := s.nameMap[name]
+		v[fi.dbName] = fi.columnValue(s.value)
+	}
+	return v
 }
 
 // columns returns the struct field values indexed by their corresponding DB column names.
+// The readonly fields are skipped.
 func (s *structValue) columns(include, exclude []string) map[string]interface{} {
 	v := make(map[string]interface{}, len(s.nameMap))
 	if len(include) == 0 {
 		for _, fi := range s.nameMap {
-			v[fi.dbName] = fi.getValue(s.value)
+			if !fi.readonly {
+				v[fi.dbName] = fi.columnValue(s.value)
+			}
 		}
 	} else {
 		for _, attr := range include {
-			if fi, ok := s.nameMap[attr]; ok {
-				v[fi.dbName] = fi.getValue(s.value)
+			if fi, ok := s.nameMap[attr]; ok && !fi.readonly {
+				v[fi.dbName] = fi.columnValue(s.value)
 			}
 		}
 	}
@@ -125,6 +168,15 @@ func (s *structValue) columns(include, exclude []string) map[string]interface{}
 	return v
 }
 
+// omitEmpty removes the columns of the "omitempty" fields with zero values from cols.
+func (s *structValue) omitEmpty(cols map[string]interface{}) {
+	for _, fi := range s.nameMap {
+		if fi.omitEmpty && fi.isZero(s.value) {
+			delete(cols, fi.dbName)
+		}
+	}
+}
+
 // getValue returns the field value for the given struct value.
 func (fi *fieldInfo) getValue(a reflect.Value) interface{} {
 	for _, i := range fi.path {
@@ -139,6 +191,38 @@ func (fi *fieldInfo) getValue(a reflect.Value) interface{} {
 	return a.Interface()
 }
 
+// columnValue returns the field value for the given struct value as it should be written in the DB column.
+func (fi *fieldInfo) columnValue(a reflect.Value) interface{} {
+	v := fi.getValue(a)
+	if fi.json && v != nil {
+		return jsonColumnValue{v}
+	}
+	return v
+}
+
+// isZero checks whether the field of the given struct value is nil or has a zero value.
+func (fi *fieldInfo) isZero(a reflect.Value) bool {
+	for _, i := range fi.path {
+		a = a.Field(i)
+		if a.Kind() == reflect.Ptr {
+			if a.IsNil() {
+				return true
+			}
+			a = a.Elem()
+		}
+	}
+	return a.IsZero()
+}
+
+// scanTarget returns the destination of the field column value for Rows.Scan().
+func (fi *fieldInfo) scanTarget(a reflect.Value) interface{} {
+	field := fi.getField(a)
+	if fi.json {
+		return &jsonScanner{field}
+	}
+	return field.Addr().Interface()
+}
+
 // getField returns the reflection value of the field for the given struct value.
 func (fi *fieldInfo) getField(a reflect.Value) reflect.Value {
 	i := 0
@@ -148,14 +232,14 @@ func (fi *fieldInfo) getField(a reflect.Value) reflect.Value {
 	return a.Field(fi.path[i])
 }
 
-func (si *structInfo) build(a reflect.Type, path []int, namePrefix, dbNamePrefix string, mapper FieldMapFunc) {
+func (si *structInfo) build(a reflect.Type, path []int, namePrefix, dbNamePrefix string, inherited tagOptions, mapper FieldMapFunc) {
 	n := a.NumField()
 	for i := 0; i < n; i++ {
 		field := a.Field(i)
 		tag := field.Tag.Get(DbTag)
 
 		// only handle anonymous or exported fields
-		if !field.Anonymous && field.PkgPath != "" || tag == "-" {
+		if !field.Anonymous && field.PkgPath != "" {
 			continue
 		}
 
@@ -163,39 +247,72 @@ func (si *structInfo) build(a reflect.Type, path []int, namePrefix, dbNamePrefix
 		copy(path2, path)
 		path2 = append(path2, i)
 
+		// an ignored field also hides the same named fields of the embedded structs
+		if tag == "-" {
+			if !field.Anonymous {
+				si.ignore(concat(namePrefix, field.Name), len(path2))
+			}
+			continue
+		}
+
+		// relation fields are not mapped to columns
+		if relTag := field.Tag.Get(RelTag); relTag != "" {
+			rel, err := parseRelTag(relTag)
+			if err != nil {
+				// a malformed relation is a programming error (eg. a tag typo)
+				panic(fmt.Sprintf("dbx: invalid %v tag of field %v.%v: %v", RelTag, a.Name(), field.Name, err))
+			}
+			rel.name = concat(namePrefix, field.Name)
+			rel.path = path2
+			rel.typ = field.Type
+			si.relations[rel.name] = rel
+			continue
+		}
+
 		ft := field.Type
 		if ft.Kind() == reflect.Ptr {
 			ft = ft.Elem()
 		}
 
 		name := field.Name
-		dbName, isPK := parseTag(tag)
-		if dbName == "" && !field.Anonymous {
+		dbName, opts := parseTag(tag)
+
+		// the readonly and omitempty options of a struct field apply also to its nested fields
+		opts.readonly = opts.readonly || inherited.readonly
+		opts.omitEmpty = opts.omitEmpty || inherited.omitEmpty
+
+		// an anonymous JSON field is stored in a single column as the other struct fields
+		isNested := isNestedStruct(ft) && !opts.json
+		if dbName == "" && (!field.Anonymous || opts.json) {
 			if mapper != nil {
 				dbName = mapper(field.Name)
 			} else {
 				dbName = field.Name
 			}
 		}
-		if field.Anonymous {
+		if field.Anonymous && isNested {
 			name = ""
 		}
 
-		if isNestedStruct(ft) {
+		if isNested {
 			// dive into non-scanner struct
-			si.build(ft, path2, concat(namePrefix, name), concat(dbNamePrefix, dbName), mapper)
+			si.build(ft, path2, concat(namePrefix, name), concat(dbNamePrefix, dbName), opts, mapper)
 		} else if dbName != "" {
 			// non-anonymous scanner or struct field
 			fi := &fieldInfo{
-				name:   concat(namePrefix, name),
-				dbName: concat(dbNamePrefix, dbName),
-				path:   path2,
+				tagOptions: opts,
+				name:       concat(namePrefix, name),
+				dbName:     concat(dbNamePrefix, dbName),
+				path:       path2,
+			}
+			if depth, ok := si.ignored[fi.name]; ok && depth < len(path2) {
+				continue
 			}
 			// a field in an anonymous struct may be shadowed
 			if _, ok := si.nameMap[fi.name]; !ok || len(path2) < len(si.nameMap[fi.name].path) {
 				si.nameMap[fi.name] = fi
 				si.dbNameMap[fi.dbName] = fi
-				if isPK {
+				if opts.pk {
 					si.pkNames = append(si.pkNames, fi.name)
 				}
 			}
@@ -210,6 +327,31 @@ func (si *structInfo) build(a reflect.Type, path []int, namePrefix, dbNamePrefix
 	}
 }
 
+// ignore excludes the struct field with the provided name and
+// the same named fields of the embedded structs below depth.
+func (si *structInfo) ignore(name string, depth int) {
+	if d, ok := si.ignored[name]; ok && d <= depth {
+		return
+	}
+	si.ignored[name] = depth
+
+	fi, ok := si.nameMap[name]
+	if !ok || len(fi.path) <= depth {
+		return
+	}
+
+	delete(si.nameMap, name)
+	if si.dbNameMap[fi.dbName] == fi {
+		delete(si.dbNameMap, fi.dbName)
+	}
+	for i, pkName := range si.pkNames {
+		if pkName == name {
+			si.pkNames = append(si.pkNames[:i], si.pkNames[i+1:]...)
+			break
+		}
+	}
+}
+
 func isNestedStruct(t reflect.Type) bool {
 	if t.PkgPath() == "time" && t.Name() == "Time" {
 		return false
@@ -217,14 +359,55 @@ func isNestedStruct(t reflect.Type) bool {
 	return t.Kind() == reflect.Struct && !reflect.PtrTo(t).Implements(scannerType)
 }
 
-func parseTag(tag string) (string, bool) {
-	if tag == "pk" {
-		return "", true
+// parseTag parses a db struct tag in the "name,option1,option2" format
+// and returns the column name and the tag options.
+//
+// The supported options are:
+//   - pk: the field is a primary key
+//   - readonly: the field is only read from the DB (eg. a column with a default value or updated by a trigger)
+//   - omitempty: the field is not inserted if it has a zero value (so that the column default is used)
+//   - json: the field is marshalized into and unmarshalized from a JSON column
+//
+// The readonly and omitempty options of an embedded or nested struct field apply to all of its fields.
+// For backward compatibility the "pk,name" format is also supported.
+func parseTag(tag string) (string, tagOptions) {
+	opts := tagOptions{}
+
+	parts := strings.Split(tag, ",")
+	name := strings.TrimSpace(parts[0])
+	parts = parts[1:]
+
+	if name == "pk" {
+		opts.pk = true
+		name = ""
+		if len(parts) > 0 && !isTagOption(parts[0]) {
+			name = strings.TrimSpace(parts[0])
+			parts = parts[1:]
+		}
 	}
-	if strings.HasPrefix(tag, "pk,") {
-		return tag[3:], true
+
+	for _, opt := range parts {
+		switch strings.TrimSpace(opt) {
+		case "pk":
+			opts.pk = true
+		case "readonly":
+			opts.readonly = true
+		case "omitempty":
+			opts.omitEmpty = true
+		case "json":
+			opts.json = true
+		}
 	}
-	return tag, false
+
+	return name, opts
+}
+
+func isTagOption(opt string) bool {
+	switch strings.TrimSpace(opt) {
+	case "pk", "readonly", "omitempty", "json":
+		return true
+	}
+	return false
 }
 
 func concat(s1, s2 string) string {
@@ -237,6 +420,49 @@ func concat(s1, s2 string) string {
 	}
 }
 
+// jsonColumnValue wraps the value of a "json" tagged struct field
+// so that it is JSON encoded when written in the DB column.
+type jsonColumnValue struct {
+	v interface{}
+}
+
+// Value implements the [driver.Valuer] interface.
+func (j jsonColumnValue) Value() (driver.Value, error) {
+	encoded, err := json.Marshal(j.v)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// jsonScanner unmarshalizes a JSON DB column value into a "json" tagged struct field.
+type jsonScanner struct {
+	field reflect.Value
+}
+
+// Scan implements the [sql.Scanner] interface.
+func (j *jsonScanner) Scan(src interface{}) error {
+	j.field.Set(reflect.Zero(j.field.Type()))
+
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a JSON field", src)
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, j.field.Addr().Interface())
+}
+
 // indirect dereferences pointers and returns the actual value it points to.
 // If a pointer is nil, it will be initialized with a new value.
 func indirect(v reflect.Value) reflect.Value {
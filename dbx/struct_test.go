@@ -7,6 +7,7 @@ package dbx
 import (
 	"database/sql"
 	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -43,17 +44,29 @@ func Test_concat(t *testing.T) {
 }
 
 func Test_parseTag(t *testing.T) {
-	name, pk := parseTag("abc")
+	name, opts := parseTag("abc")
 	assert.Equal(t, "abc", name)
-	assert.False(t, pk)
+	assert.False(t, opts.pk)
 
-	name, pk = parseTag("pk,abc")
+	name, opts = parseTag("pk,abc")
 	assert.Equal(t, "abc", name)
-	assert.True(t, pk)
+	assert.True(t, opts.pk)
 
-	name, pk = parseTag("pk")
+	name, opts = parseTag("pk")
 	assert.Equal(t, "", name)
-	assert.True(t, pk)
+	assert.True(t, opts.pk)
+
+	name, opts = parseTag("pk,readonly")
+	assert.Equal(t, "", name)
+	assert.Equal(t, tagOptions{pk: true, readonly: true}, opts)
+
+	name, opts = parseTag("abc,pk,omitempty")
+	assert.Equal(t, "abc", name)
+	assert.Equal(t, tagOptions{pk: true, omitEmpty: true}, opts)
+
+	name, opts = parseTag(",json,readonly,unknown")
+	assert.Equal(t, "", name)
+	assert.Equal(t, tagOptions{readonly: true, json: true}, opts)
 }
 
 func Test_indirect(t *testing.T) {
@@ -92,6 +105,23 @@ func Test_structValue_columns(t *testing.T) {
 	assert.Equal(t, map[string]interface{}{"Name": "abc"}, cols)
 }
 
+func TestStructColumns(t *testing.T) {
+	customer := Customer{
+		ID:     1,
+		Name:   "abc",
+		Status: 2,
+		Email:  "abc@example.com",
+	}
+	cols := StructColumns(&customer, DefaultFieldMapFunc)
+	assert.Equal(t, map[string]interface{}{"id": 1, "name": "abc", "status": 2, "email": "abc@example.com", "address": sql.NullString{}}, cols)
+
+	assert.Nil(t, StructColumns(customer, DefaultFieldMapFunc))
+	assert.Nil(t, StructColumns((*Customer)(nil), DefaultFieldMapFunc))
+
+	var i int
+	assert.Nil(t, StructColumns(&i, DefaultFieldMapFunc))
+}
+
 func TestIssue37(t *testing.T) {
 	customer := Customer{
 		ID:     1,
@@ -116,6 +146,108 @@ func TestIssue37(t *testing.T) {
 	assert.Equal(t, map[string]interface{}{"ID": 1, "Status": "20"}, cols)
 }
 
+type tagOptionsMeta struct {
+	Views int    `db:"views,readonly"`
+	Note  string `db:"note,omitempty"`
+}
+
+type tagOptionsModel struct {
+	ID      int               `db:"pk,id"`
+	Name    string            `db:"name,omitempty"`
+	Created string            `db:"created,readonly"`
+	Tags    []string          `db:"tags,json"`
+	Extra   map[string]string `db:"extra,json"`
+	Meta    tagOptionsMeta    `db:"meta,omitempty"`
+	Secret  string            `db:"-"`
+}
+
+func TestStructTagOptions(t *testing.T) {
+	m := tagOptionsModel{
+		ID:      1,
+		Created: "2022-01-01",
+		Tags:    []string{"a", "b"},
+		Meta:    tagOptionsMeta{Views: 10},
+	}
+	sv := newStructValue(&m, DefaultFieldMapFunc, GetTableName)
+
+	assert.Equal(t, []string{"ID"}, sv.pkNames)
+	assert.Contains(t, sv.dbNameMap, "created", "t1")
+	assert.Contains(t, sv.dbNameMap, "meta.views", "t2")
+	assert.NotContains(t, sv.dbNameMap, "secret", "t3")
+	assert.NotContains(t, sv.dbNameMap, "tags.len", "t4")
+
+	cols := sv.columns(nil, nil)
+	assert.Equal(t, []string{"extra", "id", "meta.note", "name", "tags"}, sortedKeys(cols), "t5")
+	assert.Equal(t, jsonColumnValue{[]string{"a", "b"}}, cols["tags"], "t6")
+	assert.Equal(t, jsonColumnValue{map[string]string(nil)}, cols["extra"], "t7")
+
+	tags, err := cols["tags"].(jsonColumnValue).Value()
+	assert.Nil(t, err, "t8")
+	assert.Equal(t, `["a","b"]`, tags, "t8")
+
+	// readonly fields are not written even if explicitly included
+	cols = sv.columns([]string{"ID", "Created"}, nil)
+	assert.Equal(t, map[string]interface{}{"id": 1}, cols, "t9")
+
+	// the primary key is always available
+	assert.Equal(t, map[string]interface{}{"id": 1}, sv.pk(), "t10")
+
+	cols = sv.columns(nil, nil)
+	sv.omitEmpty(cols)
+	assert.Equal(t, []string{"extra", "id", "tags"}, sortedKeys(cols), "t11")
+
+	m.Name = "abc"
+	m.Meta.Note = "note"
+	cols = sv.columns(nil, nil)
+	sv.omitEmpty(cols)
+	assert.Equal(t, []string{"extra", "id", "meta.note", "name", "tags"}, sortedKeys(cols), "t12")
+}
+
+func TestStructTagIgnoredEmbeddedFields(t *testing.T) {
+	customer := Customer{ID: 1, Name: "abc", Status: 2}
+
+	ev := struct {
+		Customer
+		Status int `db:"-"`
+	}{Customer: customer}
+	sv := newStructValue(&ev, DefaultFieldMapFunc, GetTableName)
+	assert.NotContains(t, sv.columns(nil, nil), "status", "t1")
+	assert.NotContains(t, sv.dbNameMap, "status", "t1")
+
+	ev2 := struct {
+		Status int `db:"-"`
+		Customer
+	}{Customer: customer}
+	sv = newStructValue(&ev2, DefaultFieldMapFunc, GetTableName)
+	assert.NotContains(t, sv.columns(nil, nil), "status", "t2")
+	assert.NotContains(t, sv.dbNameMap, "status", "t2")
+
+	ev3 := struct {
+		Customer `db:"-"`
+		Name     string
+	}{Customer: customer, Name: "xyz"}
+	sv = newStructValue(&ev3, DefaultFieldMapFunc, GetTableName)
+	assert.Equal(t, map[string]interface{}{"name": "xyz"}, sv.columns(nil, nil), "t3")
+
+	ev4 := struct {
+		Customer `db:",readonly"`
+		Note     string
+	}{Customer: customer, Note: "xyz"}
+	sv = newStructValue(&ev4, DefaultFieldMapFunc, GetTableName)
+	assert.Equal(t, map[string]interface{}{"note": "xyz"}, sv.columns(nil, nil), "t4")
+	assert.Contains(t, sv.dbNameMap, "email", "t4")
+	assert.Equal(t, map[string]interface{}{"id": 1}, sv.pk(), "t4")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 type MyCustomer struct{}
 
 func TestGetTableName(t *testing.T) {
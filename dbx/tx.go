@@ -4,12 +4,18 @@
 
 package dbx
 
-import "database/sql"
+import (
+	"database/sql"
+	"fmt"
+)
 
 // Tx enhances sql.Tx with additional querying methods.
 type Tx struct {
 	Builder
 	tx *sql.Tx
+
+	// savepoints is used to generate unique savepoint names within the transaction
+	savepoints int
 }
 
 // Commit commits the transaction.
@@ -21,3 +27,37 @@ func (t *Tx) Commit() error {
 func (t *Tx) Rollback() error {
 	return t.tx.Rollback()
 }
+
+// Transactional executes the given function within a savepoint of the current transaction
+// (aka. nested transaction).
+// If the function returns an error, the transaction will be rolled back to the savepoint.
+// Otherwise, the savepoint will be released and its changes will be committed
+// together with the outer transaction.
+func (t *Tx) Transactional(f func(*Tx) error) (err error) {
+	t.savepoints++
+	name := fmt.Sprintf("dbx_sp_%d", t.savepoints)
+
+	if _, err := t.Savepoint(name).Execute(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			t.RollbackToSavepoint(name).Execute()
+			panic(p)
+		} else if err != nil {
+			if _, err2 := t.RollbackToSavepoint(name).Execute(); err2 != nil {
+				err = Errors{err, err2}
+			}
+		} else {
+			// some DBs don't support releasing savepoints
+			if q := t.ReleaseSavepoint(name); q != nil {
+				_, err = q.Execute()
+			}
+		}
+	}()
+
+	err = f(t)
+
+	return err
+}
@@ -0,0 +1,138 @@
+package forms
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/har4s/ohmygo/core"
+	"github.com/har4s/ohmygo/dao"
+	"github.com/har4s/ohmygo/model/settings"
+	"github.com/har4s/ohmygo/validation"
+)
+
+// SettingsUpsert is a [settings.Settings] upsert (create/update) form.
+type SettingsUpsert struct {
+	*settings.Settings
+
+	app core.App
+	dao *dao.Dao
+}
+
+// NewSettingsUpsert creates a new [SettingsUpsert] form with initializer
+// config created from the provided [core.App] instance.
+//
+// If you want to submit the form as part of a transaction,
+// you can change the default Dao via [SetDao()].
+func NewSettingsUpsert(app core.App) *SettingsUpsert {
+	form := &SettingsUpsert{
+		app: app,
+		dao: app.Dao(),
+	}
+
+	// load the application settings into the form
+	form.Settings, _ = app.Settings().Clone()
+
+	return form
+}
+
+// SetDao replaces the default form Dao instance with the provided one.
+func (form *SettingsUpsert) SetDao(dao *dao.Dao) {
+	form.dao = dao
+}
+
+// Validate makes the form validatable by implementing [validation.Validatable] interface.
+//
+// It also checks that the locked settings fields (see [core.App.LockedSettings()]) were not changed.
+func (form *SettingsUpsert) Validate() error {
+	if err := form.checkLockedFields(); err != nil {
+		return err
+	}
+
+	return form.Settings.Validate()
+}
+
+// Submit validates the form and upserts the loaded settings.
+//
+// The locked settings fields are persisted with their stored values,
+// so that the settings file and environment variables values never end up in the db.
+//
+// On success the app settings are refreshed with the form data.
+//
+// You can optionally provide a list of InterceptorFunc to further
+// modify the form behavior before persisting it.
+func (form *SettingsUpsert) Submit(interceptors ...InterceptorFunc) error {
+	if err := form.Validate(); err != nil {
+		return err
+	}
+
+	return runInterceptors(func() error {
+		newSettings, err := form.Settings.Clone()
+		if err != nil {
+			return err
+		}
+
+		if locked := form.app.LockedSettings(); len(locked) > 0 {
+			storedSettings, err := form.dao.FindSettings()
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			if storedSettings == nil {
+				storedSettings = settings.New()
+			}
+
+			if err := newSettings.ApplyOverrides(storedSettings.Values(locked...)); err != nil {
+				return err
+			}
+		}
+
+		if err := form.dao.SaveSettings(newSettings); err != nil {
+			return err
+		}
+
+		// reload app settings
+		return form.app.RefreshSettings()
+	}, interceptors...)
+}
+
+// checkLockedFields returns a validation error for each changed locked settings field.
+func (form *SettingsUpsert) checkLockedFields() error {
+	locked := form.app.LockedSettings()
+	if len(locked) == 0 {
+		return nil
+	}
+
+	current := form.app.Settings().Values(locked...)
+	submitted := form.Settings.Values(locked...)
+
+	errs := validation.Errors{}
+
+	for _, path := range locked {
+		if reflect.DeepEqual(current[path], submitted[path]) {
+			continue
+		}
+
+		// nest the error by the path segments, eg. {"smtp": {"host": err}}
+		segments := strings.Split(path, ".")
+		group := errs
+		for _, segment := range segments[:len(segments)-1] {
+			nested, ok := group[segment].(validation.Errors)
+			if !ok {
+				nested = validation.Errors{}
+				group[segment] = nested
+			}
+			group = nested
+		}
+		group[segments[len(segments)-1]] = validation.NewError(
+			"validation_locked_setting",
+			"The field is locked by the app settings file or environment variables.",
+		)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/fatih/color"
@@ -11,6 +13,7 @@ import (
 	"github.com/har4s/ohmygo/core"
 	"github.com/har4s/ohmygo/dbx"
 	"github.com/har4s/ohmygo/migrations"
+	"github.com/har4s/ohmygo/plugins/audit"
 	"github.com/har4s/ohmygo/tools/migrate"
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -57,8 +60,11 @@ func serve(app core.App, port int) {
 func main() {
 	env := NewEnv()
 	app := core.NewBaseApp(&core.BaseAppConfig{
-		IsDebug:     env.IsDebug,
-		DatabaseURL: env.DatabaseURL,
+		IsDebug:            env.IsDebug,
+		DatabaseURL:        env.DatabaseURL,
+		SlowQueryThreshold: env.SlowQueryThreshold,
+		EncryptionKey:      env.EncryptionKey,
+		SettingsFile:       env.SettingsFile,
 	})
 
 	if err := app.Bootstrap(); err != nil {
@@ -71,6 +77,13 @@ func main() {
 		}
 	}
 
+	if len(os.Args) > 1 {
+		if err := runCommand(app, os.Args[1:]); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
 	if err := app.RefreshSettings(); err != nil {
 		color.Yellow("=====================================")
 		color.Yellow("WARNING: Settings load error! \n%v", err)
@@ -78,6 +91,14 @@ func main() {
 		color.Yellow("=====================================")
 	}
 
+	// reload the settings changed by other app instances
+	if env.SettingsPollInterval > 0 {
+		go app.WatchSettings(context.Background(), env.SettingsPollInterval)
+	}
+
+	// record the model changes
+	audit.Register(app, nil)
+
 	// cmd := migrate.NewMigrateCmd(app)
 	// cmd.MigrateCreateHandler([]string{""}, true)
 	serve(app, env.Port)
@@ -1,29 +1,39 @@
 package migrations
 
 import (
-	"github.com/har4s/ohmygo/dao"
 	"github.com/har4s/ohmygo/dbx"
-	"github.com/har4s/ohmygo/model"
+	"github.com/har4s/ohmygo/tools/security"
+	"github.com/har4s/ohmygo/tools/types"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func init() {
 	Register(func(db dbx.Builder) error {
-		user := &model.User{
-			Email:        "admin@example.com",
-			IsAdmin:      true,
-			IsSuperadmin: true,
-		}
-		user.SetPassword("admin")
-		user.RefreshLastResetSentAt()
-
-		return dao.New(db).SaveUser(user)
-	}, func(db dbx.Builder) error {
-		d := dao.New(db)
-		user, err := d.FindUserByEmail("admin@example.com")
+		// the user is inserted with a raw query (instead of the User model)
+		// so that the migration doesn't depend on the current model fields
+		passwordHash, err := bcrypt.GenerateFromPassword([]byte("admin"), 13)
 		if err != nil {
 			return err
 		}
 
-		return d.DeleteUser(user)
+		now := types.NowDateTime()
+
+		_, err = db.Insert("users", dbx.Params{
+			"id":              security.RandomStringWithAlphabet(15, "abcdefghijklmnopqrstuvwxyz0123456789"),
+			"email":           "admin@example.com",
+			"tokenKey":        security.RandomString(50),
+			"passwordHash":    string(passwordHash),
+			"isAdmin":         true,
+			"isSuperadmin":    true,
+			"lastResetSentAt": now,
+			"created":         now,
+			"updated":         now,
+		}).Execute()
+
+		return err
+	}, func(db dbx.Builder) error {
+		_, err := db.Delete("users", dbx.HashExp{"email": "admin@example.com"}).Execute()
+
+		return err
 	})
 }
@@ -0,0 +1,39 @@
+package migrations
+
+import "github.com/har4s/ohmygo/dbx"
+
+func init() {
+	Register(func(db dbx.Builder) error {
+		_, tablesErr := db.NewQuery(`
+		CREATE TABLE {{audit_logs}} (
+			[[id]] VARCHAR(255) NOT NULL,
+			[[recordTable]] VARCHAR(255) NOT NULL,
+			[[recordId]] VARCHAR(255) NOT NULL,
+			[[action]] VARCHAR(50) NOT NULL,
+			[[actorId]] VARCHAR(255) NOT NULL DEFAULT '',
+			[[diff]] LONGTEXT NULL DEFAULT NULL,
+			[[prevHash]] VARCHAR(64) NOT NULL DEFAULT '',
+			[[hash]] VARCHAR(64) NOT NULL,
+			[[created]] TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+			[[updated]] TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+			PRIMARY KEY ([[id]]),
+			UNIQUE KEY ([[hash]]),
+			UNIQUE KEY ([[prevHash]]),
+			KEY ([[recordTable]], [[recordId]]),
+			KEY ([[actorId]]),
+			KEY ([[created]])
+		);
+		`).Execute()
+		if tablesErr != nil {
+			return tablesErr
+		}
+
+		return nil
+	}, func(db dbx.Builder) error {
+		if _, err := db.DropTable("audit_logs").Execute(); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,15 @@
+package migrations
+
+import "github.com/har4s/ohmygo/dbx"
+
+func init() {
+	Register(func(db dbx.Builder) error {
+		_, err := db.AddColumn("params", "version", "BIGINT NOT NULL DEFAULT 0").Execute()
+
+		return err
+	}, func(db dbx.Builder) error {
+		_, err := db.DropColumn("params", "version").Execute()
+
+		return err
+	})
+}
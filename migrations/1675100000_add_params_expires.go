@@ -0,0 +1,15 @@
+package migrations
+
+import "github.com/har4s/ohmygo/dbx"
+
+func init() {
+	Register(func(db dbx.Builder) error {
+		_, err := db.AddColumn("params", "expires", "TIMESTAMP NULL DEFAULT NULL").Execute()
+
+		return err
+	}, func(db dbx.Builder) error {
+		_, err := db.DropColumn("params", "expires").Execute()
+
+		return err
+	})
+}
@@ -0,0 +1,151 @@
+package migrations
+
+import (
+	"github.com/har4s/ohmygo/dbx"
+	"github.com/har4s/ohmygo/tools/security"
+	"github.com/har4s/ohmygo/tools/types"
+)
+
+func init() {
+	Register(func(db dbx.Builder) error {
+		_, tablesErr := db.NewQuery(`
+		CREATE TABLE {{roles}} (
+			[[id]] VARCHAR(255) NOT NULL,
+			[[name]] VARCHAR(255) NOT NULL,
+			[[permissions]] TEXT NULL DEFAULT NULL,
+			[[isSystem]] BOOLEAN NOT NULL DEFAULT FALSE,
+			[[created]] TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			[[updated]] TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY ([[id]]),
+			UNIQUE KEY ([[name]])
+		);
+		`).Execute()
+		if tablesErr != nil {
+			return tablesErr
+		}
+
+		_, tablesErr = db.NewQuery(`
+		CREATE TABLE {{user_roles}} (
+			[[id]] VARCHAR(255) NOT NULL,
+			[[userId]] VARCHAR(255) NOT NULL,
+			[[roleId]] VARCHAR(255) NOT NULL,
+			[[created]] TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			[[updated]] TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY ([[id]]),
+			UNIQUE KEY ([[userId]], [[roleId]]),
+			KEY ([[roleId]])
+		);
+		`).Execute()
+		if tablesErr != nil {
+			return tablesErr
+		}
+
+		// the roles are inserted with raw queries (instead of the Role models)
+		// so that the migration doesn't depend on the current model fields
+		newId := func() string {
+			return security.RandomStringWithAlphabet(15, "abcdefghijklmnopqrstuvwxyz0123456789")
+		}
+
+		now := types.NowDateTime()
+
+		// built-in roles
+		roleIds := map[string]string{
+			"superadmin": newId(),
+			"admin":      newId(),
+		}
+		rolePermissions := map[string]string{
+			"superadmin": `["*"]`,
+			"admin":      `["settings.*","metrics.*","audit_logs.*","users.*"]`,
+		}
+		for _, name := range []string{"superadmin", "admin"} {
+			_, err := db.Insert("roles", dbx.Params{
+				"id":          roleIds[name],
+				"name":        name,
+				"permissions": rolePermissions[name],
+				"isSystem":    true,
+				"created":     now,
+				"updated":     now,
+			}).Execute()
+			if err != nil {
+				return err
+			}
+		}
+
+		// migrate the users admin flags to the built-in roles
+		flags := []struct {
+			Id           string `db:"id"`
+			IsAdmin      bool   `db:"isAdmin"`
+			IsSuperadmin bool   `db:"isSuperadmin"`
+		}{}
+		err := db.Select("id", "isAdmin", "isSuperadmin").
+			From("users").
+			Where(dbx.Or(dbx.HashExp{"isAdmin": true}, dbx.HashExp{"isSuperadmin": true})).
+			All(&flags)
+		if err != nil {
+			return err
+		}
+
+		for _, f := range flags {
+			roles := []string{}
+			if f.IsAdmin {
+				roles = append(roles, "admin")
+			}
+			if f.IsSuperadmin {
+				roles = append(roles, "superadmin")
+			}
+
+			for _, name := range roles {
+				_, err := db.Insert("user_roles", dbx.Params{
+					"id":      newId(),
+					"userId":  f.Id,
+					"roleId":  roleIds[name],
+					"created": now,
+					"updated": now,
+				}).Execute()
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if _, err := db.DropColumn("users", "isAdmin").Execute(); err != nil {
+			return err
+		}
+
+		_, err = db.DropColumn("users", "isSuperadmin").Execute()
+
+		return err
+	}, func(db dbx.Builder) error {
+		columns := []string{"isAdmin", "isSuperadmin"}
+		for _, column := range columns {
+			if _, err := db.AddColumn("users", column, "BOOLEAN NOT NULL DEFAULT FALSE").Execute(); err != nil {
+				return err
+			}
+		}
+
+		// restore the users admin flags from the built-in roles
+		for i, roleName := range []string{"admin", "superadmin"} {
+			_, err := db.NewQuery(`
+				UPDATE {{users}} SET [[` + columns[i] + `]] = TRUE
+				WHERE [[id]] IN (
+					SELECT [[user_roles.userId]] FROM {{user_roles}}
+					INNER JOIN {{roles}} ON [[roles.id]] = [[user_roles.roleId]]
+					WHERE [[roles.name]] = {:name}
+				)
+			`).Bind(dbx.Params{"name": roleName}).Execute()
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := db.DropTable("user_roles").Execute(); err != nil {
+			return err
+		}
+
+		if _, err := db.DropTable("roles").Execute(); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
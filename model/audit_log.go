@@ -0,0 +1,75 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/har4s/ohmygo/tools/types"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// Auditable defines an optional interface that models could implement
+// to explicitly opt-in or opt-out from the audit log.
+type Auditable interface {
+	// IsAuditable reports whether the model changes should be recorded.
+	IsAuditable() bool
+}
+
+// AuditLog defines a single record of the tamper-evident model changes history.
+//
+// Each log is chained with the previous one by including its hash
+// in the calculation of the current log hash.
+type AuditLog struct {
+	BaseModel
+
+	RecordTable string        `db:"recordTable" json:"recordTable"`
+	RecordId    string        `db:"recordId" json:"recordId"`
+	Action      string        `db:"action" json:"action"`
+	ActorId     string        `db:"actorId" json:"actorId"`
+	Diff        types.JsonRaw `db:"diff" json:"diff"`
+	PrevHash    string        `db:"prevHash" json:"prevHash"`
+	Hash        string        `db:"hash" json:"hash"`
+}
+
+// TableName returns the AuditLog model SQL table name.
+func (m *AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// IsAuditable implements the [Auditable] interface and always
+// returns false to prevent recursively auditing the audit logs.
+func (m *AuditLog) IsAuditable() bool {
+	return false
+}
+
+// CalculateHash returns the sha256 hex hash of the log data
+// (including its id, created date and the previous log hash).
+func (m *AuditLog) CalculateHash() string {
+	data, _ := json.Marshal([]string{
+		m.Id,
+		m.Created.String(),
+		m.RecordTable,
+		m.RecordId,
+		m.Action,
+		m.ActorId,
+		m.Diff.String(),
+		m.PrevHash,
+	})
+
+	h := sha256.New()
+	h.Write(data)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RefreshHash updates the log Hash field with the
+// current result of CalculateHash.
+func (m *AuditLog) RefreshHash() {
+	m.Hash = m.CalculateHash()
+}
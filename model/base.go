@@ -1,6 +1,10 @@
 package model
 
 import (
+	"database/sql/driver"
+	"reflect"
+
+	"github.com/har4s/ohmygo/dbx"
 	"github.com/har4s/ohmygo/tools/security"
 	"github.com/har4s/ohmygo/tools/types"
 )
@@ -25,6 +29,33 @@ type FilesManager interface {
 	BaseFilesPath() string
 }
 
+// ChangesTracker defines an interface for models that keep track
+// of their original db column values (aka. dirty checking).
+type ChangesTracker interface {
+	// Snapshot stores the provided column values as the model original state
+	// (a nil values map clears the snapshot).
+	Snapshot(values map[string]any)
+
+	// Original returns the original state snapshot (nil if not tracked).
+	Original() map[string]any
+
+	// IsTracked checks whether the model has an original state snapshot.
+	IsTracked() bool
+
+	// DetectChanges compares the provided column values with the
+	// original snapshot and stores and returns only the changed ones.
+	DetectChanges(values map[string]any) map[string]any
+
+	// IsDirty checks whether the specified db column was changed.
+	IsDirty(column string) bool
+
+	// Changes returns the last detected changed column values.
+	Changes() map[string]any
+
+	// Previous returns the original values of the last detected changed columns.
+	Previous() map[string]any
+}
+
 // Model defines an interface with common methods that all db models should have.
 type Model interface {
 	TableName() string
@@ -48,6 +79,9 @@ type Model interface {
 // BaseModel defines common fields and methods used by all other models.
 type BaseModel struct {
 	isNotNew bool
+	original map[string]any
+	changes  map[string]any
+	previous map[string]any
 
 	Id      string         `db:"id" json:"id"`
 	Created types.DateTime `db:"created" json:"created"`
@@ -119,3 +153,125 @@ func (m *BaseModel) PostScan() error {
 	m.MarkAsNotNew()
 	return nil
 }
+
+// Snapshot implements the [dbx.Snapshotter] interface.
+//
+// It stores the provided column values as the model original state,
+// which is used later by DetectChanges to find the dirty columns.
+//
+// The last detected changes are preserved so that they remain
+// accessible after persisting the model (eg. in the after hooks).
+//
+// A nil values map clears the snapshot (aka. the model is no longer tracked).
+func (m *BaseModel) Snapshot(values map[string]any) {
+	if values == nil {
+		m.original = nil
+		return
+	}
+
+	m.original = make(map[string]any, len(values))
+	for k, v := range values {
+		m.original[k] = NormalizeColumnValue(v)
+	}
+}
+
+// Original returns a shallow copy of the model original state snapshot
+// or nil if the model is not tracked.
+func (m *BaseModel) Original() map[string]any {
+	if m.original == nil {
+		return nil
+	}
+
+	result := make(map[string]any, len(m.original))
+	for k, v := range m.original {
+		result[k] = v
+	}
+	return result
+}
+
+// IsTracked checks whether the model has an original state snapshot
+// (aka. it was loaded from or already persisted in the db).
+func (m *BaseModel) IsTracked() bool {
+	return m.original != nil
+}
+
+// DetectChanges compares the provided column values with the
+// original snapshot and stores and returns only the changed ones.
+//
+// If the model doesn't have a snapshot, all values are considered changed.
+func (m *BaseModel) DetectChanges(values map[string]any) map[string]any {
+	changes := make(map[string]any, len(values))
+	previous := make(map[string]any, len(values))
+
+	for k, v := range values {
+		original, ok := m.original[k]
+		if !ok || !reflect.DeepEqual(original, NormalizeColumnValue(v)) {
+			changes[k] = v
+			previous[k] = original
+		}
+	}
+
+	m.changes = changes
+	m.previous = previous
+
+	return changes
+}
+
+// IsDirty checks whether the specified db column was changed.
+//
+// The changes are refreshed by the Dao right before triggering the
+// model update hooks, so it is safe to use it in OnModelBeforeUpdate handlers.
+func (m *BaseModel) IsDirty(column string) bool {
+	_, ok := m.changes[column]
+	return ok
+}
+
+// Changes returns a shallow copy of the last detected changed column values.
+func (m *BaseModel) Changes() map[string]any {
+	result := make(map[string]any, len(m.changes))
+	for k, v := range m.changes {
+		result[k] = v
+	}
+	return result
+}
+
+// Previous returns a shallow copy of the original (normalized) values
+// of the last detected changed columns.
+//
+// Columns that were not part of the original snapshot have nil value.
+func (m *BaseModel) Previous() map[string]any {
+	result := make(map[string]any, len(m.previous))
+	for k, v := range m.previous {
+		result[k] = v
+	}
+	return result
+}
+
+// ColumnValues returns the db column values of the provided model
+// (see also [ColumnValueMapper]).
+func ColumnValues(m Model) map[string]any {
+	if v, ok := any(m).(ColumnValueMapper); ok {
+		return v.ColumnValueMap()
+	}
+
+	return dbx.StructColumns(m, dbx.DefaultFieldMapFunc)
+}
+
+// NormalizeColumnValue converts the provided column value to its
+// db driver representation so that it could be safely compared or serialized.
+func NormalizeColumnValue(value any) any {
+	if v, ok := value.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil
+		}
+		if normalized, err := v.Value(); err == nil {
+			value = normalized
+		}
+	}
+
+	if v, ok := value.([]byte); ok {
+		return string(v)
+	}
+
+	return value
+}
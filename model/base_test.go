@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/har4s/ohmygo/model"
+	"github.com/har4s/ohmygo/tools/types"
 )
 
 func TestBaseModelHasId(t *testing.T) {
@@ -122,3 +123,106 @@ func TestBaseModelUpdated(t *testing.T) {
 		t.Fatalf("Expected non-zero datetime, got %v", m.GetUpdated())
 	}
 }
+
+func TestBaseModelChangesTracking(t *testing.T) {
+	m := model.BaseModel{}
+
+	if m.IsTracked() {
+		t.Fatal("Expected the model to not be tracked")
+	}
+
+	// without snapshot all values should be considered changed
+	changes := m.DetectChanges(map[string]any{"a": 1, "b": "test"})
+	if len(changes) != 2 || !m.IsDirty("a") || !m.IsDirty("b") {
+		t.Fatalf("Expected all columns to be dirty, got %v", changes)
+	}
+
+	m.Snapshot(map[string]any{
+		"a": 1,
+		"b": "test",
+		"c": types.JsonRaw(`{"test":123}`),
+		"d": types.DateTime{},
+	})
+
+	if !m.IsTracked() {
+		t.Fatal("Expected the model to be tracked")
+	}
+
+	if len(m.Changes()) != 2 {
+		t.Fatalf("Expected the last detected changes to be preserved after snapshot, got %v", m.Changes())
+	}
+
+	scenarios := []struct {
+		values   map[string]any
+		expected []string
+	}{
+		{
+			map[string]any{"a": 1, "b": "test", "c": types.JsonRaw(`{"test":123}`), "d": types.DateTime{}},
+			[]string{},
+		},
+		{
+			map[string]any{"a": 2, "b": "test", "c": []byte(`{"test":123}`), "d": types.DateTime{}},
+			[]string{"a"},
+		},
+		{
+			map[string]any{"a": 1, "b": "test2", "c": types.JsonRaw(`{"test":456}`), "d": types.NowDateTime()},
+			[]string{"b", "c", "d"},
+		},
+		{
+			map[string]any{"e": nil},
+			[]string{"e"},
+		},
+	}
+
+	m.DetectChanges(map[string]any{"a": 2, "b": "test"})
+	if v := m.Previous()["a"]; v != 1 {
+		t.Fatalf("Expected previous value 1, got %v", v)
+	}
+
+	for i, s := range scenarios {
+		changes := m.DetectChanges(s.values)
+
+		if len(changes) != len(s.expected) {
+			t.Errorf("(%d) Expected %d changes, got %v", i, len(s.expected), changes)
+			continue
+		}
+
+		for _, col := range s.expected {
+			if !m.IsDirty(col) {
+				t.Errorf("(%d) Expected column %q to be dirty", i, col)
+			}
+			if _, ok := m.Changes()[col]; !ok {
+				t.Errorf("(%d) Expected column %q to be in %v", i, col, m.Changes())
+			}
+			if _, ok := m.Previous()[col]; !ok {
+				t.Errorf("(%d) Expected column %q to be in %v", i, col, m.Previous())
+			}
+		}
+	}
+}
+
+func TestBaseModelOriginal(t *testing.T) {
+	m := model.BaseModel{}
+
+	if m.Original() != nil {
+		t.Fatalf("Expected nil original, got %v", m.Original())
+	}
+
+	m.Snapshot(map[string]any{"a": 1, "b": []byte("test")})
+
+	original := m.Original()
+	if len(original) != 2 || original["a"] != 1 || original["b"] != "test" {
+		t.Fatalf("Expected the normalized snapshot values, got %v", original)
+	}
+
+	// the returned map is a copy
+	original["a"] = 2
+	if m.Original()["a"] != 1 {
+		t.Fatalf("Expected the snapshot to not be modified, got %v", m.Original())
+	}
+
+	m.Snapshot(nil)
+	if m.IsTracked() || m.Original() != nil {
+		t.Fatalf("Expected the snapshot to be cleared, got %v", m.Original())
+	}
+}
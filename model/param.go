@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"github.com/har4s/ohmygo/tools/types"
 )
 
@@ -13,8 +15,21 @@ type Param struct {
 
 	Key   string        `db:"key" json:"key"`
 	Value types.JsonRaw `db:"value" json:"value"`
+
+	// Version is incremented by the db on each param save
+	// (eg. to detect changes made by other app instances).
+	Version int64 `db:"version,readonly" json:"version"`
+
+	// Expires is the optional expiration date of the param value
+	// (nil, aka. NULL in the db, for params that never expire).
+	Expires *types.DateTime `db:"expires" json:"expires"`
 }
 
 func (m *Param) TableName() string {
 	return "params"
 }
+
+// IsExpired checks whether the param value has expired.
+func (m *Param) IsExpired() bool {
+	return m.Expires != nil && !m.Expires.IsZero() && !m.Expires.Time().After(time.Now())
+}
@@ -0,0 +1,40 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/har4s/ohmygo/model"
+	"github.com/har4s/ohmygo/tools/types"
+)
+
+func TestParamTableName(t *testing.T) {
+	m := model.Param{}
+	if m.TableName() != "params" {
+		t.Fatalf("Unexpected table name, got %q", m.TableName())
+	}
+}
+
+func TestParamIsExpired(t *testing.T) {
+	past, _ := types.ParseDateTime(time.Now().Add(-1 * time.Minute))
+	future, _ := types.ParseDateTime(time.Now().Add(1 * time.Minute))
+
+	scenarios := []struct {
+		expires  *types.DateTime
+		expected bool
+	}{
+		{nil, false},
+		{&types.DateTime{}, false},
+		{&past, true},
+		{&future, false},
+	}
+
+	for i, s := range scenarios {
+		m := model.Param{Expires: s.expires}
+
+		result := m.IsExpired()
+		if result != s.expected {
+			t.Errorf("(%d) Expected %v, got %v", i, s.expected, result)
+		}
+	}
+}
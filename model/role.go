@@ -0,0 +1,85 @@
+package model
+
+import "strings"
+
+// Built-in role names.
+const (
+	RoleAdmin      = "admin"
+	RoleSuperadmin = "superadmin"
+)
+
+// Common permissions in the "resource.action" format.
+//
+// A "resource.*" permission grants all actions of the resource
+// and the "*" permission grants everything.
+const (
+	PermissionAll            = "*"
+	PermissionSettingsView   = "settings.view"
+	PermissionSettingsUpdate = "settings.update"
+	PermissionMetricsView    = "metrics.view"
+	PermissionAuditLogsView  = "audit_logs.view"
+	PermissionUsersView      = "users.view"
+	PermissionUsersCreate    = "users.create"
+	PermissionUsersUpdate    = "users.update"
+	PermissionUsersDelete    = "users.delete"
+)
+
+// Role defines a named set of permissions that could be assigned to users.
+type Role struct {
+	BaseModel
+
+	Name        string   `db:"name" json:"name"`
+	Permissions []string `db:"permissions,json" json:"permissions"`
+
+	// IsSystem marks the built-in roles that cannot be deleted.
+	IsSystem bool `db:"isSystem" json:"isSystem"`
+}
+
+// TableName returns the Role model SQL table name.
+func (m *Role) TableName() string {
+	return "roles"
+}
+
+// HasPermission checks whether the role grants the provided permission
+// (either explicitly or with a "*" or "resource.*" wildcard).
+func (m *Role) HasPermission(permission string) bool {
+	for _, p := range m.Permissions {
+		if MatchPermission(p, permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchPermission checks whether the granted permission pattern
+// matches the required permission, eg.:
+//
+//	MatchPermission("users.*", "users.delete") // true
+//	MatchPermission("users.view", "users.delete") // false
+func MatchPermission(granted string, required string) bool {
+	if granted == PermissionAll || granted == required {
+		return true
+	}
+
+	if strings.HasSuffix(granted, "*") {
+		return strings.HasPrefix(required, strings.TrimSuffix(granted, "*"))
+	}
+
+	return false
+}
+
+// -------------------------------------------------------------------
+
+// UserRole defines a single user-role assignment.
+type UserRole struct {
+	BaseModel
+
+	UserId string `db:"userId" json:"userId"`
+	RoleId string `db:"roleId" json:"roleId"`
+}
+
+// TableName returns the UserRole model SQL table name.
+func (m *UserRole) TableName() string {
+	return "user_roles"
+}
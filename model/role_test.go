@@ -0,0 +1,85 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/har4s/ohmygo/model"
+)
+
+func TestMatchPermission(t *testing.T) {
+	scenarios := []struct {
+		granted  string
+		required string
+		expected bool
+	}{
+		{"", "users.delete", false},
+		{"users.view", "users.delete", false},
+		{"users.delete", "users.delete", true},
+		{"users.*", "users.delete", true},
+		{"users.*", "users", false},
+		{"users.*", "settings.view", false},
+		{"*", "users.delete", true},
+		{"*", "", true},
+	}
+
+	for i, s := range scenarios {
+		result := model.MatchPermission(s.granted, s.required)
+		if result != s.expected {
+			t.Errorf("(%d) Expected %v, got %v", i, s.expected, result)
+		}
+	}
+}
+
+func TestRoleHasPermission(t *testing.T) {
+	role := &model.Role{Permissions: []string{"settings.view", "users.*"}}
+
+	scenarios := []struct {
+		permission string
+		expected   bool
+	}{
+		{"settings.view", true},
+		{"settings.update", false},
+		{"users.create", true},
+		{"metrics.view", false},
+	}
+
+	for i, s := range scenarios {
+		result := role.HasPermission(s.permission)
+		if result != s.expected {
+			t.Errorf("(%d) Expected %v, got %v", i, s.expected, result)
+		}
+	}
+}
+
+func TestUserRoles(t *testing.T) {
+	user := &model.User{
+		Roles: []*model.Role{
+			{Name: model.RoleAdmin, Permissions: []string{"users.*"}},
+			{Name: "editor", Permissions: []string{"posts.create"}},
+		},
+	}
+
+	if !user.IsAdmin() {
+		t.Error("Expected the user to be admin")
+	}
+
+	if user.IsSuperadmin() {
+		t.Error("Expected the user to not be superadmin")
+	}
+
+	if !user.HasRole("missing", "editor") {
+		t.Error("Expected the user to have the editor role")
+	}
+
+	if !user.HasPermission("posts.create") || !user.HasPermission("users.delete") {
+		t.Error("Expected the user to have the posts.create and users.delete permissions")
+	}
+
+	if user.HasPermission("settings.update") {
+		t.Error("Expected the user to not have the settings.update permission")
+	}
+
+	if (&model.User{}).HasPermission("users.view") {
+		t.Error("Expected a user without roles to not have any permission")
+	}
+}
@@ -0,0 +1,295 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/har4s/ohmygo/tools/inflector"
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v3"
+)
+
+// Overrides defines settings field values indexed by their json path
+// (eg. "smtp.host") that have precedence over the stored settings.
+type Overrides map[string]any
+
+// Paths returns the sorted json paths of the overridden settings fields.
+func (o Overrides) Paths() []string {
+	paths := make([]string, 0, len(o))
+	for path := range o {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ReadOverridesFile reads the settings overrides from a YAML (.yaml, .yml)
+// or JSON file with the same structure as the settings json, eg.:
+//
+//	smtp:
+//	  enabled: true
+//	  host: smtp.example.com
+//
+// The keys that don't match any settings field are ignored.
+func ReadOverridesFile(path string) (Overrides, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]any{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &data)
+	default:
+		err = json.Unmarshal(content, &data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse settings file %q: %w", path, err)
+	}
+
+	result := Overrides{}
+
+	for path := range fieldTypes() {
+		if value, ok := lookupNested(data, strings.Split(path, ".")); ok {
+			result[path] = value
+		}
+	}
+
+	return result, nil
+}
+
+// EnvOverrides collects the settings overrides from the "PREFIX_GROUP_FIELD" environment
+// variables in environ (see [os.Environ]), eg. "OHMYGO_SMTP_HOST" overrides "smtp.host"
+// and "OHMYGO_USER_AUTH_TOKEN_DURATION" overrides "userAuthToken.duration".
+//
+// The list values (eg. "emailAuth.onlyDomains") are comma separated.
+func EnvOverrides(prefix string, environ []string) (Overrides, error) {
+	vars := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			vars[k] = v
+		}
+	}
+
+	result := Overrides{}
+
+	for path, t := range fieldTypes() {
+		name := EnvName(prefix, path)
+
+		raw, ok := vars[name]
+		if !ok {
+			continue
+		}
+
+		value, err := parseEnvValue(raw, t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value: %w", name, err)
+		}
+
+		result[path] = value
+	}
+
+	return result, nil
+}
+
+// EnvName returns the name of the environment variable overriding
+// the settings field with the provided json path, eg. "OHMYGO_SMTP_HOST" for "smtp.host".
+func EnvName(prefix string, path string) string {
+	parts := []string{}
+	if prefix != "" {
+		parts = append(parts, prefix)
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		parts = append(parts, strings.ToUpper(inflector.Snakecase(segment)))
+	}
+
+	return strings.Join(parts, "_")
+}
+
+// ApplyOverrides sets the provided overrides values to the settings fields.
+func (s *Settings) ApplyOverrides(overrides Overrides) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for path, value := range overrides {
+		field, ok := fieldByPath(reflect.ValueOf(s).Elem(), path)
+		if !ok {
+			return fmt.Errorf("unknown settings field %q", path)
+		}
+
+		if err := setFieldValue(field, value); err != nil {
+			return fmt.Errorf("invalid %q value: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// Values returns the current values of the settings fields with the provided json paths.
+//
+// The unknown paths are ignored.
+func (s *Settings) Values(paths ...string) Overrides {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	result := make(Overrides, len(paths))
+
+	for _, path := range paths {
+		if field, ok := fieldByPath(reflect.ValueOf(s).Elem(), path); ok {
+			result[path] = field.Interface()
+		}
+	}
+
+	return result
+}
+
+// fieldTypes returns the types of all settings leaf fields indexed by their json path.
+func fieldTypes() map[string]reflect.Type {
+	result := map[string]reflect.Type{}
+	collectFieldTypes(reflect.TypeOf(Settings{}), "", result)
+	return result
+}
+
+func collectFieldTypes(t reflect.Type, prefix string, result map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			collectFieldTypes(field.Type, path, result)
+		} else {
+			result[path] = field.Type
+		}
+	}
+}
+
+// fieldByPath returns the settings field with the provided json path.
+func fieldByPath(v reflect.Value, path string) (reflect.Value, bool) {
+	for _, segment := range strings.Split(path, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			if jsonName(v.Type().Field(i)) == segment {
+				v = v.Field(i)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return reflect.Value{}, false
+		}
+	}
+
+	return v, v.Kind() != reflect.Struct
+}
+
+// jsonName returns the json key of the exported struct field (if any).
+func jsonName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
+
+func lookupNested(data map[string]any, segments []string) (any, bool) {
+	value, ok := data[segments[0]]
+	if !ok || len(segments) == 1 {
+		return value, ok
+	}
+
+	nested, ok := value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	return lookupNested(nested, segments[1:])
+}
+
+func parseEnvValue(raw string, t reflect.Type) (any, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return raw, nil
+	case reflect.Bool:
+		return strconv.ParseBool(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(raw, 10, 64)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			list := []string{}
+			for _, item := range strings.Split(raw, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			return list, nil
+		}
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func setFieldValue(field reflect.Value, value any) error {
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	if field.Kind() == reflect.String {
+		str, err := cast.ToStringE(value)
+		if err != nil {
+			return err
+		}
+		field.SetString(str)
+		return nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	target := reflect.New(field.Type())
+	if err := json.Unmarshal(encoded, target.Interface()); err != nil {
+		return err
+	}
+	field.Set(target.Elem())
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package settings_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/har4s/ohmygo/model/settings"
+	"github.com/har4s/ohmygo/tools/types"
+)
+
+func TestEnvName(t *testing.T) {
+	scenarios := []struct {
+		prefix   string
+		path     string
+		expected string
+	}{
+		{"", "smtp.host", "SMTP_HOST"},
+		{"OHMYGO", "smtp.host", "OHMYGO_SMTP_HOST"},
+		{"OHMYGO", "meta.appUrl", "OHMYGO_META_APP_URL"},
+		{"OHMYGO", "userAuthToken.secret", "OHMYGO_USER_AUTH_TOKEN_SECRET"},
+		{"OHMYGO", "meta.verificationTemplate.actionUrl", "OHMYGO_META_VERIFICATION_TEMPLATE_ACTION_URL"},
+	}
+
+	for i, s := range scenarios {
+		if result := settings.EnvName(s.prefix, s.path); result != s.expected {
+			t.Errorf("(%d) Expected %q, got %q", i, s.expected, result)
+		}
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	overrides, err := settings.EnvOverrides("OHMYGO", []string{
+		"OHMYGO_SMTP_HOST=smtp.test.com",
+		"OHMYGO_SMTP_PORT=25",
+		"OHMYGO_SMTP_ENABLED=true",
+		"OHMYGO_EMAIL_AUTH_ONLY_DOMAINS=a.com, b.com",
+		"OHMYGO_UNKNOWN=test",
+		"SMTP_USERNAME=test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := settings.Overrides{
+		"smtp.host":             "smtp.test.com",
+		"smtp.port":             int64(25),
+		"smtp.enabled":          true,
+		"emailAuth.onlyDomains": []string{"a.com", "b.com"},
+	}
+
+	if !reflect.DeepEqual(overrides, expected) {
+		t.Fatalf("Expected %v, got %v", expected, overrides)
+	}
+
+	if _, err := settings.EnvOverrides("OHMYGO", []string{"OHMYGO_SMTP_PORT=abc"}); err == nil {
+		t.Fatal("Expected invalid int value error, got nil")
+	}
+}
+
+func TestReadOverridesFile(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlFile := filepath.Join(dir, "settings.yaml")
+	os.WriteFile(yamlFile, []byte("smtp:\n  host: smtp.test.com\n  port: 25\nlogs:\n  unknown: 1\n"), 0644)
+
+	jsonFile := filepath.Join(dir, "settings.json")
+	os.WriteFile(jsonFile, []byte(`{"s3": {"enabled": true, "secret": "test"}}`), 0644)
+
+	invalidFile := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalidFile, []byte(`{`), 0644)
+
+	scenarios := []struct {
+		path        string
+		expectError bool
+		expected    settings.Overrides
+	}{
+		{filepath.Join(dir, "missing.yaml"), true, nil},
+		{invalidFile, true, nil},
+		{yamlFile, false, settings.Overrides{"smtp.host": "smtp.test.com", "smtp.port": 25}},
+		{jsonFile, false, settings.Overrides{"s3.enabled": true, "s3.secret": "test"}},
+	}
+
+	for i, s := range scenarios {
+		result, err := settings.ReadOverridesFile(s.path)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if !hasErr && !reflect.DeepEqual(result, s.expected) {
+			t.Errorf("(%d) Expected %v, got %v", i, s.expected, result)
+		}
+	}
+}
+
+func TestSettingsApplyOverridesAndValues(t *testing.T) {
+	s := settings.New()
+
+	err := s.ApplyOverrides(settings.Overrides{
+		"smtp.host":             "smtp.test.com",
+		"smtp.port":             25,
+		"smtp.password":         123,
+		"s3.enabled":            true,
+		"emailAuth.onlyDomains": []any{"a.com"},
+		"meta.appName":          nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := settings.Overrides{
+		"smtp.host":             "smtp.test.com",
+		"smtp.port":             25,
+		"smtp.password":         types.EncryptedString("123"),
+		"s3.enabled":            true,
+		"emailAuth.onlyDomains": []string{"a.com"},
+		"meta.appName":          "",
+	}
+
+	values := s.Values(expected.Paths()...)
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("Expected %v, got %v", expected, values)
+	}
+
+	if err := s.ApplyOverrides(settings.Overrides{"smtp.missing": 1}); err == nil {
+		t.Fatal("Expected unknown field error, got nil")
+	}
+
+	if err := s.ApplyOverrides(settings.Overrides{"smtp": 1}); err == nil {
+		t.Fatal("Expected unknown (not leaf) field error, got nil")
+	}
+
+	if err := s.ApplyOverrides(settings.Overrides{"smtp.port": "abc"}); err == nil {
+		t.Fatal("Expected invalid value error, got nil")
+	}
+}
+
+func TestOverridesPaths(t *testing.T) {
+	paths := settings.Overrides{"smtp.port": 1, "meta.appName": "", "s3.secret": ""}.Paths()
+
+	expected := []string{"meta.appName", "s3.secret", "smtp.port"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Expected %v, got %v", expected, paths)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/har4s/ohmygo/tools/mailer"
 	"github.com/har4s/ohmygo/tools/rest"
 	"github.com/har4s/ohmygo/tools/security"
+	"github.com/har4s/ohmygo/tools/types"
 	"github.com/har4s/ohmygo/validation"
 	"github.com/har4s/ohmygo/validation/is"
 )
@@ -60,11 +61,11 @@ func New() *Settings {
 			Tls:      false,
 		},
 		UserAuthToken: TokenConfig{
-			Secret:   security.RandomString(50),
+			Secret:   types.EncryptedString(security.RandomString(50)),
 			Duration: 1209600, // 14 days,
 		},
 		UserPasswordResetToken: TokenConfig{
-			Secret:   security.RandomString(50),
+			Secret:   types.EncryptedString(security.RandomString(50)),
 			Duration: 1800, // 30 minutes,
 		},
 		GoogleAuth: AuthProviderConfig{
@@ -135,22 +136,10 @@ func (s *Settings) RedactClone() (*Settings, error) {
 		return nil, err
 	}
 
-	mask := "******"
-
-	sensitiveFields := []*string{
-		&clone.Smtp.Password,
-		&clone.S3.Secret,
-		&clone.UserAuthToken.Secret,
-		&clone.UserPasswordResetToken.Secret,
-		&clone.GoogleAuth.ClientSecret,
-		&clone.FacebookAuth.ClientSecret,
-		&clone.GithubAuth.ClientSecret,
-		&clone.TwitterAuth.ClientSecret,
-		&clone.MicrosoftAuth.ClientSecret,
-	}
+	var mask types.EncryptedString = "******"
 
 	// mask all sensitive fields
-	for _, v := range sensitiveFields {
+	for _, v := range clone.secretFields() {
 		if v != nil && *v != "" {
 			*v = mask
 		}
@@ -159,6 +148,40 @@ func (s *Settings) RedactClone() (*Settings, error) {
 	return clone, nil
 }
 
+// DecryptSecrets decrypts in place the individually encrypted secret values
+// (eg. settings persisted before the whole settings value was encrypted).
+//
+// Not encrypted values are left unchanged.
+func (s *Settings) DecryptSecrets(keyring *security.Keyring) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for _, v := range s.secretFields() {
+		decrypted, err := types.ParseEncryptedString(v.String(), keyring)
+		if err != nil {
+			return err
+		}
+		*v = decrypted
+	}
+
+	return nil
+}
+
+// secretFields returns pointers to the sensitive settings fields.
+func (s *Settings) secretFields() []*types.EncryptedString {
+	return []*types.EncryptedString{
+		&s.Smtp.Password,
+		&s.S3.Secret,
+		&s.UserAuthToken.Secret,
+		&s.UserPasswordResetToken.Secret,
+		&s.GoogleAuth.ClientSecret,
+		&s.FacebookAuth.ClientSecret,
+		&s.GithubAuth.ClientSecret,
+		&s.TwitterAuth.ClientSecret,
+		&s.MicrosoftAuth.ClientSecret,
+	}
+}
+
 // NamedAuthProviderConfigs returns a map with all registered OAuth2
 // provider configurations (indexed by their name identifier).
 func (s *Settings) NamedAuthProviderConfigs() map[string]AuthProviderConfig {
@@ -177,8 +200,8 @@ func (s *Settings) NamedAuthProviderConfigs() map[string]AuthProviderConfig {
 // -------------------------------------------------------------------
 
 type TokenConfig struct {
-	Secret   string `form:"secret" json:"secret"`
-	Duration int64  `form:"duration" json:"duration"`
+	Secret   types.EncryptedString `form:"secret" json:"secret"`
+	Duration int64                 `form:"duration" json:"duration"`
 }
 
 // Validate makes TokenConfig validatable by implementing [validation.Validatable] interface.
@@ -192,11 +215,11 @@ func (c TokenConfig) Validate() error {
 // -------------------------------------------------------------------
 
 type SmtpConfig struct {
-	Enabled  bool   `form:"enabled" json:"enabled"`
-	Host     string `form:"host" json:"host"`
-	Port     int    `form:"port" json:"port"`
-	Username string `form:"username" json:"username"`
-	Password string `form:"password" json:"password"`
+	Enabled  bool                  `form:"enabled" json:"enabled"`
+	Host     string                `form:"host" json:"host"`
+	Port     int                   `form:"port" json:"port"`
+	Username string                `form:"username" json:"username"`
+	Password types.EncryptedString `form:"password" json:"password"`
 
 	// SMTP AUTH - PLAIN (default) or LOGIN
 	AuthMethod string `form:"authMethod" json:"authMethod"`
@@ -234,13 +257,13 @@ func (c SmtpConfig) Validate() error {
 // -------------------------------------------------------------------
 
 type S3Config struct {
-	Enabled        bool   `form:"enabled" json:"enabled"`
-	Bucket         string `form:"bucket" json:"bucket"`
-	Region         string `form:"region" json:"region"`
-	Endpoint       string `form:"endpoint" json:"endpoint"`
-	AccessKey      string `form:"accessKey" json:"accessKey"`
-	Secret         string `form:"secret" json:"secret"`
-	ForcePathStyle bool   `form:"forcePathStyle" json:"forcePathStyle"`
+	Enabled        bool                  `form:"enabled" json:"enabled"`
+	Bucket         string                `form:"bucket" json:"bucket"`
+	Region         string                `form:"region" json:"region"`
+	Endpoint       string                `form:"endpoint" json:"endpoint"`
+	AccessKey      string                `form:"accessKey" json:"accessKey"`
+	Secret         types.EncryptedString `form:"secret" json:"secret"`
+	ForcePathStyle bool                  `form:"forcePathStyle" json:"forcePathStyle"`
 }
 
 // Validate makes S3Config validatable by implementing [validation.Validatable] interface.
@@ -380,12 +403,12 @@ func (c LogsConfig) Validate() error {
 // -------------------------------------------------------------------
 
 type AuthProviderConfig struct {
-	Enabled      bool   `form:"enabled" json:"enabled"`
-	ClientId     string `form:"clientId" json:"clientId,omitempty"`
-	ClientSecret string `form:"clientSecret" json:"clientSecret,omitempty"`
-	AuthUrl      string `form:"authUrl" json:"authUrl,omitempty"`
-	TokenUrl     string `form:"tokenUrl" json:"tokenUrl,omitempty"`
-	UserApiUrl   string `form:"userApiUrl" json:"userApiUrl,omitempty"`
+	Enabled      bool                  `form:"enabled" json:"enabled"`
+	ClientId     string                `form:"clientId" json:"clientId,omitempty"`
+	ClientSecret types.EncryptedString `form:"clientSecret" json:"clientSecret,omitempty"`
+	AuthUrl      string                `form:"authUrl" json:"authUrl,omitempty"`
+	TokenUrl     string                `form:"tokenUrl" json:"tokenUrl,omitempty"`
+	UserApiUrl   string                `form:"userApiUrl" json:"userApiUrl,omitempty"`
 }
 
 // Validate makes `ProviderConfig` validatable by implementing [validation.Validatable] interface.
@@ -410,7 +433,7 @@ func (c AuthProviderConfig) SetupProvider(provider auth.Provider) error {
 	}
 
 	if c.ClientSecret != "" {
-		provider.SetClientSecret(c.ClientSecret)
+		provider.SetClientSecret(c.ClientSecret.String())
 	}
 
 	if c.AuthUrl != "" {
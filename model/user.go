@@ -14,9 +14,11 @@ type User struct {
 	Email           string         `db:"email" json:"email"`
 	TokenKey        string         `db:"tokenKey" json:"-"`
 	PasswordHash    string         `db:"passwordHash" json:"-"`
-	IsAdmin         bool           `db:"isAdmin" json:"-"`
-	IsSuperadmin    bool           `db:"isSuperadmin" json:"-"`
 	LastResetSentAt types.DateTime `db:"lastResetSentAt" json:"-"`
+
+	// Roles are the roles assigned to the user.
+	// They are not loaded by default (see dao.ExpandUserRoles).
+	Roles []*Role `db:"-" json:"-"`
 }
 
 // TableName returns the User model SQL table name.
@@ -24,6 +26,40 @@ func (m *User) TableName() string {
 	return "users"
 }
 
+// HasRole checks whether the user has any of the provided roles.
+func (m *User) HasRole(names ...string) bool {
+	for _, role := range m.Roles {
+		for _, name := range names {
+			if role.Name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// HasPermission checks whether any of the user roles grants the provided permission.
+func (m *User) HasPermission(permission string) bool {
+	for _, role := range m.Roles {
+		if role.HasPermission(permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsAdmin checks whether the user has the built-in admin role.
+func (m *User) IsAdmin() bool {
+	return m.HasRole(RoleAdmin)
+}
+
+// IsSuperadmin checks whether the user has the built-in superadmin role.
+func (m *User) IsSuperadmin() bool {
+	return m.HasRole(RoleSuperadmin)
+}
+
 // ValidatePassword validates a plain password against the model's password.
 func (m *User) ValidatePassword(password string) bool {
 	bytePassword := []byte(password)
@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/har4s/ohmygo/api"
+	"github.com/har4s/ohmygo/core"
+	"github.com/har4s/ohmygo/dbx"
+	"github.com/har4s/ohmygo/model"
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/cast"
+)
+
+const (
+	defaultPerPage = 30
+	maxPerPage     = 500
+)
+
+// bindAuditLogApi registers the audit log api endpoints and the corresponding handlers.
+func bindAuditLogApi(app core.App, rg *echo.Echo) {
+	handler := auditLogApi{app: app}
+	subGroup := rg.Group("/audit-logs", api.RequireAuth(), api.RequirePermission(model.PermissionAuditLogsView))
+	subGroup.GET("", handler.list)
+	subGroup.GET("/verify", handler.verify)
+	subGroup.GET("/:id", handler.view)
+}
+
+type auditLogApi struct {
+	app core.App
+}
+
+// list returns a paginated list of audit logs filtered by the
+// optional "table", "recordId", "action", "actorId", "from" and "to" query parameters.
+func (h *auditLogApi) list(c echo.Context) error {
+	page := cast.ToInt(c.QueryParam("page"))
+	if page <= 0 {
+		page = 1
+	}
+
+	perPage := cast.ToInt(c.QueryParam("perPage"))
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	} else if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	dao := api.RequestDao(h.app, c)
+
+	query := dao.AuditLogQuery()
+
+	filters := map[string]string{
+		"recordTable": c.QueryParam("table"),
+		"recordId":    c.QueryParam("recordId"),
+		"action":      c.QueryParam("action"),
+		"actorId":     c.QueryParam("actorId"),
+	}
+	for column, value := range filters {
+		if value != "" {
+			query.AndWhere(dbx.HashExp{column: value})
+		}
+	}
+
+	if from := c.QueryParam("from"); from != "" {
+		query.AndWhere(dbx.NewExp("[[created]] >= {:from}", dbx.Params{"from": from}))
+	}
+
+	if to := c.QueryParam("to"); to != "" {
+		query.AndWhere(dbx.NewExp("[[created]] <= {:to}", dbx.Params{"to": to}))
+	}
+
+	var totalItems int
+	if err := query.Select("count(*)").Row(&totalItems); err != nil {
+		return api.NewBadRequestError("Failed to count the audit logs.", err)
+	}
+
+	items := []model.AuditLog{}
+	err := query.
+		Select("*").
+		OrderBy("created DESC").
+		Offset(int64(perPage * (page - 1))).
+		Limit(int64(perPage)).
+		All(&items)
+	if err != nil {
+		return api.NewBadRequestError("Failed to load the audit logs.", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"page":       page,
+		"perPage":    perPage,
+		"totalItems": totalItems,
+		"items":      items,
+	})
+}
+
+func (h *auditLogApi) view(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return api.NewNotFoundError("", nil)
+	}
+
+	log, err := api.RequestDao(h.app, c).FindAuditLogById(id)
+	if err != nil || log == nil {
+		return api.NewNotFoundError("", err)
+	}
+
+	return c.JSON(http.StatusOK, log)
+}
+
+// verify checks the integrity of the audit logs chain.
+func (h *auditLogApi) verify(c echo.Context) error {
+	if err := api.RequestDao(h.app, c).VerifyAuditLogs(); err != nil {
+		return c.JSON(http.StatusOK, map[string]any{
+			"valid": false,
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"valid": true,
+	})
+}
@@ -0,0 +1,168 @@
+// Package audit implements a tamper-evident audit log of the app
+// model changes (create, update and delete).
+//
+// Example:
+//
+//	audit.Register(app, &audit.Options{
+//		ExcludeTables: []string{"params"},
+//	})
+//
+// Individual models could also opt-in or opt-out from the audit log
+// by implementing the [model.Auditable] interface.
+package audit
+
+import (
+	"encoding/json"
+
+	"github.com/har4s/ohmygo/api"
+	"github.com/har4s/ohmygo/core"
+	"github.com/har4s/ohmygo/model"
+	"github.com/har4s/ohmygo/tools/list"
+)
+
+// DefaultHiddenColumns specifies the default columns whose values are masked in the logs diff.
+var DefaultHiddenColumns = []string{"passwordHash", "tokenKey"}
+
+const hiddenValueMask = "******"
+
+// Options defines optional struct to customize the default plugin behavior.
+type Options struct {
+	// Tables specifies the only tables that should be audited (aka. opt-in).
+	//
+	// If empty, all tables are audited except those listed in ExcludeTables.
+	Tables []string
+
+	// ExcludeTables specifies the tables that should not be audited (aka. opt-out).
+	ExcludeTables []string
+
+	// HiddenColumns specifies the columns whose values should be masked
+	// in the logs diff (default to DefaultHiddenColumns).
+	HiddenColumns []string
+}
+
+type plugin struct {
+	app     core.App
+	options *Options
+}
+
+// Register registers the audit log model hooks and api endpoints to the provided app instance.
+func Register(app core.App, options *Options) {
+	p := &plugin{app: app}
+
+	if options != nil {
+		p.options = options
+	} else {
+		p.options = &Options{}
+	}
+
+	if p.options.HiddenColumns == nil {
+		p.options.HiddenColumns = DefaultHiddenColumns
+	}
+
+	app.OnModelAfterCreate().Add(func(e *core.ModelEvent) error {
+		return p.record(e, model.AuditActionCreate)
+	})
+
+	app.OnModelAfterUpdate().Add(func(e *core.ModelEvent) error {
+		return p.record(e, model.AuditActionUpdate)
+	})
+
+	app.OnModelAfterDelete().Add(func(e *core.ModelEvent) error {
+		return p.record(e, model.AuditActionDelete)
+	})
+
+	app.OnBeforeServe().Add(func(e *core.ServeEvent) error {
+		bindAuditLogApi(app, e.Router)
+		return nil
+	})
+}
+
+// isAuditable checks whether the changes of the provided model should be recorded.
+func (p *plugin) isAuditable(m model.Model) bool {
+	if v, ok := m.(model.Auditable); ok {
+		return v.IsAuditable()
+	}
+
+	table := m.TableName()
+
+	if len(p.options.Tables) > 0 && !list.ExistInSlice(table, p.options.Tables) {
+		return false
+	}
+
+	return !list.ExistInSlice(table, p.options.ExcludeTables)
+}
+
+// record creates a new audit log entry for the provided model event.
+func (p *plugin) record(e *core.ModelEvent, action string) error {
+	if !p.isAuditable(e.Model) {
+		return nil
+	}
+
+	diff := p.diff(e.Model, action)
+	if len(diff) == 0 {
+		return nil // nothing to record
+	}
+
+	encodedDiff, err := json.Marshal(diff)
+	if err != nil {
+		return err
+	}
+
+	log := &model.AuditLog{
+		RecordTable: e.Model.TableName(),
+		RecordId:    e.Model.GetId(),
+		Action:      action,
+		Diff:        encodedDiff,
+	}
+
+	if user := api.RequestContextUser(e.Dao.Context()); user != nil {
+		log.ActorId = user.Id
+	}
+
+	return e.Dao.SaveAuditLog(log)
+}
+
+// diff returns the old and new column values of the provided model
+// in the format `{"column": {"old": ..., "new": ...}}`.
+func (p *plugin) diff(m model.Model, action string) map[string]map[string]any {
+	result := map[string]map[string]any{}
+
+	switch action {
+	case model.AuditActionCreate:
+		for col, v := range model.ColumnValues(m) {
+			result[col] = map[string]any{"new": p.value(col, v)}
+		}
+	case model.AuditActionDelete:
+		for col, v := range model.ColumnValues(m) {
+			result[col] = map[string]any{"old": p.value(col, v)}
+		}
+	case model.AuditActionUpdate:
+		tracker, ok := m.(model.ChangesTracker)
+		if !ok {
+			// the changes are unknown so record the new state of the model
+			for col, v := range model.ColumnValues(m) {
+				result[col] = map[string]any{"new": p.value(col, v)}
+			}
+			break
+		}
+
+		previous := tracker.Previous()
+		for col, v := range tracker.Changes() {
+			result[col] = map[string]any{
+				"old": p.value(col, previous[col]),
+				"new": p.value(col, v),
+			}
+		}
+	}
+
+	return result
+}
+
+// value normalizes the provided column value and masks it if the column is hidden.
+func (p *plugin) value(col string, v any) any {
+	if list.ExistInSlice(col, p.options.HiddenColumns) {
+		return hiddenValueMask
+	}
+
+	return model.NormalizeColumnValue(v)
+}
@@ -0,0 +1,206 @@
+package audit_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/har4s/ohmygo/api"
+	"github.com/har4s/ohmygo/core"
+	"github.com/har4s/ohmygo/dbx"
+	"github.com/har4s/ohmygo/migrations"
+	"github.com/har4s/ohmygo/model"
+	"github.com/har4s/ohmygo/plugins/audit"
+	"github.com/har4s/ohmygo/tokens"
+	"github.com/har4s/ohmygo/tools/migrate"
+	"github.com/labstack/echo/v4"
+)
+
+// createTestApp creates a new bootstrapped app with a clean and fully migrated test db.
+func createTestApp(t *testing.T) *core.BaseApp {
+	app := core.NewBaseApp(&core.BaseAppConfig{
+		DatabaseURL: "har4s:@/ozzo_dbx_test?parseTime=true",
+	})
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		app.ResetBootstrapState()
+	})
+
+	tables := []string{"user_roles", "roles", "audit_logs", "users", "params", migrate.DefaultMigrationsTable}
+	for _, table := range tables {
+		if _, err := app.DB().NewQuery("DROP TABLE IF EXISTS {{" + table + "}}").Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runner, err := migrate.NewRunner(app.DB(), migrations.Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.RefreshSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	return app
+}
+
+func findTestAuditLogs(t *testing.T, app core.App, recordId string) []model.AuditLog {
+	logs := []model.AuditLog{}
+
+	err := app.Dao().AuditLogQuery().
+		AndWhere(dbx.HashExp{"recordId": recordId}).
+		OrderBy("created ASC").
+		All(&logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return logs
+}
+
+func TestRecordModelChanges(t *testing.T) {
+	app := createTestApp(t)
+
+	audit.Register(app, nil)
+
+	user := &model.User{Email: "test@example.com", PasswordHash: "test"}
+	user.RefreshTokenKey()
+	if err := app.Dao().SaveUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	user.Email = "test_update@example.com"
+	if err := app.Dao().SaveUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Dao().DeleteUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	logs := findTestAuditLogs(t, app, user.Id)
+
+	expectedActions := []string{model.AuditActionCreate, model.AuditActionUpdate, model.AuditActionDelete}
+	if len(logs) != len(expectedActions) {
+		t.Fatalf("Expected %d logs, got %d", len(expectedActions), len(logs))
+	}
+
+	for i, log := range logs {
+		if log.Action != expectedActions[i] {
+			t.Errorf("(%d) Expected action %q, got %q", i, expectedActions[i], log.Action)
+		}
+
+		if log.RecordTable != user.TableName() {
+			t.Errorf("(%d) Expected table %q, got %q", i, user.TableName(), log.RecordTable)
+		}
+
+		if log.ActorId != "" {
+			t.Errorf("(%d) Expected empty actor, got %q", i, log.ActorId)
+		}
+
+		diff := map[string]map[string]any{}
+		if err := json.Unmarshal(log.Diff, &diff); err != nil {
+			t.Errorf("(%d) Failed to decode the diff: %v", i, err)
+			continue
+		}
+
+		switch log.Action {
+		case model.AuditActionCreate:
+			if diff["email"]["new"] != "test@example.com" {
+				t.Errorf("(%d) Expected the new email in the diff, got %v", i, diff["email"])
+			}
+			if diff["passwordHash"]["new"] != "******" {
+				t.Errorf("(%d) Expected masked passwordHash, got %v", i, diff["passwordHash"])
+			}
+		case model.AuditActionUpdate:
+			if diff["email"]["old"] != "test@example.com" || diff["email"]["new"] != "test_update@example.com" {
+				t.Errorf("(%d) Expected the email change in the diff, got %v", i, diff)
+			}
+			if _, ok := diff["passwordHash"]; ok {
+				t.Errorf("(%d) Expected only the changed columns in the diff, got %v", i, diff)
+			}
+		case model.AuditActionDelete:
+			if diff["email"]["old"] != "test_update@example.com" {
+				t.Errorf("(%d) Expected the old email in the diff, got %v", i, diff["email"])
+			}
+		}
+	}
+
+	if err := app.Dao().VerifyAuditLogs(); err != nil {
+		t.Fatalf("Expected valid audit logs chain, got %v", err)
+	}
+}
+
+func TestRecordModelChangesOptions(t *testing.T) {
+	app := createTestApp(t)
+
+	audit.Register(app, &audit.Options{ExcludeTables: []string{"users"}})
+
+	user := &model.User{Email: "test@example.com", PasswordHash: "test"}
+	user.RefreshTokenKey()
+	if err := app.Dao().SaveUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	role := &model.Role{Name: "editor"}
+	if err := app.Dao().SaveRole(role); err != nil {
+		t.Fatal(err)
+	}
+
+	if logs := findTestAuditLogs(t, app, user.Id); len(logs) != 0 {
+		t.Fatalf("Expected the excluded users table to not be audited, got %d logs", len(logs))
+	}
+
+	if logs := findTestAuditLogs(t, app, role.Id); len(logs) != 1 {
+		t.Fatalf("Expected 1 role log, got %d", len(logs))
+	}
+}
+
+func TestRecordModelChangesActor(t *testing.T) {
+	app := createTestApp(t)
+
+	audit.Register(app, nil)
+
+	actor := &model.User{Email: "actor@example.com", PasswordHash: "test"}
+	actor.RefreshTokenKey()
+	if err := app.Dao().SaveUser(actor); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := tokens.NewUserAuthToken(app, actor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	role := &model.Role{Name: "editor"}
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Authorization", token)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err = api.LoadAuthContext(app)(func(c echo.Context) error {
+		return api.RequestDao(app, c).SaveRole(role)
+	})(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := findTestAuditLogs(t, app, role.Id)
+	if len(logs) != 1 {
+		t.Fatalf("Expected 1 role log, got %d", len(logs))
+	}
+
+	if logs[0].ActorId != actor.Id {
+		t.Fatalf("Expected actor %q, got %q", actor.Id, logs[0].ActorId)
+	}
+}
@@ -11,7 +11,7 @@ import (
 func NewUserAuthToken(app core.App, user *model.User) (string, error) {
 	return security.NewToken(
 		jwt.MapClaims{"id": user.Id, "type": TypeUser},
-		(user.TokenKey + app.Settings().UserAuthToken.Secret),
+		(user.TokenKey + app.Settings().UserAuthToken.Secret.String()),
 		app.Settings().UserAuthToken.Duration,
 	)
 }
@@ -20,7 +20,7 @@ func NewUserAuthToken(app core.App, user *model.User) (string, error) {
 func NewUserResetPasswordToken(app core.App, user *model.User) (string, error) {
 	return security.NewToken(
 		jwt.MapClaims{"id": user.Id, "type": TypeUser, "email": user.Email},
-		(user.TokenKey + app.Settings().UserPasswordResetToken.Secret),
+		(user.TokenKey + app.Settings().UserPasswordResetToken.Secret.String()),
 		app.Settings().UserPasswordResetToken.Duration,
 	)
 }
@@ -0,0 +1,125 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultKeyId is the id of a key specified without id in ParseKeyring.
+const DefaultKeyId = "default"
+
+// Keyring defines a set of AES encryption keys indexed by their ids.
+//
+// New values are always encrypted with the primary key, while the older (aka. rotated)
+// keys are kept only to decrypt the values that were encrypted with them.
+type Keyring struct {
+	primaryId string
+	keys      map[string]string
+}
+
+// NewKeyring creates a new Keyring instance from the provided keys
+// (each must be valid 32 char aes key) indexed by their ids.
+//
+// The primaryId key is used for encrypting the new values.
+func NewKeyring(primaryId string, keys map[string]string) (*Keyring, error) {
+	if _, ok := keys[primaryId]; !ok {
+		return nil, fmt.Errorf("missing primary key %q", primaryId)
+	}
+
+	k := &Keyring{
+		primaryId: primaryId,
+		keys:      make(map[string]string, len(keys)),
+	}
+
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+
+		if len(key) != 32 {
+			return nil, fmt.Errorf("the key %q must be 32 chars long", id)
+		}
+
+		k.keys[id] = key
+	}
+
+	return k, nil
+}
+
+// ParseKeyring creates a new Keyring instance from a comma separated list
+// of "keyId:key" pairs, where the first one is the primary key, eg.:
+//
+//	ParseKeyring("k2:new_32_chars_key,k1:old_32_chars_key")
+//
+// A key without id (eg. just the plain 32 chars key) is registered with the [DefaultKeyId] id.
+func ParseKeyring(spec string) (*Keyring, error) {
+	primaryId := ""
+	keys := map[string]string{}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		id, key, ok := strings.Cut(part, ":")
+		if !ok || len(key) != 32 {
+			id, key = DefaultKeyId, part
+		}
+
+		if _, exists := keys[id]; exists {
+			return nil, fmt.Errorf("duplicated key id %q", id)
+		}
+		keys[id] = key
+
+		if primaryId == "" {
+			primaryId = id
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("missing encryption keys")
+	}
+
+	return NewKeyring(primaryId, keys)
+}
+
+// PrimaryId returns the id of the key used for encrypting the new values.
+func (k *Keyring) PrimaryId() string {
+	return k.primaryId
+}
+
+// Encrypt encrypts data with the primary key.
+//
+// The result is in the format "keyId:cipherText".
+func (k *Keyring) Encrypt(data []byte) (string, error) {
+	cipherText, err := Encrypt(data, k.keys[k.primaryId])
+	if err != nil {
+		return "", err
+	}
+
+	return k.primaryId + ":" + cipherText, nil
+}
+
+// Decrypt decrypts text encrypted by Encrypt with the key of its key id.
+func (k *Keyring) Decrypt(text string) ([]byte, error) {
+	id, cipherText, ok := strings.Cut(text, ":")
+	if !ok {
+		return nil, errors.New("missing encryption key id")
+	}
+
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key %q", id)
+	}
+
+	return Decrypt(cipherText, key)
+}
+
+// NeedsRotation checks whether text was encrypted with a key different from the primary one.
+func (k *Keyring) NeedsRotation(text string) bool {
+	id, _, _ := strings.Cut(text, ":")
+
+	return id != k.primaryId
+}
@@ -0,0 +1,127 @@
+package security_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/har4s/ohmygo/tools/security"
+)
+
+func TestNewKeyring(t *testing.T) {
+	scenarios := []struct {
+		primaryId   string
+		keys        map[string]string
+		expectError bool
+	}{
+		{"", nil, true},
+		{"k1", map[string]string{"k2": "abcdabcdabcdabcdabcdabcdabcdabcd"}, true},   // missing primary key
+		{"k1", map[string]string{"k1": "test"}, true},                               // key must be valid 32 char aes string
+		{"k:1", map[string]string{"k:1": "abcdabcdabcdabcdabcdabcdabcdabcd"}, true}, // invalid key id
+		{"k1", map[string]string{"k1": "abcdabcdabcdabcdabcdabcdabcdabcd"}, false},
+		{"k1", map[string]string{"k1": "abcdabcdabcdabcdabcdabcdabcdabcd", "k2": "dcbadcbadcbadcbadcbadcbadcbadcba"}, false},
+	}
+
+	for i, s := range scenarios {
+		keyring, err := security.NewKeyring(s.primaryId, s.keys)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if !hasErr && keyring.PrimaryId() != s.primaryId {
+			t.Errorf("(%d) Expected primary id %q, got %q", i, s.primaryId, keyring.PrimaryId())
+		}
+	}
+}
+
+func TestKeyringEncryptDecrypt(t *testing.T) {
+	oldKeyring, _ := security.NewKeyring("k1", map[string]string{
+		"k1": "abcdabcdabcdabcdabcdabcdabcdabcd",
+	})
+
+	// rotated keyring
+	keyring, _ := security.NewKeyring("k2", map[string]string{
+		"k1": "abcdabcdabcdabcdabcdabcdabcdabcd",
+		"k2": "dcbadcbadcbadcbadcbadcbadcbadcba",
+	})
+
+	oldEncrypted, err := oldKeyring.Encrypt([]byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(oldEncrypted, "k1:") {
+		t.Fatalf("Expected the k1 key id prefix, got %q", oldEncrypted)
+	}
+
+	encrypted, err := keyring.Encrypt([]byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encrypted, "k2:") {
+		t.Fatalf("Expected the k2 key id prefix, got %q", encrypted)
+	}
+
+	scenarios := []struct {
+		keyring      *security.Keyring
+		text         string
+		expectError  bool
+		expectRotate bool
+	}{
+		{keyring, "", true, true},
+		{keyring, "test", true, true},    // missing key id
+		{keyring, "k3:test", true, true}, // unknown key id
+		{keyring, oldEncrypted, false, true},
+		{keyring, encrypted, false, false},
+		{oldKeyring, encrypted, true, true},
+		{oldKeyring, oldEncrypted, false, false},
+	}
+
+	for i, s := range scenarios {
+		result, err := s.keyring.Decrypt(s.text)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+
+		if !hasErr && string(result) != "test" {
+			t.Errorf("(%d) Expected %q, got %q", i, "test", result)
+		}
+
+		if rotate := s.keyring.NeedsRotation(s.text); rotate != s.expectRotate {
+			t.Errorf("(%d) Expected NeedsRotation %v, got %v", i, s.expectRotate, rotate)
+		}
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	scenarios := []struct {
+		spec            string
+		expectError     bool
+		expectPrimaryId string
+	}{
+		{"", true, ""},
+		{" , ", true, ""},
+		{"test", true, ""}, // key must be valid 32 char aes string
+		{"abcdabcdabcdabcdabcdabcdabcdabcd", false, security.DefaultKeyId},
+		{"k1:abcdabcdabcdabcdabcdabcdabcdabcd", false, "k1"},
+		{"k2:dcbadcbadcbadcbadcbadcbadcbadcba, k1:abcdabcdabcdabcdabcdabcdabcdabcd", false, "k2"},
+		{"k1:dcbadcbadcbadcbadcbadcbadcbadcba,k1:abcdabcdabcdabcdabcdabcdabcdabcd", true, ""},
+	}
+
+	for i, s := range scenarios {
+		keyring, err := security.ParseKeyring(s.spec)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if !hasErr && keyring.PrimaryId() != s.expectPrimaryId {
+			t.Errorf("(%d) Expected primary id %q, got %q", i, s.expectPrimaryId, keyring.PrimaryId())
+		}
+	}
+}
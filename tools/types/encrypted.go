@@ -0,0 +1,252 @@
+package types
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/har4s/ohmygo/tools/security"
+)
+
+// encryptedPrefix is the prefix of the encrypted values ("enc:keyId:cipherText").
+const encryptedPrefix = "enc:"
+
+// IsEncrypted checks whether text is an encrypted value in the "enc:keyId:cipherText" format.
+func IsEncrypted(text string) bool {
+	return strings.HasPrefix(text, encryptedPrefix)
+}
+
+// encrypt encrypts data with keyring in the "enc:keyId:cipherText" format.
+// The data is returned as plain text if keyring is nil.
+func encrypt(data []byte, keyring *security.Keyring) (string, error) {
+	if keyring == nil {
+		return string(data), nil
+	}
+
+	cipherText, err := keyring.Encrypt(data)
+	if err != nil {
+		return "", err
+	}
+
+	return encryptedPrefix + cipherText, nil
+}
+
+// decrypt decrypts text encrypted with encrypt.
+// Not encrypted text is returned as it is (eg. values persisted before enabling the encryption).
+func decrypt(text string, keyring *security.Keyring) ([]byte, error) {
+	if !IsEncrypted(text) {
+		return []byte(text), nil
+	}
+
+	if keyring == nil {
+		return nil, errors.New("missing encryption keyring")
+	}
+
+	return keyring.Decrypt(strings.TrimPrefix(text, encryptedPrefix))
+}
+
+// -------------------------------------------------------------------
+
+// EncryptedString defines a plain text string value (eg. a secret)
+// that could be encrypted with [EncryptedString.Encrypt] before persisting it
+// and decrypted with [ParseEncryptedString] after loading it.
+//
+// To read and write it directly as a db column value, bind it
+// to a keyring with [EncryptedString.WithKeyring].
+//
+// The json representation of the value is its plain text.
+type EncryptedString string
+
+// ParseEncryptedString creates a new EncryptedString instance from the
+// provided text decrypting it with keyring (if encrypted).
+func ParseEncryptedString(text string, keyring *security.Keyring) (EncryptedString, error) {
+	data, err := decrypt(text, keyring)
+	if err != nil {
+		return "", err
+	}
+
+	return EncryptedString(data), nil
+}
+
+// String returns the plain text of the current EncryptedString instance.
+func (s EncryptedString) String() string {
+	return string(s)
+}
+
+// Encrypt returns the current value encrypted with keyring in the "enc:keyId:cipherText" format.
+//
+// Empty values are not encrypted.
+func (s EncryptedString) Encrypt(keyring *security.Keyring) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+
+	return encrypt([]byte(s), keyring)
+}
+
+// WithKeyring binds the current EncryptedString instance to the keyring used to
+// encrypt it when written to the db and decrypt it when read from the db.
+//
+// The keyring is usually the Dao one, eg.:
+//
+//	dao.DB().Insert("example", dbx.Params{"secret": secret.WithKeyring(dao.EncryptionKeyring)})
+//	dao.DB().Select("secret").From("example").Row(secret.WithKeyring(dao.EncryptionKeyring))
+func (s *EncryptedString) WithKeyring(keyring *security.Keyring) *EncryptedStringValue {
+	return &EncryptedStringValue{target: s, keyring: keyring}
+}
+
+// EncryptedStringValue is an EncryptedString bound to a keyring (see [EncryptedString.WithKeyring]).
+type EncryptedStringValue struct {
+	target  *EncryptedString
+	keyring *security.Keyring
+}
+
+// Value implements the [driver.Valuer] interface.
+//
+// The value is written as plain text if the keyring is nil.
+func (v *EncryptedStringValue) Value() (driver.Value, error) {
+	return v.target.Encrypt(v.keyring)
+}
+
+// Scan implements [sql.Scanner] interface to decrypt and scan
+// the provided value into the bound EncryptedString instance.
+func (v *EncryptedStringValue) Scan(value any) error {
+	var text string
+
+	switch val := value.(type) {
+	case nil:
+		// no cast is needed
+	case []byte:
+		text = string(val)
+	case string:
+		text = val
+	default:
+		return fmt.Errorf("failed to scan EncryptedString value: %q", value)
+	}
+
+	parsed, err := ParseEncryptedString(text, v.keyring)
+	if err != nil {
+		return err
+	}
+
+	*v.target = parsed
+
+	return nil
+}
+
+// -------------------------------------------------------------------
+
+// EncryptedJsonRaw defines a plain json value that could be encrypted
+// with [EncryptedJsonRaw.Encrypt] before persisting it and decrypted with
+// [ParseEncryptedJsonRaw] after loading it.
+//
+// To read and write it directly as a db column value, bind it
+// to a keyring with [EncryptedJsonRaw.WithKeyring].
+//
+// The json representation of the value is the plain json value (see [JsonRaw]).
+type EncryptedJsonRaw []byte
+
+// ParseEncryptedJsonRaw creates a new EncryptedJsonRaw instance from the
+// provided text decrypting it with keyring (if encrypted).
+//
+// Not encrypted values are normalized the same way as [JsonRaw.Scan].
+func ParseEncryptedJsonRaw(text string, keyring *security.Keyring) (EncryptedJsonRaw, error) {
+	data, err := decrypt(text, keyring)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := JsonRaw{}
+	if err := raw.Scan(data); err != nil {
+		return nil, err
+	}
+
+	return EncryptedJsonRaw(raw), nil
+}
+
+// String returns the current EncryptedJsonRaw instance as a plain json encoded string.
+func (j EncryptedJsonRaw) String() string {
+	return string(j)
+}
+
+// MarshalJSON implements the [json.Marshaler] interface.
+func (j EncryptedJsonRaw) MarshalJSON() ([]byte, error) {
+	return JsonRaw(j).MarshalJSON()
+}
+
+// UnmarshalJSON implements the [json.Unmarshaler] interface.
+func (j *EncryptedJsonRaw) UnmarshalJSON(b []byte) error {
+	if j == nil {
+		return errors.New("EncryptedJsonRaw: UnmarshalJSON on nil pointer")
+	}
+
+	*j = append((*j)[0:0], b...)
+
+	return nil
+}
+
+// Encrypt returns the current json value encrypted with keyring in the "enc:keyId:cipherText" format.
+//
+// Empty values are not encrypted.
+func (j EncryptedJsonRaw) Encrypt(keyring *security.Keyring) (string, error) {
+	if len(j) == 0 {
+		return "", nil
+	}
+
+	return encrypt(j, keyring)
+}
+
+// WithKeyring binds the current EncryptedJsonRaw instance to the keyring used to
+// encrypt it when written to the db and decrypt it when read from the db
+// (see also [EncryptedString.WithKeyring]).
+func (j *EncryptedJsonRaw) WithKeyring(keyring *security.Keyring) *EncryptedJsonRawValue {
+	return &EncryptedJsonRawValue{target: j, keyring: keyring}
+}
+
+// EncryptedJsonRawValue is an EncryptedJsonRaw bound to a keyring (see [EncryptedJsonRaw.WithKeyring]).
+type EncryptedJsonRawValue struct {
+	target  *EncryptedJsonRaw
+	keyring *security.Keyring
+}
+
+// Value implements the [driver.Valuer] interface.
+//
+// Empty values are written as NULL and the other values
+// are written as plain json if the keyring is nil.
+func (v *EncryptedJsonRawValue) Value() (driver.Value, error) {
+	if len(*v.target) == 0 {
+		return nil, nil
+	}
+
+	return v.target.Encrypt(v.keyring)
+}
+
+// Scan implements [sql.Scanner] interface to decrypt and scan
+// the provided value into the bound EncryptedJsonRaw instance.
+//
+// Encrypted string values are decrypted, the other values are
+// normalized the same way as [JsonRaw.Scan].
+func (v *EncryptedJsonRawValue) Scan(value any) error {
+	switch val := value.(type) {
+	case []byte:
+		value = string(val)
+	case EncryptedJsonRaw:
+		value = []byte(val)
+	}
+
+	if text, ok := value.(string); ok && IsEncrypted(text) {
+		data, err := decrypt(text, v.keyring)
+		if err != nil {
+			return err
+		}
+		value = data
+	}
+
+	raw := JsonRaw{}
+	if err := raw.Scan(value); err != nil {
+		return err
+	}
+
+	return v.target.UnmarshalJSON(raw)
+}
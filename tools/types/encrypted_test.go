@@ -0,0 +1,281 @@
+package types_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/har4s/ohmygo/tools/security"
+	"github.com/har4s/ohmygo/tools/types"
+)
+
+func TestIsEncrypted(t *testing.T) {
+	scenarios := []struct {
+		text     string
+		expected bool
+	}{
+		{"", false},
+		{"test", false},
+		{"k1:test", false},
+		{"enc:k1:test", true},
+	}
+
+	for i, s := range scenarios {
+		if result := types.IsEncrypted(s.text); result != s.expected {
+			t.Errorf("(%d) Expected %v, got %v", i, s.expected, result)
+		}
+	}
+}
+
+func TestEncryptedStringEncrypt(t *testing.T) {
+	keyring, _ := security.NewKeyring("k1", map[string]string{
+		"k1": "abcdabcdabcdabcdabcdabcdabcdabcd",
+	})
+
+	// without keyring
+	plain, err := types.EncryptedString("test").Encrypt(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain != "test" {
+		t.Fatalf("Expected plain text value, got %v", plain)
+	}
+
+	// with keyring
+	empty, err := types.EncryptedString("").Encrypt(keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty != "" {
+		t.Fatalf("Expected empty value, got %v", empty)
+	}
+
+	encrypted, err := types.EncryptedString("test").Encrypt(keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encrypted, "enc:k1:") {
+		t.Fatalf("Expected encrypted value, got %v", encrypted)
+	}
+}
+
+func TestEncryptedStringMarshalJSON(t *testing.T) {
+	result, err := json.Marshal(types.EncryptedString("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(result) != `"test"` {
+		t.Fatalf("Expected the plain text json value, got %s", result)
+	}
+}
+
+func TestParseEncryptedString(t *testing.T) {
+	keyring, _ := security.NewKeyring("k1", map[string]string{
+		"k1": "abcdabcdabcdabcdabcdabcdabcdabcd",
+	})
+
+	encrypted, err := types.EncryptedString("test").Encrypt(keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		text        string
+		keyring     *security.Keyring
+		expectError bool
+		expected    string
+	}{
+		{"", nil, false, ""},
+		{"test", nil, false, "test"},
+		{"test", keyring, false, "test"},
+		{encrypted, nil, true, ""},
+		{encrypted, keyring, false, "test"},
+		{"enc:k2:test", keyring, true, ""}, // unknown key
+	}
+
+	for i, s := range scenarios {
+		result, err := types.ParseEncryptedString(s.text, s.keyring)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if result.String() != s.expected {
+			t.Errorf("(%d) Expected %q, got %q", i, s.expected, result.String())
+		}
+	}
+}
+
+func TestEncryptedStringValueValueAndScan(t *testing.T) {
+	keyring, _ := security.NewKeyring("k1", map[string]string{
+		"k1": "abcdabcdabcdabcdabcdabcdabcdabcd",
+	})
+	otherKeyring, _ := security.NewKeyring("k2", map[string]string{
+		"k2": "efghefghefghefghefghefghefghefgh",
+	})
+
+	secret := types.EncryptedString("test")
+
+	value, err := secret.WithKeyring(keyring).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, _ := value.(string)
+	if !strings.HasPrefix(encrypted, "enc:k1:") {
+		t.Fatalf("Expected encrypted value, got %v", value)
+	}
+
+	scenarios := []struct {
+		keyring     *security.Keyring
+		value       any
+		expectError bool
+		expected    string
+	}{
+		{keyring, nil, false, ""},
+		{keyring, "plain", false, "plain"},
+		{keyring, []byte("plain"), false, "plain"},
+		{keyring, encrypted, false, "test"},
+		{keyring, []byte(encrypted), false, "test"},
+		{keyring, 123, true, ""},
+		{nil, encrypted, true, ""},
+		{otherKeyring, encrypted, true, ""},
+	}
+
+	for i, s := range scenarios {
+		result := types.EncryptedString("")
+
+		err := result.WithKeyring(s.keyring).Scan(s.value)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if result.String() != s.expected {
+			t.Errorf("(%d) Expected %q, got %q", i, s.expected, result.String())
+		}
+	}
+}
+
+func TestParseEncryptedJsonRaw(t *testing.T) {
+	keyring, _ := security.NewKeyring("k1", map[string]string{
+		"k1": "abcdabcdabcdabcdabcdabcdabcdabcd",
+	})
+
+	encrypted, err := types.EncryptedJsonRaw(`{"a":123}`).Encrypt(keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encrypted, "enc:k1:") {
+		t.Fatalf("Expected encrypted value, got %v", encrypted)
+	}
+
+	scenarios := []struct {
+		text        string
+		keyring     *security.Keyring
+		expectError bool
+		expected    string
+	}{
+		{"", keyring, false, ""},
+		{`{"a":123}`, keyring, false, `{"a":123}`},
+		{"test", keyring, false, "test"},
+		{encrypted, keyring, false, `{"a":123}`},
+		{encrypted, nil, true, ""},
+	}
+
+	for i, s := range scenarios {
+		result, err := types.ParseEncryptedJsonRaw(s.text, s.keyring)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if result.String() != s.expected {
+			t.Errorf("(%d) Expected %q, got %q", i, s.expected, result.String())
+		}
+	}
+}
+
+func TestEncryptedJsonRawMarshalJSON(t *testing.T) {
+	scenarios := []struct {
+		value    types.EncryptedJsonRaw
+		expected string
+	}{
+		{nil, "null"},
+		{types.EncryptedJsonRaw(`{"a":123}`), `{"a":123}`},
+	}
+
+	for i, s := range scenarios {
+		result, err := json.Marshal(s.value)
+		if err != nil {
+			t.Errorf("(%d) %v", i, err)
+			continue
+		}
+
+		if string(result) != s.expected {
+			t.Errorf("(%d) Expected %s, got %s", i, s.expected, result)
+		}
+	}
+}
+
+func TestEncryptedJsonRawValueValueAndScan(t *testing.T) {
+	keyring, _ := security.NewKeyring("k1", map[string]string{
+		"k1": "abcdabcdabcdabcdabcdabcdabcdabcd",
+	})
+
+	empty := types.EncryptedJsonRaw(nil)
+	emptyValue, err := empty.WithKeyring(keyring).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emptyValue != nil {
+		t.Fatalf("Expected nil value, got %v", emptyValue)
+	}
+
+	data := types.EncryptedJsonRaw(`{"a":123}`)
+	value, err := data.WithKeyring(keyring).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, _ := value.(string)
+	if !strings.HasPrefix(encrypted, "enc:k1:") {
+		t.Fatalf("Expected encrypted value, got %v", value)
+	}
+
+	scenarios := []struct {
+		keyring     *security.Keyring
+		value       any
+		expectError bool
+		expected    string
+	}{
+		{keyring, nil, false, ""},
+		{keyring, `{"a":123}`, false, `{"a":123}`},
+		{keyring, []byte(`[1,2]`), false, `[1,2]`},
+		{keyring, "test", false, "test"},
+		{keyring, encrypted, false, `{"a":123}`},
+		{keyring, []byte(encrypted), false, `{"a":123}`},
+		{nil, encrypted, true, ""},
+	}
+
+	for i, s := range scenarios {
+		result := types.EncryptedJsonRaw(nil)
+
+		err := result.WithKeyring(s.keyring).Scan(s.value)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if result.String() != s.expected {
+			t.Errorf("(%d) Expected %q, got %q", i, s.expected, result.String())
+		}
+	}
+}